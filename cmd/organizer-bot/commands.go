@@ -0,0 +1,392 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/backup"
+	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/bwmarrin/discordgo"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// commands are run instead of the bot, when their name is the first argument,
+// e.g. "organizer-bot migrate-json". They use only the storage configuration.
+var commands = map[string]func(ctx context.Context, args []string) error{
+	"migrate-json": migrateJSONCommand,
+	"reindex":      reindexCommand,
+	"export":       exportCommand,
+	"import":       importCommand,
+}
+
+func runCommand(ctx context.Context, name string, args []string) error {
+	command, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %s", name)
+	}
+
+	if err := loadStorageConfig(); err != nil {
+		return err
+	}
+
+	return command(ctx, args)
+}
+
+// loadStorageConfig loads only the configuration of the stores, so the commands
+// do not require the Discord token.
+func loadStorageConfig() error {
+	for prefix, spec := range map[string]interface{}{
+		envconfigPrefix + "_storage": &cfg.Storage,
+		envconfigPrefix + "_redis":   &cfg.Redis,
+		envconfigPrefix + "_sql":     &cfg.SQL,
+	} {
+		if err := envconfig.Process(prefix, spec); err != nil {
+			return errors.Wrapf(err, "while loading %s config", prefix)
+		}
+	}
+
+	return nil
+}
+
+// migrateJSONCommand rewrites the reminders and todo entries stored in Redis with gob
+// as versioned JSON. The stores read both formats, so it can run while the bot is running.
+func migrateJSONCommand(ctx context.Context, args []string) error {
+	if cfg.Storage.Driver != storageDriverRedis {
+		return fmt.Errorf("only the %s storage has data to migrate", storageDriverRedis)
+	}
+
+	if err := setupStores(ctx); err != nil {
+		return err
+	}
+
+	reminders, skippedReminders, err := reminder.NewRedisReminderStore(rdb).MigrateToJSON(ctx)
+	log.WithFields(log.Fields{"keys": reminders, "skipped": skippedReminders}).Info("migrated reminders to JSON")
+	if err != nil {
+		return errors.Wrap(err, "while migrating reminders")
+	}
+
+	entries, skippedEntries, err := todo.NewRedisTodoStore(rdb).MigrateToJSON(ctx)
+	log.WithFields(log.Fields{"keys": entries, "skipped": skippedEntries}).Info("migrated todo entries to JSON")
+	if err != nil {
+		return errors.Wrap(err, "while migrating todo entries")
+	}
+
+	// the skipped keys were changed by the bot, which writes JSON, but they are checked again to be sure
+	if skippedReminders+skippedEntries > 0 {
+		log.Warn("some keys changed during the migration, run migrate-json again to rewrite them")
+	}
+
+	return nil
+}
+
+// reindexCommand builds the Redis indexes of the reminders and todo entries stored before
+// the indexes were kept. It only adds to the indexes, so it can run while the bot is running.
+func reindexCommand(ctx context.Context, args []string) error {
+	if cfg.Storage.Driver != storageDriverRedis {
+		return fmt.Errorf("only the %s storage has indexes to rebuild", storageDriverRedis)
+	}
+
+	if err := setupStores(ctx); err != nil {
+		return err
+	}
+
+	reminders, err := reminder.NewRedisReminderStore(rdb).Reindex(ctx)
+	if err != nil {
+		return errors.Wrap(err, "while reindexing reminders")
+	}
+	log.WithField("keys", reminders).Info("reindexed reminders")
+
+	entries, err := todo.NewRedisTodoStore(rdb).Reindex(ctx)
+	if err != nil {
+		return errors.Wrap(err, "while reindexing todo entries")
+	}
+	log.WithField("keys", entries).Info("reindexed todo entries")
+
+	return nil
+}
+
+// stringsFlag is a flag, which can be given many times.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func backupStores() *backup.Stores {
+	return &backup.Stores{
+		Config:    configStore,
+		Reminders: reminderStore,
+		Todo:      todoStore,
+	}
+}
+
+// archivedThreadsPageSize is the most archived threads Discord returns in one request.
+const archivedThreadsPageSize = 100
+
+// discordSession returns a session for the REST API. It needs only the Discord token
+// from the configuration.
+func discordSession() (*discordgo.Session, error) {
+	var discordCfg struct {
+		DiscordToken string `required:"true"`
+	}
+	if err := envconfig.Process(envconfigPrefix, &discordCfg); err != nil {
+		return nil, errors.Wrap(err, "while loading Discord config")
+	}
+
+	session, err := discordgo.New("Bot " + discordCfg.DiscordToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "while creating Discord session")
+	}
+
+	return session, nil
+}
+
+// guildChannelIDs returns the channels of the guild with their active and archived threads.
+func guildChannelIDs(session *discordgo.Session, guildID string) ([]string, error) {
+	channels, err := session.GuildChannels(guildID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while getting channels of guild %s", guildID)
+	}
+
+	// GuildChannels does not return threads
+	active, err := session.GuildThreadsActive(guildID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while getting active threads of guild %s", guildID)
+	}
+
+	channelIDs := make([]string, 0, len(channels)+len(active.Threads))
+	for _, channel := range channels {
+		channelIDs = append(channelIDs, channel.ID)
+
+		switch channel.Type {
+		case discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews, discordgo.ChannelTypeGuildForum:
+		default:
+			continue
+		}
+
+		threads, err := archivedThreadIDs(session, channel.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while getting archived threads of channel %s", channel.ID)
+		}
+
+		channelIDs = append(channelIDs, threads...)
+	}
+
+	for _, thread := range active.Threads {
+		channelIDs = append(channelIDs, thread.ID)
+	}
+
+	return channelIDs, nil
+}
+
+// archivedThreadIDs returns the public and private archived threads of the channel. The private
+// ones need the Manage Threads permission, without it they are left out with a warning.
+func archivedThreadIDs(session *discordgo.Session, channelID string) ([]string, error) {
+	threads, err := pagedThreadIDs(session.ThreadsArchived, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	private, err := pagedThreadIDs(session.ThreadsPrivateArchived, channelID)
+	if isForbidden(err) {
+		log.WithField("channelID", channelID).Warn("cannot list private archived threads, they are not exported")
+	} else if err != nil {
+		return nil, err
+	}
+
+	return append(threads, private...), nil
+}
+
+func pagedThreadIDs(list func(channelID string, before *time.Time, limit int, options ...discordgo.RequestOption) (*discordgo.ThreadsList, error), channelID string) ([]string, error) {
+	var (
+		threadIDs []string
+		before    *time.Time
+	)
+
+	for {
+		page, err := list(channelID, before, archivedThreadsPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, thread := range page.Threads {
+			threadIDs = append(threadIDs, thread.ID)
+
+			if thread.ThreadMetadata != nil {
+				archivedAt := thread.ThreadMetadata.ArchiveTimestamp
+				before = &archivedAt
+			}
+		}
+
+		if !page.HasMore || len(page.Threads) == 0 {
+			return threadIDs, nil
+		}
+	}
+}
+
+func isForbidden(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusForbidden
+}
+
+// exportCommand writes the data of the channels of a guild or of the given channels
+// as a JSON archive, e.g. "organizer-bot export --guild 1234 > dump.json".
+func exportCommand(ctx context.Context, args []string) error {
+	var (
+		flags      = flag.NewFlagSet("export", flag.ContinueOnError)
+		guildID    = flags.String("guild", "", "export the channels and threads of the guild, needs APP_DISCORDTOKEN")
+		output     = flags.String("output", "", "write the archive to the file instead of stdout")
+		channelIDs stringsFlag
+		userIDs    stringsFlag
+	)
+	flags.Var(&channelIDs, "channel", "export the channel, can be given many times")
+	flags.Var(&userIDs, "user", "export the DM channel with the user, can be given many times, needs APP_DISCORDTOKEN")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *guildID != "" || len(userIDs) > 0 {
+		session, err := discordSession()
+		if err != nil {
+			return err
+		}
+
+		if *guildID != "" {
+			guildChannels, err := guildChannelIDs(session, *guildID)
+			if err != nil {
+				return err
+			}
+
+			channelIDs = append(channelIDs, guildChannels...)
+		}
+
+		for _, userID := range userIDs {
+			dmChannel, err := session.UserChannelCreate(userID)
+			if err != nil {
+				return errors.Wrapf(err, "while getting DM channel of user %s", userID)
+			}
+
+			channelIDs = append(channelIDs, dmChannel.ID)
+		}
+	}
+
+	if len(channelIDs) == 0 {
+		return errors.New("no channels to export, use --guild, --channel or --user")
+	}
+
+	// a channel can be given also with --channel
+	seen := make(map[string]bool, len(channelIDs))
+	uniqueIDs := make([]string, 0, len(channelIDs))
+	for _, channelID := range channelIDs {
+		if !seen[channelID] {
+			seen[channelID] = true
+			uniqueIDs = append(uniqueIDs, channelID)
+		}
+	}
+
+	if err := setupStores(ctx); err != nil {
+		return err
+	}
+
+	archive, err := backup.Export(ctx, backupStores(), uniqueIDs)
+	if err != nil {
+		return err
+	}
+	archive.GuildID = *guildID
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return errors.Wrap(err, "while creating output file")
+		}
+		defer file.Close()
+
+		w = file
+	}
+
+	if err := backup.WriteArchive(w, archive); err != nil {
+		return err
+	}
+
+	log.WithField("channels", len(archive.Channels)).Info("exported channels")
+	return nil
+}
+
+// importCommand writes a JSON archive created by exportCommand to the stores,
+// e.g. "organizer-bot import --dry-run < dump.json".
+func importCommand(ctx context.Context, args []string) error {
+	var (
+		flags      = flag.NewFlagSet("import", flag.ContinueOnError)
+		input      = flags.String("input", "", "read the archive from the file instead of stdin")
+		dryRun     = flags.Bool("dry-run", false, "only print the changes")
+		onConflict = flags.String("on-conflict", string(backup.ConflictFail), "what to do with data, which already exists: fail, skip or overwrite")
+	)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	policy, err := backup.ParseConflictPolicy(*onConflict)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "" {
+		file, err := os.Open(*input)
+		if err != nil {
+			return errors.Wrap(err, "while opening input file")
+		}
+		defer file.Close()
+
+		r = file
+	}
+
+	archive, err := backup.ReadArchive(r)
+	if err != nil {
+		return err
+	}
+
+	if err := setupStores(ctx); err != nil {
+		return err
+	}
+
+	changes, err := backup.Import(ctx, backupStores(), archive, backup.ImportOptions{
+		DryRun:     *dryRun,
+		OnConflict: policy,
+	})
+
+	if conflictErr, ok := err.(*backup.ConflictError); ok {
+		for _, conflict := range conflictErr.Conflicts {
+			fmt.Printf("conflict: %s %q in channel %s\n", conflict.Kind, conflict.Name, conflict.ChannelID)
+		}
+		return errors.Wrap(err, "nothing was imported, use --on-conflict skip or overwrite")
+	}
+
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"changes": len(changes), "dryRun": *dryRun}).Info("imported archive")
+	return nil
+}
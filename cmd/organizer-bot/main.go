@@ -24,17 +24,30 @@ import (
 	"github.com/Trojan295/organizer-bot/internal/metrics"
 	"github.com/Trojan295/organizer-bot/internal/organizer"
 	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/Trojan295/organizer-bot/internal/sqlutils"
 	"github.com/Trojan295/organizer-bot/internal/todo"
 	"github.com/bwmarrin/discordgo"
 	"github.com/kelseyhightower/envconfig"
 )
 
 type RedisConfig struct {
-	Address  string `required:"true"`
+	Address  string
 	Password string
 	DB       int
 }
 
+// SQLConfig is used by the sqlite and postgres storage drivers. DSN is the file name
+// for SQLite and the connection string for PostgreSQL.
+type SQLConfig struct {
+	DSN string
+}
+
+// StorageConfig selects the backend of the stores. The memory driver keeps
+// everything in the process and is meant for local development and tests.
+type StorageConfig struct {
+	Driver string `default:"redis"`
+}
+
 type TestingConfig struct {
 	GuildID string
 }
@@ -42,12 +55,19 @@ type TestingConfig struct {
 type Config struct {
 	DiscordToken string `required:"true"`
 
+	Storage StorageConfig
 	Redis   RedisConfig
+	SQL     SQLConfig
 	Testing TestingConfig
 }
 
 const (
 	envconfigPrefix = "app"
+
+	storageDriverRedis    = "redis"
+	storageDriverSQLite   = "sqlite"
+	storageDriverPostgres = "postgres"
+	storageDriverMemory   = "memory"
 )
 
 var (
@@ -59,9 +79,10 @@ var (
 	requestTimeout            = 10 * time.Second
 
 	rdb           *redis.Client
-	reminderStore *reminder.RedisReminderStore
-	todoStore     *todo.RedisTodoStore
-	configStore   *organizer.RedisConfigStore
+	sqlDB         *sqlutils.DB
+	reminderStore reminder.Store
+	todoStore     todo.EntryStore
+	configStore   organizer.ConfigStore
 )
 
 func setupRedisClient() {
@@ -72,6 +93,48 @@ func setupRedisClient() {
 	})
 }
 
+func setupStores(ctx context.Context) error {
+	switch cfg.Storage.Driver {
+	case storageDriverRedis:
+		if cfg.Redis.Address == "" {
+			return errors.New("required key APP_REDIS_ADDRESS missing value")
+		}
+
+		setupRedisClient()
+
+		configStore = organizer.NewRedisConfigStore(rdb)
+		reminderStore = reminder.NewRedisReminderStore(rdb)
+		todoStore = todo.NewRedisTodoStore(rdb)
+
+	case storageDriverSQLite, storageDriverPostgres:
+		if cfg.SQL.DSN == "" {
+			return errors.New("required key APP_SQL_DSN missing value")
+		}
+
+		var err error
+		sqlDB, err = sqlutils.Open(ctx, sqlutils.Dialect(cfg.Storage.Driver), cfg.SQL.DSN)
+		if err != nil {
+			return errors.Wrap(err, "while opening SQL database")
+		}
+
+		configStore = organizer.NewSQLConfigStore(sqlDB)
+		reminderStore = reminder.NewSQLReminderStore(sqlDB)
+		todoStore = todo.NewSQLTodoStore(sqlDB)
+
+	case storageDriverMemory:
+		log.Warn("using the memory storage, the data is lost on restart")
+
+		configStore = organizer.NewMemoryConfigStore()
+		reminderStore = reminder.NewMemoryReminderStore()
+		todoStore = todo.NewMemoryTodoStore()
+
+	default:
+		return fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+
+	return nil
+}
+
 func getRootModule() (*root.Module, error) {
 	rootModule, err := root.NewModule(&root.ModuleConfig{
 		Name: "organizer",
@@ -80,12 +143,9 @@ func getRootModule() (*root.Module, error) {
 		return nil, errors.Wrap(err, "while creating root module")
 	}
 
-	configStore = organizer.NewRedisConfigStore(rdb)
-	reminderStore = reminder.NewRedisReminderStore(rdb)
-	todoStore = todo.NewRedisTodoStore(rdb)
-
 	todoModule, err := discordtodo.NewTodoModule(&discordtodo.ModuleConfig{
-		TodoRepo: todoStore,
+		TodoRepo:           todoStore,
+		TimezoneRepository: configStore,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "while creating TodoModule")
@@ -100,7 +160,8 @@ func getRootModule() (*root.Module, error) {
 	}
 
 	configModule, err := common.NewConfigModule(&common.ConfigModuleInput{
-		TimezoneRepository: configStore,
+		TimezoneRepository:       configStore,
+		DigestScheduleRepository: configStore,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "while creating ConfigModule")
@@ -112,8 +173,8 @@ func getRootModule() (*root.Module, error) {
 }
 
 func getReminderService() *reminder.Service {
-	sender := message.NewSender(ds)
-	return reminder.NewService(sender, reminderStore)
+	sender := discordreminder.NewSender(ds)
+	return reminder.NewService(sender, reminderStore, configStore)
 }
 
 func getTodoService() (*todo.Notifier, error) {
@@ -122,6 +183,7 @@ func getTodoService() (*todo.Notifier, error) {
 		Pusher:        sender,
 		Store:         todoStore,
 		TimezoneStore: configStore,
+		ScheduleStore: configStore,
 	})
 	if err != nil {
 		return nil, err
@@ -141,6 +203,8 @@ func setupCommandHandlers(s *discordgo.Session, rootModule *root.Module) {
 
 	applicationCommandInteractionHandlers := rootModule.GetApplicationCommandInteractionHandlers()
 	messageComponentInteractionHandlers := rootModule.GetMessageComponentInteractionHandlers()
+	modalSubmitInteractionHandlers := rootModule.GetModalSubmitInteractionHandlers()
+	autocompleteInteractionHandlers := rootModule.GetAutocompleteInteractionHandlers()
 
 	s.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
@@ -157,12 +221,29 @@ func setupCommandHandlers(s *discordgo.Session, rootModule *root.Module) {
 			}
 
 		case discordgo.InteractionMessageComponent:
-			cmd := i.MessageComponentData().CustomID
+			cmd, _ := common.ParseCustomID(i.MessageComponentData().CustomID)
 			if f, ok := messageComponentInteractionHandlers[cmd]; ok {
 				f(ctx, s, i)
 			} else {
 				log.WithField("customID", cmd).Warn("failed to find message component interaction")
 			}
+
+		case discordgo.InteractionModalSubmit:
+			cmd, _ := common.ParseCustomID(i.ModalSubmitData().CustomID)
+			if f, ok := modalSubmitInteractionHandlers[cmd]; ok {
+				f(ctx, s, i)
+			} else {
+				log.WithField("customID", cmd).Warn("failed to find modal submit interaction")
+			}
+
+		case discordgo.InteractionApplicationCommandAutocomplete:
+			cmd := i.ApplicationCommandData().Options[0].Name
+
+			if f, ok := autocompleteInteractionHandlers[cmd]; ok {
+				f(ctx, s, i, i.ApplicationCommandData().Options[0])
+			} else {
+				log.WithField("command name", cmd).Warn("failed to find autocomplete interaction")
+			}
 		}
 	})
 }
@@ -188,11 +269,24 @@ func main() {
 		log.Fatal(fmt.Sprintf("while setup logging: %v", err))
 	}
 
+	if len(os.Args) > 1 {
+		if err := runCommand(ctx, os.Args[1], os.Args[2:]); err != nil {
+			log.WithError(err).Fatalf("failed to run command %s", os.Args[1])
+		}
+		return
+	}
+
 	if err := envconfig.Process(envconfigPrefix, &cfg); err != nil {
 		log.WithError(err).Fatal("failed to load envconfig")
 	}
 
-	setupRedisClient()
+	if err := setupStores(ctx); err != nil {
+		log.WithError(err).Fatal("failed to setup stores")
+	}
+
+	if sqlDB != nil {
+		defer sqlDB.Close()
+	}
 
 	ds, err = discordgo.New("Bot " + cfg.DiscordToken)
 	if err != nil {
@@ -0,0 +1,273 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/pkg/errors"
+)
+
+// ArchiveVersion is the version of the archive format written by Export. Archives
+// with a newer version are rejected by ReadArchive.
+const ArchiveVersion = 1
+
+// Archive is a portable copy of the data of the channels. It does not depend on the
+// storage driver, so it can be imported into any of them.
+type Archive struct {
+	Version    int        `json:"version"`
+	ExportedAt time.Time  `json:"exportedAt"`
+	GuildID    string     `json:"guildID,omitempty"`
+	Channels   []*Channel `json:"channels"`
+}
+
+// Channel contains the settings, reminders and todo lists of a channel. The settings
+// are kept in the formats, in which users enter them, e.g. "inactivity:30".
+type Channel struct {
+	ID             string           `json:"id"`
+	Timezone       string           `json:"timezone,omitempty"`
+	DigestSchedule string           `json:"digestSchedule,omitempty"`
+	ExpiryPolicy   string           `json:"expiryPolicy,omitempty"`
+	BoardMessageID string           `json:"boardMessageID,omitempty"`
+	Reminders      []*Reminder      `json:"reminders,omitempty"`
+	Lists          []*List          `json:"lists,omitempty"`
+	Archive        []*ArchivedEntry `json:"archive,omitempty"`
+}
+
+// IsEmpty returns true, if there is nothing to import in the channel.
+func (ch *Channel) IsEmpty() bool {
+	return ch.Timezone == "" && ch.DigestSchedule == "" && ch.ExpiryPolicy == "" && ch.BoardMessageID == "" &&
+		len(ch.Reminders) == 0 && len(ch.Lists) == 0 && len(ch.Archive) == 0
+}
+
+type Reminder struct {
+	Title      string     `json:"title"`
+	Date       *time.Time `json:"date,omitempty"`
+	Recurrence string     `json:"recurrence,omitempty"`
+	UserID     string     `json:"userID,omitempty"`
+	Mentions   *Mentions  `json:"mentions,omitempty"`
+	// LeadTimes are durations, e.g. "15m0s".
+	LeadTimes []string `json:"leadTimes,omitempty"`
+}
+
+type Mentions struct {
+	UserIDs []string `json:"userIDs,omitempty"`
+	RoleIDs []string `json:"roleIDs,omitempty"`
+	Here    bool     `json:"here,omitempty"`
+}
+
+// List is a todo list with the entries in their order. Name is empty for the default list.
+type List struct {
+	Name    string   `json:"name,omitempty"`
+	Entries []*Entry `json:"entries"`
+}
+
+type Entry struct {
+	Text    string     `json:"text"`
+	DueDate *time.Time `json:"dueDate,omitempty"`
+	// Priority is the priority name, e.g. "high".
+	Priority     string     `json:"priority,omitempty"`
+	AssigneeID   string     `json:"assigneeID,omitempty"`
+	CreatedBy    string     `json:"createdBy,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	Subtasks     []*Subtask `json:"subtasks,omitempty"`
+	AutoComplete bool       `json:"autoComplete,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+}
+
+type Subtask struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+	Done bool   `json:"done,omitempty"`
+}
+
+// ArchivedEntry is a completed entry. It keeps its ID, so it can be restored with
+// the ID shown in the history.
+type ArchivedEntry struct {
+	ID          string    `json:"id"`
+	List        string    `json:"list,omitempty"`
+	CompletedAt time.Time `json:"completedAt"`
+	CompletedBy string    `json:"completedBy,omitempty"`
+	Entry
+}
+
+// WriteArchive writes the archive as indented JSON.
+func WriteArchive(w io.Writer, archive *Archive) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(archive); err != nil {
+		return errors.Wrap(err, "while encoding archive")
+	}
+
+	return nil
+}
+
+// ReadArchive reads an archive written by WriteArchive.
+func ReadArchive(r io.Reader) (*Archive, error) {
+	archive := &Archive{}
+	if err := json.NewDecoder(r).Decode(archive); err != nil {
+		return nil, errors.Wrap(err, "while decoding archive")
+	}
+
+	if archive.Version == 0 {
+		return nil, errors.New("archive has no version")
+	} else if archive.Version > ArchiveVersion {
+		return nil, fmt.Errorf("archive version %d is newer than the supported %d", archive.Version, ArchiveVersion)
+	}
+
+	return archive, nil
+}
+
+func listName(name string) string {
+	if name == "" {
+		return todo.DefaultList
+	}
+
+	return name
+}
+
+func archiveListName(name string) string {
+	if name == todo.DefaultList {
+		return ""
+	}
+
+	return name
+}
+
+func newReminder(rem *reminder.Reminder) *Reminder {
+	archived := &Reminder{
+		Title:      rem.Title,
+		Date:       rem.Date,
+		Recurrence: rem.Recurrence,
+		UserID:     rem.UserID,
+	}
+
+	if !rem.Mentions.IsEmpty() {
+		archived.Mentions = &Mentions{
+			UserIDs: rem.Mentions.UserIDs,
+			RoleIDs: rem.Mentions.RoleIDs,
+			Here:    rem.Mentions.Here,
+		}
+	}
+
+	for _, leadTime := range rem.LeadTimes {
+		archived.LeadTimes = append(archived.LeadTimes, leadTime.String())
+	}
+
+	return archived
+}
+
+func (r *Reminder) reminder(channelID string) (*reminder.Reminder, error) {
+	rem := &reminder.Reminder{
+		ChannelID:  channelID,
+		Title:      r.Title,
+		Date:       r.Date,
+		Recurrence: r.Recurrence,
+		UserID:     r.UserID,
+	}
+
+	if r.Mentions != nil {
+		rem.Mentions = reminder.Mentions{
+			UserIDs: r.Mentions.UserIDs,
+			RoleIDs: r.Mentions.RoleIDs,
+			Here:    r.Mentions.Here,
+		}
+	}
+
+	for _, leadTime := range r.LeadTimes {
+		duration, err := time.ParseDuration(leadTime)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while parsing lead time of reminder %q", r.Title)
+		}
+
+		rem.LeadTimes = append(rem.LeadTimes, duration)
+	}
+
+	return rem, nil
+}
+
+func newEntry(entry *todo.Entry) *Entry {
+	archived := &Entry{
+		Text:         entry.Text,
+		DueDate:      entry.DueDate,
+		AssigneeID:   entry.AssigneeID,
+		CreatedBy:    entry.CreatedBy,
+		CreatedAt:    entry.CreatedAt,
+		AutoComplete: entry.AutoComplete,
+		Tags:         entry.Tags,
+	}
+
+	if entry.Priority != todo.PriorityNormal {
+		archived.Priority = entry.Priority.String()
+	}
+
+	for _, subtask := range entry.Subtasks {
+		archived.Subtasks = append(archived.Subtasks, &Subtask{ID: subtask.ID, Text: subtask.Text, Done: subtask.Done})
+	}
+
+	return archived
+}
+
+func (e *Entry) entry() (*todo.Entry, error) {
+	entry := &todo.Entry{
+		Text:         e.Text,
+		DueDate:      e.DueDate,
+		AssigneeID:   e.AssigneeID,
+		CreatedBy:    e.CreatedBy,
+		CreatedAt:    e.CreatedAt,
+		AutoComplete: e.AutoComplete,
+		Tags:         e.Tags,
+	}
+
+	if e.Priority != "" {
+		priority, err := todo.ParsePriority(e.Priority)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while parsing priority of entry %q", e.Text)
+		}
+
+		entry.Priority = priority
+	}
+
+	for _, subtask := range e.Subtasks {
+		entry.Subtasks = append(entry.Subtasks, &todo.Subtask{ID: subtask.ID, Text: subtask.Text, Done: subtask.Done})
+	}
+
+	return entry, nil
+}
+
+func newArchivedEntry(archived *todo.ArchivedEntry) *ArchivedEntry {
+	entry := &ArchivedEntry{
+		ID:          archived.Entry.ID,
+		List:        archiveListName(archived.ListName),
+		CompletedBy: archived.Entry.CompletedBy,
+		Entry:       *newEntry(archived.Entry),
+	}
+
+	if archived.Entry.CompletedAt != nil {
+		entry.CompletedAt = *archived.Entry.CompletedAt
+	}
+
+	return entry
+}
+
+func (e *ArchivedEntry) archivedEntry() (*todo.ArchivedEntry, error) {
+	entry, err := e.Entry.entry()
+	if err != nil {
+		return nil, err
+	}
+
+	completedAt := e.CompletedAt
+	entry.ID = e.ID
+	entry.CompletedAt = &completedAt
+	entry.CompletedBy = e.CompletedBy
+	entry.UpdatedAt = completedAt
+
+	return &todo.ArchivedEntry{
+		Entry:    entry,
+		ListName: listName(e.List),
+	}, nil
+}
@@ -0,0 +1,271 @@
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/backup"
+	"github.com/Trojan295/organizer-bot/internal/organizer"
+	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func memoryStores() *backup.Stores {
+	return &backup.Stores{
+		Config:    organizer.NewMemoryConfigStore(),
+		Reminders: reminder.NewMemoryReminderStore(),
+		Todo:      todo.NewMemoryTodoStore(),
+	}
+}
+
+// seedStores adds data of every kind to the channel.
+func seedStores(t *testing.T, stores *backup.Stores, channelID string) {
+	ctx := context.Background()
+
+	location, err := time.LoadLocation("Europe/Warsaw")
+	require.NoError(t, err)
+	require.NoError(t, stores.Config.SetCurrentTimezone(ctx, channelID, location))
+
+	schedule, err := organizer.ParseDigestSchedule("weekdays 9:00 skip-unchanged")
+	require.NoError(t, err)
+	require.NoError(t, stores.Config.SetDigestSchedule(ctx, channelID, schedule))
+
+	require.NoError(t, stores.Todo.SetExpiryPolicy(ctx, channelID, todo.ExpiryPolicy{Mode: todo.ExpiryCompletion, Days: 7}))
+	require.NoError(t, stores.Todo.SetBoardMessageID(ctx, channelID, "messageID"))
+
+	date := time.Date(2030, 1, 2, 9, 0, 0, 0, time.UTC)
+	_, err = stores.Reminders.AddReminder(ctx, channelID, &reminder.Reminder{
+		Title:      "Standup",
+		Date:       &date,
+		Recurrence: "FREQ=DAILY",
+		Mentions:   reminder.Mentions{RoleIDs: []string{"roleID"}},
+		LeadTimes:  []time.Duration{15 * time.Minute},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, stores.Todo.CreateList(ctx, channelID, "backlog"))
+	for _, entry := range []*todo.Entry{
+		{Text: "release", Priority: todo.PriorityHigh, Tags: []string{"ops"}},
+		{Text: "docs", Subtasks: todo.ParseSubtasks("readme, changelog")},
+	} {
+		_, err := stores.Todo.AddEntry(ctx, channelID, "backlog", entry)
+		require.NoError(t, err)
+	}
+
+	// the order differs from the one by priority
+	_, err = stores.Todo.MoveEntry(ctx, channelID, "backlog", 1, 0)
+	require.NoError(t, err)
+
+	ID, err := stores.Todo.AddEntry(ctx, channelID, todo.DefaultList, &todo.Entry{Text: "deploy"})
+	require.NoError(t, err)
+	_, err = stores.Todo.CompleteEntry(ctx, channelID, todo.DefaultList, ID, "userID")
+	require.NoError(t, err)
+}
+
+func channelsJSON(t *testing.T, archive *backup.Archive) string {
+	data, err := json.Marshal(archive.Channels)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	source := memoryStores()
+	seedStores(t, source, "channelID")
+
+	archive, err := backup.Export(ctx, source, []string{"channelID", "emptyChannelID"})
+	require.NoError(t, err)
+	require.Len(t, archive.Channels, 1)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, backup.WriteArchive(buf, archive))
+
+	read, err := backup.ReadArchive(buf)
+	require.NoError(t, err)
+
+	target := memoryStores()
+	changes, err := backup.Import(ctx, target, read, backup.ImportOptions{OnConflict: backup.ConflictFail})
+	require.NoError(t, err)
+	for _, change := range changes {
+		require.Equal(t, backup.ActionCreate, change.Action, change.String())
+	}
+
+	exported, err := backup.Export(ctx, target, []string{"channelID"})
+	require.NoError(t, err)
+	require.JSONEq(t, channelsJSON(t, archive), channelsJSON(t, exported))
+
+	list, err := target.Todo.GetEntries(ctx, "channelID", "backlog")
+	require.NoError(t, err)
+	require.Equal(t, "docs", list.Entries[0].Text)
+}
+
+func TestImport_Conflicts(t *testing.T) {
+	tt := map[string]struct {
+		policy           backup.ConflictPolicy
+		expectedAction   backup.Action
+		expectedPriority todo.Priority
+	}{
+		"Skip": {
+			policy:           backup.ConflictSkip,
+			expectedAction:   backup.ActionSkip,
+			expectedPriority: todo.PriorityLow,
+		},
+		"Overwrite": {
+			policy:           backup.ConflictOverwrite,
+			expectedAction:   backup.ActionOverwrite,
+			expectedPriority: todo.PriorityHigh,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			stores := memoryStores()
+			seedStores(t, stores, "channelID")
+
+			archive, err := backup.Export(ctx, stores, []string{"channelID"})
+			require.NoError(t, err)
+
+			list, err := stores.Todo.GetEntries(ctx, "channelID", "backlog")
+			require.NoError(t, err)
+			release := list.Entries[1]
+			release.Priority = todo.PriorityLow
+			require.NoError(t, stores.Todo.UpdateEntry(ctx, "channelID", "backlog", release))
+
+			// the board in the channel is not a conflict
+			require.NoError(t, stores.Todo.SetBoardMessageID(ctx, "channelID", "otherMessageID"))
+
+			_, err = backup.Import(ctx, stores, archive, backup.ImportOptions{OnConflict: backup.ConflictFail})
+			conflictErr, ok := err.(*backup.ConflictError)
+			require.True(t, ok)
+			// the reminder, two entries and the archived entry, the settings did not change
+			require.Len(t, conflictErr.Conflicts, 4)
+
+			changes, err := backup.Import(ctx, stores, archive, backup.ImportOptions{OnConflict: test.policy})
+			require.NoError(t, err)
+			require.Len(t, changes, 4)
+			for _, change := range changes {
+				require.Equal(t, test.expectedAction, change.Action, change.String())
+			}
+
+			reminders, err := stores.Reminders.GetReminders(ctx, "channelID")
+			require.NoError(t, err)
+			require.Len(t, reminders, 1)
+
+			release, err = stores.Todo.GetEntry(ctx, "channelID", "backlog", release.ID)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedPriority, release.Priority)
+
+			list, err = stores.Todo.GetEntries(ctx, "channelID", "backlog")
+			require.NoError(t, err)
+			require.Len(t, list.Entries, 2)
+
+			messageID, err := stores.Todo.GetBoardMessageID(ctx, "channelID")
+			require.NoError(t, err)
+			require.Equal(t, "otherMessageID", messageID)
+		})
+	}
+}
+
+func TestImport_DuplicateTexts(t *testing.T) {
+	ctx := context.Background()
+
+	stores := memoryStores()
+	for _, priority := range []todo.Priority{todo.PriorityLow, todo.PriorityNormal} {
+		_, err := stores.Todo.AddEntry(ctx, "channelID", todo.DefaultList, &todo.Entry{Text: "review", Priority: priority})
+		require.NoError(t, err)
+	}
+
+	archive, err := backup.Export(ctx, stores, []string{"channelID"})
+	require.NoError(t, err)
+
+	list, err := stores.Todo.GetEntries(ctx, "channelID", todo.DefaultList)
+	require.NoError(t, err)
+	for _, entry := range list.Entries {
+		entry.Priority = todo.PriorityUrgent
+		require.NoError(t, stores.Todo.UpdateEntry(ctx, "channelID", todo.DefaultList, entry))
+	}
+
+	// each existing entry is overwritten by one of the archived ones
+	changes, err := backup.Import(ctx, stores, archive, backup.ImportOptions{OnConflict: backup.ConflictOverwrite})
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	for _, change := range changes {
+		require.Equal(t, backup.ActionOverwrite, change.Action, change.String())
+	}
+
+	list, err = stores.Todo.GetEntries(ctx, "channelID", todo.DefaultList)
+	require.NoError(t, err)
+	require.Len(t, list.Entries, 2)
+
+	priorities := []todo.Priority{list.Entries[0].Priority, list.Entries[1].Priority}
+	require.ElementsMatch(t, []todo.Priority{todo.PriorityNormal, todo.PriorityLow}, priorities)
+}
+
+func TestImport_PastReminders(t *testing.T) {
+	ctx := context.Background()
+	past := time.Now().Add(-48 * time.Hour)
+
+	archive := &backup.Archive{
+		Version: backup.ArchiveVersion,
+		Channels: []*backup.Channel{{
+			ID: "channelID",
+			Reminders: []*backup.Reminder{
+				{Title: "Once", Date: &past},
+				{Title: "Daily", Date: &past, Recurrence: "FREQ=DAILY"},
+			},
+		}},
+	}
+
+	stores := memoryStores()
+	changes, err := backup.Import(ctx, stores, archive, backup.ImportOptions{OnConflict: backup.ConflictFail})
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, backup.ActionSkip, changes[0].Action, changes[0].String())
+	require.Equal(t, backup.ActionCreate, changes[1].Action, changes[1].String())
+
+	reminders, err := stores.Reminders.GetReminders(ctx, "channelID")
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	require.Equal(t, "Daily", reminders[0].Title)
+	require.True(t, reminders[0].Date.After(time.Now()))
+
+	// the recurring reminder is found, although its date moved forward
+	_, err = backup.Import(ctx, stores, archive, backup.ImportOptions{OnConflict: backup.ConflictFail})
+	conflictErr, ok := err.(*backup.ConflictError)
+	require.True(t, ok)
+	require.Len(t, conflictErr.Conflicts, 1)
+}
+
+func TestImport_DryRun(t *testing.T) {
+	ctx := context.Background()
+
+	source := memoryStores()
+	seedStores(t, source, "channelID")
+
+	archive, err := backup.Export(ctx, source, []string{"channelID"})
+	require.NoError(t, err)
+
+	target := memoryStores()
+	changes, err := backup.Import(ctx, target, archive, backup.ImportOptions{DryRun: true, OnConflict: backup.ConflictFail})
+	require.NoError(t, err)
+	require.NotEmpty(t, changes)
+
+	exported, err := backup.Export(ctx, target, []string{"channelID"})
+	require.NoError(t, err)
+	require.Empty(t, exported.Channels)
+}
+
+func TestReadArchive_NewerVersion(t *testing.T) {
+	_, err := backup.ReadArchive(strings.NewReader(`{"version": 2, "channels": []}`))
+	require.Error(t, err)
+}
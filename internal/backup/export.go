@@ -0,0 +1,124 @@
+package backup
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/organizer"
+	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/pkg/errors"
+)
+
+// Stores are the stores, which keep the data of the channels.
+type Stores struct {
+	Config    organizer.ConfigStore
+	Reminders reminder.Store
+	Todo      todo.EntryStore
+}
+
+// Export copies the data of the channels to an archive. Channels without any data are left out.
+// The delivery state of the reminders and the notification history are not exported.
+func Export(ctx context.Context, stores *Stores, channelIDs []string) (*Archive, error) {
+	archive := &Archive{
+		Version:    ArchiveVersion,
+		ExportedAt: time.Now().UTC(),
+		Channels:   make([]*Channel, 0),
+	}
+
+	for _, channelID := range channelIDs {
+		ch, err := exportChannel(ctx, stores, channelID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while exporting channel %s", channelID)
+		}
+
+		if !ch.IsEmpty() {
+			archive.Channels = append(archive.Channels, ch)
+		}
+	}
+
+	return archive, nil
+}
+
+func exportChannel(ctx context.Context, stores *Stores, channelID string) (*Channel, error) {
+	ch := &Channel{ID: channelID}
+
+	location, err := stores.Config.GetCurrentTimezone(ctx, channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting timezone")
+	} else if location != nil {
+		ch.Timezone = location.String()
+	}
+
+	schedule, err := stores.Config.GetDigestSchedule(ctx, channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting digest schedule")
+	} else if schedule != nil {
+		ch.DigestSchedule = schedule.String()
+	}
+
+	policy, err := stores.Todo.GetExpiryPolicy(ctx, channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting expiry policy")
+	} else if policy != todo.DefaultExpiryPolicy {
+		ch.ExpiryPolicy = policy.String()
+	}
+
+	ch.BoardMessageID, err = stores.Todo.GetBoardMessageID(ctx, channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting board message")
+	}
+
+	reminders, err := stores.Reminders.GetReminders(ctx, channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting reminders")
+	}
+
+	// the stores return the reminders in any order
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].Date.Equal(*reminders[j].Date) {
+			return reminders[i].Date.Before(*reminders[j].Date)
+		}
+		return reminders[i].Title < reminders[j].Title
+	})
+
+	for _, rem := range reminders {
+		ch.Reminders = append(ch.Reminders, newReminder(rem))
+	}
+
+	names, err := stores.Todo.GetLists(ctx, channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting lists")
+	}
+
+	for _, name := range names {
+		list, err := stores.Todo.GetEntries(ctx, channelID, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while getting entries of list %s", name)
+		}
+
+		// the default list always exists, so it is exported only with entries
+		if name == todo.DefaultList && len(list.Entries) == 0 {
+			continue
+		}
+
+		archivedList := &List{Name: archiveListName(name), Entries: make([]*Entry, 0, len(list.Entries))}
+		for _, entry := range list.Entries {
+			archivedList.Entries = append(archivedList.Entries, newEntry(entry))
+		}
+
+		ch.Lists = append(ch.Lists, archivedList)
+	}
+
+	archived, err := stores.Todo.GetArchivedEntries(ctx, channelID, time.Unix(0, 0), time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting archived entries")
+	}
+
+	for _, entry := range archived {
+		ch.Archive = append(ch.Archive, newArchivedEntry(entry))
+	}
+
+	return ch, nil
+}
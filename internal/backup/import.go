@@ -0,0 +1,522 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/organizer"
+	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/pkg/errors"
+)
+
+// ConflictPolicy decides, what happens with the data in the archive, which is already in the stores.
+// Reminders conflict, when they have the same title, date and user, recurring reminders, when
+// they have the same title, recurrence and user, todo entries, when they have the same text
+// on the same list, archived entries, when they have the same ID, and settings, when they are
+// set to a different value. The board message is only imported
+// to channels without a board, it is never a conflict.
+type ConflictPolicy string
+
+const (
+	// ConflictFail aborts the import before anything is written.
+	ConflictFail ConflictPolicy = "fail"
+	// ConflictSkip keeps the data in the stores.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the data in the stores with the data in the archive.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+)
+
+func ParseConflictPolicy(policy string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(strings.ToLower(policy)); p {
+	case ConflictFail, ConflictSkip, ConflictOverwrite:
+		return p, nil
+	}
+
+	return "", fmt.Errorf("unknown conflict policy %s, use %s, %s or %s", policy, ConflictFail, ConflictSkip, ConflictOverwrite)
+}
+
+type ImportOptions struct {
+	// DryRun only returns the changes, which the import would make.
+	DryRun     bool
+	OnConflict ConflictPolicy
+}
+
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionOverwrite Action = "overwrite"
+	ActionSkip      Action = "skip"
+)
+
+// Change is a single item of the archive and what the import does with it.
+type Change struct {
+	ChannelID string
+	// Kind is the kind of the item, e.g. "reminder".
+	Kind   string
+	Name   string
+	Action Action
+}
+
+func (c *Change) String() string {
+	return fmt.Sprintf("%s %s %q in channel %s", c.Action, c.Kind, c.Name, c.ChannelID)
+}
+
+// ConflictError is returned by Import with ConflictFail, when the archive conflicts with the stores.
+type ConflictError struct {
+	Conflicts []*Change
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("archive conflicts with the existing data in %d places", len(e.Conflicts))
+}
+
+type importStep func(ctx context.Context) error
+
+// importer plans the import, before anything is written, so a dry run and
+// a failed import leave the stores unchanged.
+type importer struct {
+	stores    *Stores
+	policy    ConflictPolicy
+	changes   []*Change
+	conflicts []*Change
+	steps     []importStep
+}
+
+// Import writes the archive to the stores and returns the changes. Reminders and todo
+// entries get new IDs, the archived entries keep theirs. Past recurring reminders move to
+// their next occurrence, other past reminders are skipped. With ConflictFail a ConflictError
+// is returned, if any item conflicts, and nothing is written.
+func Import(ctx context.Context, stores *Stores, archive *Archive, opts ImportOptions) ([]*Change, error) {
+	imp := &importer{
+		stores: stores,
+		policy: opts.OnConflict,
+	}
+
+	for _, ch := range archive.Channels {
+		if err := imp.planChannel(ctx, ch); err != nil {
+			return nil, errors.Wrapf(err, "while planning import of channel %s", ch.ID)
+		}
+	}
+
+	if imp.policy == ConflictFail && len(imp.conflicts) > 0 {
+		return imp.changes, &ConflictError{Conflicts: imp.conflicts}
+	}
+
+	if opts.DryRun {
+		return imp.changes, nil
+	}
+
+	for _, step := range imp.steps {
+		if err := step(ctx); err != nil {
+			return imp.changes, errors.Wrap(err, "while importing")
+		}
+	}
+
+	return imp.changes, nil
+}
+
+// add records the change of an item. Conflicting items are overwritten with the overwrite
+// step or skipped, depending on the policy.
+func (imp *importer) add(channelID, kind, name string, conflict bool, create, overwrite importStep) {
+	change := &Change{
+		ChannelID: channelID,
+		Kind:      kind,
+		Name:      name,
+		Action:    ActionCreate,
+	}
+
+	step := create
+	if conflict {
+		imp.conflicts = append(imp.conflicts, change)
+
+		if imp.policy == ConflictOverwrite {
+			change.Action = ActionOverwrite
+			step = overwrite
+		} else {
+			change.Action = ActionSkip
+			step = nil
+		}
+	}
+
+	imp.changes = append(imp.changes, change)
+	if step != nil {
+		imp.steps = append(imp.steps, step)
+	}
+}
+
+func (imp *importer) planChannel(ctx context.Context, ch *Channel) error {
+	for _, plan := range []func(context.Context, *Channel) error{
+		imp.planTimezone,
+		imp.planDigestSchedule,
+		// the policy goes before the entries, as it sets their expiration
+		imp.planExpiryPolicy,
+		imp.planBoardMessage,
+		imp.planReminders,
+		imp.planLists,
+		imp.planArchive,
+	} {
+		if err := plan(ctx, ch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (imp *importer) planTimezone(ctx context.Context, ch *Channel) error {
+	if ch.Timezone == "" {
+		return nil
+	}
+
+	location, err := time.LoadLocation(ch.Timezone)
+	if err != nil {
+		return errors.Wrapf(err, "while loading timezone %s", ch.Timezone)
+	}
+
+	current, err := imp.stores.Config.GetCurrentTimezone(ctx, ch.ID)
+	if err != nil {
+		return errors.Wrap(err, "while getting timezone")
+	}
+
+	if current != nil && current.String() == ch.Timezone {
+		return nil
+	}
+
+	set := func(ctx context.Context) error {
+		return imp.stores.Config.SetCurrentTimezone(ctx, ch.ID, location)
+	}
+
+	imp.add(ch.ID, "timezone", ch.Timezone, current != nil, set, set)
+	return nil
+}
+
+func (imp *importer) planDigestSchedule(ctx context.Context, ch *Channel) error {
+	if ch.DigestSchedule == "" {
+		return nil
+	}
+
+	schedule, err := organizer.ParseDigestSchedule(ch.DigestSchedule)
+	if err != nil {
+		return errors.Wrapf(err, "while parsing digest schedule %s", ch.DigestSchedule)
+	}
+
+	current, err := imp.stores.Config.GetDigestSchedule(ctx, ch.ID)
+	if err != nil {
+		return errors.Wrap(err, "while getting digest schedule")
+	}
+
+	if current != nil && current.String() == schedule.String() {
+		return nil
+	}
+
+	set := func(ctx context.Context) error {
+		return imp.stores.Config.SetDigestSchedule(ctx, ch.ID, schedule)
+	}
+
+	imp.add(ch.ID, "digest schedule", ch.DigestSchedule, current != nil, set, set)
+	return nil
+}
+
+func (imp *importer) planExpiryPolicy(ctx context.Context, ch *Channel) error {
+	if ch.ExpiryPolicy == "" {
+		return nil
+	}
+
+	policy, err := todo.ParseExpiryPolicy(ch.ExpiryPolicy)
+	if err != nil {
+		return errors.Wrapf(err, "while parsing expiry policy %s", ch.ExpiryPolicy)
+	}
+
+	current, err := imp.stores.Todo.GetExpiryPolicy(ctx, ch.ID)
+	if err != nil {
+		return errors.Wrap(err, "while getting expiry policy")
+	}
+
+	if current == policy {
+		return nil
+	}
+
+	set := func(ctx context.Context) error {
+		return imp.stores.Todo.SetExpiryPolicy(ctx, ch.ID, policy)
+	}
+
+	imp.add(ch.ID, "expiry policy", ch.ExpiryPolicy, current != todo.DefaultExpiryPolicy, set, set)
+	return nil
+}
+
+func (imp *importer) planBoardMessage(ctx context.Context, ch *Channel) error {
+	if ch.BoardMessageID == "" {
+		return nil
+	}
+
+	current, err := imp.stores.Todo.GetBoardMessageID(ctx, ch.ID)
+	if err != nil {
+		return errors.Wrap(err, "while getting board message")
+	}
+
+	// the existing board is kept and never is a conflict, as the archived message may not exist
+	// anymore or be in another Discord deployment, then the board is recreated on the next refresh
+	if current != "" {
+		return nil
+	}
+
+	imp.add(ch.ID, "board message", ch.BoardMessageID, false, func(ctx context.Context) error {
+		return imp.stores.Todo.SetBoardMessageID(ctx, ch.ID, ch.BoardMessageID)
+	}, nil)
+	return nil
+}
+
+// reminderKey identifies a reminder in the archive and in the stores. The date of a recurring
+// reminder moves forward after each occurrence, so they are matched by the recurrence instead.
+func reminderKey(rem *reminder.Reminder) string {
+	if rem.Recurrence != "" {
+		return fmt.Sprintf("recurring\x00%s\x00%s\x00%s", rem.Title, rem.Recurrence, rem.UserID)
+	}
+
+	var unix int64
+	if rem.Date != nil {
+		unix = rem.Date.Unix()
+	}
+
+	return fmt.Sprintf("once\x00%s\x00%d\x00%s", rem.Title, unix, rem.UserID)
+}
+
+func (imp *importer) planReminders(ctx context.Context, ch *Channel) error {
+	if len(ch.Reminders) == 0 {
+		return nil
+	}
+
+	current, err := imp.stores.Reminders.GetReminders(ctx, ch.ID)
+	if err != nil {
+		return errors.Wrap(err, "while getting reminders")
+	}
+
+	currentIDs := make(map[string]string, len(current))
+	for _, rem := range current {
+		currentIDs[reminderKey(rem)] = rem.ID
+	}
+
+	location, err := imp.channelLocation(ctx, ch)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for _, archived := range ch.Reminders {
+		rem, err := archived.reminder(ch.ID)
+		if err != nil {
+			return err
+		}
+
+		// past reminders would be pushed right after the import, so the recurring ones
+		// move to their next occurrence and the others are left out
+		if rem.Date != nil && rem.Date.Before(now) {
+			if !reschedule(rem, now, location) {
+				imp.changes = append(imp.changes, &Change{ChannelID: ch.ID, Kind: "past reminder", Name: rem.Title, Action: ActionSkip})
+				continue
+			}
+		}
+
+		currentID, conflict := currentIDs[reminderKey(rem)]
+
+		imp.add(ch.ID, "reminder", rem.Title, conflict,
+			func(ctx context.Context) error {
+				_, err := imp.stores.Reminders.AddReminder(ctx, ch.ID, rem)
+				return err
+			},
+			func(ctx context.Context) error {
+				rem.ID = currentID
+				return imp.stores.Reminders.UpdateReminder(ctx, rem)
+			})
+	}
+
+	return nil
+}
+
+// channelLocation returns the timezone, which the channel has after the import.
+func (imp *importer) channelLocation(ctx context.Context, ch *Channel) (*time.Location, error) {
+	if ch.Timezone != "" {
+		location, err := time.LoadLocation(ch.Timezone)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while loading timezone %s", ch.Timezone)
+		}
+
+		return location, nil
+	}
+
+	location, err := imp.stores.Config.GetCurrentTimezone(ctx, ch.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting timezone")
+	}
+
+	return location, nil
+}
+
+// reschedule moves a recurring reminder to its first occurrence after now. It returns false,
+// if the reminder is not recurring or its recurrence has ended.
+func reschedule(rem *reminder.Reminder, now time.Time, location *time.Location) bool {
+	if rem.Recurrence == "" {
+		return false
+	}
+
+	recurrence, err := reminder.ParseRecurrence(rem.Recurrence)
+	if err != nil {
+		return false
+	}
+
+	if location == nil {
+		location = rem.Date.Location()
+	}
+
+	next := recurrence.Next(*rem.Date, now, location)
+	if next == nil {
+		return false
+	}
+
+	rem.Date = next
+	return true
+}
+
+func (imp *importer) planLists(ctx context.Context, ch *Channel) error {
+	for _, archived := range ch.Lists {
+		name := listName(archived.Name)
+
+		exists, err := imp.stores.Todo.ListExists(ctx, ch.ID, name)
+		if err != nil {
+			return errors.Wrapf(err, "while checking list %s", name)
+		}
+
+		// many entries can have the same text, so the n-th of them conflicts with the n-th archived one
+		currentIDs := make(map[textOccurrence]string)
+
+		if exists {
+			list, err := imp.stores.Todo.GetEntries(ctx, ch.ID, name)
+			if err != nil {
+				return errors.Wrapf(err, "while getting entries of list %s", name)
+			}
+
+			occurrences := make(map[string]int)
+			for _, entry := range list.Entries {
+				currentIDs[textOccurrence{text: entry.Text, n: occurrences[entry.Text]}] = entry.ID
+				occurrences[entry.Text]++
+			}
+		} else {
+			imp.add(ch.ID, "list", name, false, func(ctx context.Context) error {
+				return imp.stores.Todo.CreateList(ctx, ch.ID, name)
+			}, nil)
+		}
+
+		written := false
+		occurrences := make(map[string]int)
+		for _, archivedEntry := range archived.Entries {
+			entry, err := archivedEntry.entry()
+			if err != nil {
+				return err
+			}
+
+			currentID, conflict := currentIDs[textOccurrence{text: entry.Text, n: occurrences[entry.Text]}]
+			occurrences[entry.Text]++
+			written = written || !conflict || imp.policy == ConflictOverwrite
+
+			imp.add(ch.ID, "todo entry", entry.Text, conflict,
+				func(ctx context.Context) error {
+					_, err := imp.stores.Todo.AddEntry(ctx, ch.ID, name, entry)
+					return err
+				},
+				func(ctx context.Context) error {
+					entry.ID = currentID
+					return imp.stores.Todo.UpdateEntry(ctx, ch.ID, name, entry)
+				})
+		}
+
+		if written {
+			texts := make([]string, 0, len(archived.Entries))
+			for _, entry := range archived.Entries {
+				texts = append(texts, entry.Text)
+			}
+
+			imp.steps = append(imp.steps, func(ctx context.Context) error {
+				return imp.restoreOrder(ctx, ch.ID, name, texts)
+			})
+		}
+	}
+
+	return nil
+}
+
+// textOccurrence is the n-th entry with the text on a list, counted from 0.
+type textOccurrence struct {
+	text string
+	n    int
+}
+
+// restoreOrder moves the entries with the texts to the top of the list in the same order,
+// as the stores insert new entries by their priority.
+func (imp *importer) restoreOrder(ctx context.Context, channelID, name string, texts []string) error {
+	list, err := imp.stores.Todo.GetEntries(ctx, channelID, name)
+	if err != nil {
+		return errors.Wrapf(err, "while getting entries of list %s", name)
+	}
+
+	entries := list.Entries
+
+	for to, text := range texts {
+		from := -1
+		for idx := to; idx < len(entries); idx++ {
+			if entries[idx].Text == text {
+				from = idx
+				break
+			}
+		}
+
+		if from < 0 || from == to {
+			continue
+		}
+
+		if _, err := imp.stores.Todo.MoveEntry(ctx, channelID, name, from, to); err != nil {
+			return errors.Wrapf(err, "while moving entry %q", text)
+		}
+
+		moved := entries[from]
+		copy(entries[to+1:from+1], entries[to:from])
+		entries[to] = moved
+	}
+
+	return nil
+}
+
+func (imp *importer) planArchive(ctx context.Context, ch *Channel) error {
+	if len(ch.Archive) == 0 {
+		return nil
+	}
+
+	current, err := imp.stores.Todo.GetArchivedEntries(ctx, ch.ID, time.Unix(0, 0), time.Now())
+	if err != nil {
+		return errors.Wrap(err, "while getting archived entries")
+	}
+
+	currentIDs := make(map[string]bool, len(current))
+	for _, archived := range current {
+		currentIDs[archived.Entry.ID] = true
+	}
+
+	for _, archivedEntry := range ch.Archive {
+		archived, err := archivedEntry.archivedEntry()
+		if err != nil {
+			return err
+		}
+
+		store := func(ctx context.Context) error {
+			return imp.stores.Todo.ArchiveEntry(ctx, ch.ID, archived)
+		}
+
+		imp.add(ch.ID, "archived entry", archived.Entry.Text, currentIDs[archived.Entry.ID], store, store)
+	}
+
+	return nil
+}
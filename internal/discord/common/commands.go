@@ -79,7 +79,8 @@ func StringResponseHandler(log *log.Entry, s *discordgo.Session, i *discordgo.In
 	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: msg,
+			Content:         msg,
+			AllowedMentions: noMentions(),
 		},
 	})
 	if err != nil {
@@ -87,3 +88,98 @@ func StringResponseHandler(log *log.Entry, s *discordgo.Session, i *discordgo.In
 			Error("cannot respond with string response")
 	}
 }
+
+func EphemeralStringResponseHandler(log *log.Entry, s *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         msg,
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: noMentions(),
+		},
+	})
+	if err != nil {
+		log.WithError(err).
+			Error("cannot respond with ephemeral string response")
+	}
+}
+
+// UpdateMessageResponseHandler replaces the message, on which the component was used, and removes its components.
+func UpdateMessageResponseHandler(log *log.Entry, s *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:         msg,
+			Components:      []discordgo.MessageComponent{},
+			AllowedMentions: noMentions(),
+		},
+	})
+	if err != nil {
+		log.WithError(err).
+			Error("cannot respond with message update")
+	}
+}
+
+// IsEphemeral returns true, if the message is visible only to one user.
+// Such messages cannot be deleted with the Discord API.
+func IsEphemeral(msg *discordgo.Message) bool {
+	return msg.Flags&discordgo.MessageFlagsEphemeral != 0
+}
+
+// InteractionUserID returns the ID of the user, who invoked the interaction.
+func InteractionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+
+	if i.User != nil {
+		return i.User.ID
+	}
+
+	return ""
+}
+
+// HasPermissions returns true, if the user, who invoked the interaction, has all the permissions
+// in the channel. Direct messages are not restricted.
+func HasPermissions(i *discordgo.InteractionCreate, permissions int64) bool {
+	if i.Member == nil {
+		return true
+	}
+
+	return i.Member.Permissions&permissions == permissions
+}
+
+// HasChannelPermissions returns true, if the user, who invoked the interaction, has all the
+// permissions in another channel of the same guild. Channels of other guilds and channels
+// used from direct messages are always rejected.
+func HasChannelPermissions(s *discordgo.Session, i *discordgo.InteractionCreate, channelID string, permissions int64) (bool, error) {
+	if i.Member == nil || i.Member.User == nil || i.GuildID == "" {
+		return false, nil
+	}
+
+	channel, err := s.State.Channel(channelID)
+	if err != nil {
+		channel, err = s.Channel(channelID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if channel.GuildID != i.GuildID {
+		return false, nil
+	}
+
+	channelPermissions, err := s.UserChannelPermissions(i.Member.User.ID, channelID)
+	if err != nil {
+		return false, err
+	}
+
+	return channelPermissions&permissions == permissions, nil
+}
+
+// noMentions prevents pinging anyone with texts provided by users, which are echoed in responses.
+func noMentions() *discordgo.MessageAllowedMentions {
+	return &discordgo.MessageAllowedMentions{
+		Parse: []discordgo.AllowedMentionType{},
+	}
+}
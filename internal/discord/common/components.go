@@ -0,0 +1,42 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const customIDSeparator = ":"
+
+// NewCustomID builds a message component custom ID, which carries arguments
+// for the component handler registered under name.
+func NewCustomID(name string, args ...string) string {
+	return strings.Join(append([]string{name}, args...), customIDSeparator)
+}
+
+// ParseCustomID splits a custom ID built by NewCustomID into the handler name
+// and the arguments.
+func ParseCustomID(customID string) (string, []string) {
+	parts := strings.Split(customID, customIDSeparator)
+	return parts[0], parts[1:]
+}
+
+// ModalValues returns the values of text inputs in a submitted modal by their custom IDs.
+func ModalValues(data discordgo.ModalSubmitInteractionData) map[string]string {
+	values := make(map[string]string)
+
+	for _, component := range data.Components {
+		row, ok := component.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+
+		for _, rowComponent := range row.Components {
+			if input, ok := rowComponent.(*discordgo.TextInput); ok {
+				values[input.CustomID] = input.Value
+			}
+		}
+	}
+
+	return values
+}
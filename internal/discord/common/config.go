@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Trojan295/organizer-bot/internal/metrics"
+	"github.com/Trojan295/organizer-bot/internal/organizer"
 	"github.com/bwmarrin/discordgo"
 	log "github.com/sirupsen/logrus"
 )
@@ -13,6 +14,8 @@ import (
 const (
 	LabelConfigTimezoneSet = "config_timezone_set"
 	LabelConfigTimezoneGet = "config_timezone_get"
+	LabelConfigDigestSet   = "config_digest_set"
+	LabelConfigDigestGet   = "config_digest_get"
 )
 
 type TimezoneRepository interface {
@@ -20,14 +23,21 @@ type TimezoneRepository interface {
 	SetCurrentTimezone(ctx context.Context, ID string, tz *time.Location) error
 }
 
+type DigestScheduleRepository interface {
+	GetDigestSchedule(ctx context.Context, ID string) (*organizer.DigestSchedule, error)
+	SetDigestSchedule(ctx context.Context, ID string, schedule *organizer.DigestSchedule) error
+}
+
 type ConfigModule struct {
-	logger             *log.Entry
-	timezoneRepository TimezoneRepository
+	logger                   *log.Entry
+	timezoneRepository       TimezoneRepository
+	digestScheduleRepository DigestScheduleRepository
 }
 
 type ConfigModuleInput struct {
-	Logger             *log.Entry
-	TimezoneRepository TimezoneRepository
+	Logger                   *log.Entry
+	TimezoneRepository       TimezoneRepository
+	DigestScheduleRepository DigestScheduleRepository
 }
 
 func NewConfigModule(input *ConfigModuleInput) (*ConfigModule, error) {
@@ -39,13 +49,18 @@ func NewConfigModule(input *ConfigModuleInput) (*ConfigModule, error) {
 		return nil, fmt.Errorf("TimezoneRepository not provided")
 	}
 
+	if input.DigestScheduleRepository == nil {
+		return nil, fmt.Errorf("DigestScheduleRepository not provided")
+	}
+
 	if input.Logger == nil {
 		input.Logger = log.NewEntry(log.New())
 	}
 
 	return &ConfigModule{
-		timezoneRepository: input.TimezoneRepository,
-		logger:             input.Logger,
+		timezoneRepository:       input.TimezoneRepository,
+		digestScheduleRepository: input.DigestScheduleRepository,
+		logger:                   input.Logger,
 	}, nil
 }
 
@@ -68,6 +83,28 @@ func (module *ConfigModule) GetApplicationCommandSubgroups() []*discordgo.Applic
 						},
 					},
 				},
+				{
+					Name:        "digest",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Description: "Todo digest schedule",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "schedule",
+							Description: "Days and times, e.g.: daily 9:00, weekdays 9:00,17:00, mon 8:30, off",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "skip-unchanged",
+							Description: "Skip the digest, when nothing changed since the previous one",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "group-by-tag",
+							Description: "Group the tasks in the digest by their tags",
+						},
+					},
+				},
 			},
 		},
 	}
@@ -83,12 +120,22 @@ func (module *ConfigModule) GetMessageComponentInteractionHandlers() map[string]
 	return map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate){}
 }
 
+func (module *ConfigModule) GetModalSubmitInteractionHandlers() map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	return map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate){}
+}
+
+func (module *ConfigModule) GetAutocompleteInteractionHandlers() map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	return map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption){}
+}
+
 func (module *ConfigModule) configHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, cmd *discordgo.ApplicationCommandInteractionDataOption) {
 	subCmd := cmd.Options[0]
 
 	switch subCmd.Name {
 	case "timezone":
 		module.timezoneHandler(ctx, s, i, subCmd)
+	case "digest":
+		module.digestHandler(ctx, s, i, subCmd)
 
 	default:
 		UnknownCommandHandler(module.logger, s, i)
@@ -148,3 +195,81 @@ https://github.com/Trojan295/organizer-bot/blob/main/docs/timezones.md`)
 	metrics.CountExecutedCommand(LabelConfigTimezoneSet)
 	StringResponseHandler(module.logger, s, i, fmt.Sprintf("🚀 Timezone set to **%s**.", location.String()))
 }
+
+func (module *ConfigModule) digestHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, cmd *discordgo.ApplicationCommandInteractionDataOption) {
+	if len(cmd.Options) == 0 {
+		module.getDigestHandler(ctx, s, i)
+		return
+	}
+
+	module.setDigestHandler(ctx, s, i, cmd)
+}
+
+func (module *ConfigModule) getDigestHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	schedule, err := module.digestScheduleRepository.GetDigestSchedule(ctx, i.ChannelID)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelConfigDigestGet)
+		module.logger.WithError(err).Error("failed to get digest schedule")
+		ServerErrorCommandHandler(module.logger, s, i)
+		return
+	}
+
+	metrics.CountExecutedCommand(LabelConfigDigestGet)
+
+	if schedule == nil {
+		schedule = &organizer.DefaultDigestSchedule
+	}
+
+	msg := fmt.Sprintf("📰 The todo digest schedule is:\n**%s**", schedule.String())
+	StringResponseHandler(module.logger, s, i, msg)
+}
+
+func (module *ConfigModule) setDigestHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, cmd *discordgo.ApplicationCommandInteractionDataOption) {
+	var schedule *organizer.DigestSchedule
+
+	if scheduleOpt := FindOption(cmd.Options, "schedule"); scheduleOpt != nil {
+		parsed, err := organizer.ParseDigestSchedule(scheduleOpt.StringValue())
+		if err != nil {
+			metrics.CountClientErroredCommand(LabelConfigDigestSet)
+			ClientErrorCommandHandler(module.logger, s, i, `Incorrect schedule!
+Use the days and the times, e.g.: **daily 9:00**, **weekdays 9:00,17:00**, **mon 8:30**
+or **off** to disable the digest.`)
+			return
+		}
+
+		schedule = parsed
+	} else {
+		current, err := module.digestScheduleRepository.GetDigestSchedule(ctx, i.ChannelID)
+		if err != nil {
+			metrics.CountServerErroredCommand(LabelConfigDigestSet)
+			module.logger.WithError(err).Error("failed to get digest schedule")
+			ServerErrorCommandHandler(module.logger, s, i)
+			return
+		}
+
+		if current == nil {
+			defaultSchedule := organizer.DefaultDigestSchedule
+			current = &defaultSchedule
+		}
+
+		schedule = current
+	}
+
+	if skipOpt := FindOption(cmd.Options, "skip-unchanged"); skipOpt != nil {
+		schedule.SkipUnchanged = skipOpt.BoolValue()
+	}
+
+	if groupOpt := FindOption(cmd.Options, "group-by-tag"); groupOpt != nil {
+		schedule.GroupByTag = groupOpt.BoolValue()
+	}
+
+	if err := module.digestScheduleRepository.SetDigestSchedule(ctx, i.ChannelID, schedule); err != nil {
+		metrics.CountServerErroredCommand(LabelConfigDigestSet)
+		module.logger.WithError(err).Error("failed to set digest schedule")
+		ServerErrorCommandHandler(module.logger, s, i)
+		return
+	}
+
+	metrics.CountExecutedCommand(LabelConfigDigestSet)
+	StringResponseHandler(module.logger, s, i, fmt.Sprintf("🚀 Todo digest schedule set to **%s**.", schedule.String()))
+}
@@ -0,0 +1,49 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxMessageLength is the most characters Discord accepts in a message.
+	MaxMessageLength = 2000
+
+	// maxMoreLineLength is the room kept for the "... and N more" line
+	maxMoreLineLength = 32
+)
+
+// AppendLines appends the lines to the message, while it fits in MaxMessageLength. The lines,
+// which do not fit, are replaced with "... and N more". The lines should end with a newline.
+func AppendLines(builder *strings.Builder, lines []string) {
+	length := utf8.RuneCountInString(builder.String())
+
+	for idx, line := range lines {
+		lineLength := utf8.RuneCountInString(line)
+
+		reserved := maxMoreLineLength
+		if idx == len(lines)-1 {
+			reserved = 0
+		}
+
+		if length+lineLength+reserved > MaxMessageLength {
+			builder.WriteString(fmt.Sprintf("... and %d more\n", len(lines)-idx))
+			return
+		}
+
+		builder.WriteString(line)
+		length += lineLength
+	}
+}
+
+// TruncateLines cuts the message after the last whole line, which fits in MaxMessageLength
+// together with the note. The message is returned unchanged, if it fits.
+func TruncateLines(msg, note string) string {
+	if utf8.RuneCountInString(msg) <= MaxMessageLength {
+		return msg
+	}
+
+	kept := string([]rune(msg)[:MaxMessageLength-utf8.RuneCountInString(note)])
+	return kept[:strings.LastIndex(kept, "\n")+1] + note
+}
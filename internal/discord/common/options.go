@@ -0,0 +1,15 @@
+package common
+
+import "github.com/bwmarrin/discordgo"
+
+// FindOption returns the command option with the given name or nil,
+// if the option was not provided.
+func FindOption(options []*discordgo.ApplicationCommandInteractionDataOption, name string) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, opt := range options {
+		if opt.Name == name {
+			return opt
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Discord limits on select menus
+	pickerPageSize        = 25
+	pickerOptionMaxLength = 100
+
+	pickerPageSuffix = "_page"
+)
+
+// Picker is a select menu, which is split into pages with Prev and Next buttons,
+// when it has more options than Discord allows in one select menu.
+// The select menu gets the custom ID built from Name and the buttons from PickerPageName(Name),
+// so both handlers have to be registered in the module. Args are added to both custom IDs
+// and can be read with PickerArgs.
+type Picker struct {
+	Name    string
+	Args    []string
+	Content string
+	Options []discordgo.SelectMenuOption
+	Page    int
+}
+
+// PickerPageName returns the name of the custom ID used by the Prev and Next buttons of the picker.
+func PickerPageName(name string) string {
+	return name + pickerPageSuffix
+}
+
+// PickerPage returns the page requested by a Prev or Next button. It is zero for other interactions.
+func PickerPage(i *discordgo.InteractionCreate) int {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return 0
+	}
+
+	_, args := ParseCustomID(i.MessageComponentData().CustomID)
+	if len(args) == 0 {
+		return 0
+	}
+
+	page, err := strconv.Atoi(args[len(args)-1])
+	if err != nil {
+		return 0
+	}
+
+	return page
+}
+
+// PickerArgs returns the Args of the picker, which sent the interaction.
+func PickerArgs(i *discordgo.InteractionCreate) []string {
+	_, args := ParseCustomID(i.MessageComponentData().CustomID)
+	if len(args) == 0 {
+		return nil
+	}
+
+	return args[:len(args)-1]
+}
+
+func (p *Picker) customID(name string, page int) string {
+	args := append(append([]string{}, p.Args...), strconv.Itoa(page))
+	return NewCustomID(name, args...)
+}
+
+func (p *Picker) pageCount() int {
+	return (len(p.Options) + pickerPageSize - 1) / pickerPageSize
+}
+
+// Components returns the select menu with the options on the current page and
+// the buttons to switch the pages.
+func (p *Picker) Components() []discordgo.MessageComponent {
+	pages := p.pageCount()
+
+	page := p.Page
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+
+	end := (page + 1) * pickerPageSize
+	if end > len(p.Options) {
+		end = len(p.Options)
+	}
+
+	options := make([]discordgo.SelectMenuOption, 0, end-page*pickerPageSize)
+	for _, option := range p.Options[page*pickerPageSize : end] {
+		option.Label = truncateOptionText(option.Label)
+		option.Description = truncateOptionText(option.Description)
+		options = append(options, option)
+	}
+
+	menu := discordgo.SelectMenu{
+		CustomID: p.customID(p.Name, page),
+		Options:  options,
+	}
+
+	if pages <= 1 {
+		return []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{menu}},
+		}
+	}
+
+	menu.Placeholder = fmt.Sprintf("Page %d of %d", page+1, pages)
+
+	prevPage, nextPage := page-1, page+1
+	if prevPage < 0 {
+		prevPage = 0
+	}
+	if nextPage > pages-1 {
+		nextPage = pages - 1
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{menu}},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Prev",
+					Style:    discordgo.SecondaryButton,
+					CustomID: p.customID(PickerPageName(p.Name), prevPage),
+					Disabled: page == 0,
+				},
+				discordgo.Button{
+					Label:    "Next",
+					Style:    discordgo.SecondaryButton,
+					CustomID: p.customID(PickerPageName(p.Name), nextPage),
+					Disabled: page == pages-1,
+				},
+			},
+		},
+	}
+}
+
+// PickerResponseHandler responds with the picker. When the interaction comes from a Prev
+// or Next button, the message with the picker is updated instead.
+func PickerResponseHandler(log *log.Entry, s *discordgo.Session, i *discordgo.InteractionCreate, picker *Picker, flags discordgo.MessageFlags) {
+	responseType := discordgo.InteractionResponseChannelMessageWithSource
+	if i.Type == discordgo.InteractionMessageComponent {
+		responseType = discordgo.InteractionResponseUpdateMessage
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: responseType,
+		Data: &discordgo.InteractionResponseData{
+			Content:         picker.Content,
+			Flags:           flags,
+			Components:      picker.Components(),
+			AllowedMentions: noMentions(),
+		},
+	})
+	if err != nil {
+		log.WithError(err).
+			WithField("customID", picker.Name).
+			Error("cannot respond with picker")
+	}
+}
+
+func truncateOptionText(text string) string {
+	runes := []rune(text)
+	if len(runes) <= pickerOptionMaxLength {
+		return text
+	}
+
+	return string(runes[:pickerOptionMaxLength-3]) + "..."
+}
@@ -2,10 +2,14 @@ package message
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"time"
 
-	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/Trojan295/organizer-bot/internal/discord/common"
+	discordtodo "github.com/Trojan295/organizer-bot/internal/discord/todo"
 	"github.com/Trojan295/organizer-bot/internal/todo"
 	"github.com/bwmarrin/discordgo"
 )
@@ -20,35 +24,61 @@ func NewSender(ds *discordgo.Session) *Sender {
 	}
 }
 
-func (send *Sender) PushReminder(ctx context.Context, rem *reminder.Reminder) error {
-	msg := fmt.Sprintf(`🚨 **Reminder!** <#%s>
-%s
-`, rem.ChannelID, rem.Title)
+func (send *Sender) PushTodoListNotification(ctx context.Context, channelID string, digest *todo.Digest) error {
+	builder := strings.Builder{}
 
-	_, err := send.session.ChannelMessageSendComplex(rem.ChannelID, &discordgo.MessageSend{
-		Content: msg,
-	})
-	if err != nil {
-		return err
+	now := time.Now()
+	if digest.Location != nil {
+		now = now.In(digest.Location)
 	}
 
-	return nil
-}
+	for idx, list := range digest.Lists {
+		if idx == 0 {
+			builder.WriteString(fmt.Sprintf("📰 **%s:** <#%s>\n", discordtodo.ListTitle(list.Name), channelID))
 
-func (send *Sender) PushTodoListNotification(ctx context.Context, list *todo.List) error {
-	builder := strings.Builder{}
-	builder.WriteString(fmt.Sprintf("📰 **Tasks:** <#%s>\n", list.ChannelID))
+			if digest.Changes != nil {
+				builder.WriteString(discordtodo.FormatDigestChanges(digest.Changes, now) + "\n")
+			}
+		} else {
+			builder.WriteString(fmt.Sprintf("\n📰 **%s:**\n", discordtodo.ListTitle(list.Name)))
+		}
 
-	for i, entry := range list.Entries {
-		builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, entry.Text))
+		if digest.GroupByTag {
+			builder.WriteString(discordtodo.FormatEntriesByTag(list.Entries, now))
+			continue
+		}
+
+		for i, entry := range list.Entries {
+			builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, discordtodo.FormatEntry(entry, now)))
+			builder.WriteString(discordtodo.FormatSubtasks(entry))
+		}
+	}
+
+	if len(digest.Expiring) > 0 {
+		builder.WriteString("\n" + discordtodo.FormatExpiringEntries(digest.Expiring))
 	}
 
-	_, err := send.session.ChannelMessageSendComplex(list.ChannelID, &discordgo.MessageSend{
-		Content: builder.String(),
+	// large channels do not fit in one message, the full lists are shown by /organizer todo show
+	content := common.TruncateLines(builder.String(), "... use /organizer todo show to see all tasks\n")
+
+	_, err := send.session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:    content,
+		Components: discordtodo.ExpiringEntriesComponents(digest.Expiring),
+		AllowedMentions: &discordgo.MessageAllowedMentions{
+			Parse: []discordgo.AllowedMentionType{},
+		},
 	})
-	if err != nil {
+	if isBadRequest(err) {
+		// the same digest would be rejected again
+		return &todo.PermanentError{Err: err}
+	} else if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func isBadRequest(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusBadRequest
+}
@@ -0,0 +1,159 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Trojan295/organizer-bot/internal/discord/common"
+	"github.com/Trojan295/organizer-bot/internal/metrics"
+	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	LabelReminderFailed = "reminder_failed"
+	LabelReminderRetry  = "reminder_retry"
+
+	componentReminderRetry = "reminder_retry"
+
+	// deadLetterPermissions are required to inspect and retry undelivered reminders
+	deadLetterPermissions = discordgo.PermissionManageChannels
+)
+
+func (m *Module) reminderFailedCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	metrics.CountExecutedCommand(LabelReminderFailed)
+
+	deadLetters, ok := m.getDeadLetters(ctx, s, i, LabelReminderFailed)
+	if !ok {
+		return
+	}
+
+	if len(deadLetters) == 0 {
+		common.EphemeralStringResponseHandler(m.logger, s, i, "**All reminders were delivered!**")
+		return
+	}
+
+	location := m.channelLocation(ctx, i.ChannelID)
+
+	builder := strings.Builder{}
+	builder.WriteString("⚠️ **Undelivered reminders:**\n")
+
+	for _, deadLetter := range deadLetters {
+		rem := deadLetter.Trigger.Reminder
+
+		builder.WriteString(fmt.Sprintf("**%s:** %s", rem.Date.In(location).Format(datetimeFormat), rem.Title))
+		if deadLetter.Trigger.LeadTime > 0 {
+			builder.WriteString(fmt.Sprintf(" (⏳ %s before)", FormatLeadTime(deadLetter.Trigger.LeadTime)))
+		}
+		builder.WriteString(fmt.Sprintf("\n> failed at %s: %s\n", deadLetter.FailedAt.In(location).Format(datetimeFormat), deadLetter.Error))
+	}
+
+	builder.WriteString("Use `/organizer reminder retry` to deliver them again.")
+
+	common.EphemeralStringResponseHandler(m.logger, s, i, builder.String())
+}
+
+func (m *Module) reminderRetryCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	metrics.CountExecutedCommand(LabelReminderRetry)
+
+	m.reminderRetryPageHandler(ctx, s, i)
+}
+
+// reminderRetryPageHandler responds with a picker of the undelivered reminders.
+// It also handles switching the pages of the picker.
+func (m *Module) reminderRetryPageHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	deadLetters, ok := m.getDeadLetters(ctx, s, i, LabelReminderRetry)
+	if !ok {
+		return
+	}
+
+	if len(deadLetters) == 0 {
+		if i.Type == discordgo.InteractionMessageComponent {
+			common.UpdateMessageResponseHandler(m.logger, s, i, "**All reminders were delivered!**")
+			return
+		}
+
+		common.EphemeralStringResponseHandler(m.logger, s, i, "**All reminders were delivered!**")
+		return
+	}
+
+	picker := &common.Picker{
+		Name:    componentReminderRetry,
+		Content: "Select the reminder to deliver again:",
+		Page:    common.PickerPage(i),
+	}
+
+	location := m.channelLocation(ctx, i.ChannelID)
+
+	seen := map[string]bool{}
+	for _, deadLetter := range deadLetters {
+		rem := deadLetter.Trigger.Reminder
+		if seen[rem.ID] {
+			continue
+		}
+		seen[rem.ID] = true
+
+		picker.Options = append(picker.Options, discordgo.SelectMenuOption{
+			Label:       rem.Title,
+			Description: rem.Date.In(location).Format(datetimeFormat),
+			Value:       rem.ID,
+		})
+	}
+
+	common.PickerResponseHandler(m.logger, s, i, picker, discordgo.MessageFlagsEphemeral)
+}
+
+func (m *Module) reminderRetryComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	reminderID := i.MessageComponentData().Values[0]
+
+	if !common.HasPermissions(i, deadLetterPermissions) {
+		metrics.CountClientErroredCommand(LabelReminderRetry)
+		common.ClientErrorCommandHandler(m.logger, s, i, "You need the Manage Channels permission to retry reminders.")
+		return
+	}
+
+	err := m.reminderRepository.RequeueDeadLetter(ctx, i.ChannelID, reminderID)
+	if err == reminder.ErrReminderNotFound {
+		metrics.CountClientErroredCommand(LabelReminderRetry)
+		common.ClientErrorCommandHandler(m.logger, s, i, "This reminder was already delivered or removed.")
+		return
+	} else if err != nil {
+		metrics.CountServerErroredCommand(LabelReminderRetry)
+		m.logger.WithError(err).Error("failed to requeue reminder")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	common.UpdateMessageResponseHandler(m.logger, s, i, "🔄 Reminder will be delivered again!")
+}
+
+// getDeadLetters checks the permissions and gets the dead letters visible to the user.
+// When it fails, it responds to the interaction and returns false.
+func (m *Module) getDeadLetters(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, label string) ([]*reminder.DeadLetter, bool) {
+	if !common.HasPermissions(i, deadLetterPermissions) {
+		metrics.CountClientErroredCommand(label)
+		common.ClientErrorCommandHandler(m.logger, s, i, "You need the Manage Channels permission to manage undelivered reminders.")
+		return nil, false
+	}
+
+	deadLetters, err := m.reminderRepository.GetDeadLetters(ctx, i.ChannelID)
+	if err != nil {
+		metrics.CountServerErroredCommand(label)
+		m.logger.WithError(err).Error("failed to get dead letters")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return nil, false
+	}
+
+	userID := common.InteractionUserID(i)
+	visible := make([]*reminder.DeadLetter, 0, len(deadLetters))
+
+	for _, deadLetter := range deadLetters {
+		rem := deadLetter.Trigger.Reminder
+		if rem.UserID == "" || rem.UserID == userID {
+			visible = append(visible, deadLetter)
+		}
+	}
+
+	return visible, true
+}
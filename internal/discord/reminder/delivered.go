@@ -0,0 +1,171 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/discord/common"
+	"github.com/Trojan295/organizer-bot/internal/metrics"
+	"github.com/Trojan295/organizer-bot/internal/organizer"
+	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	LabelReminderSnooze = "reminder_snooze"
+	LabelReminderDone   = "reminder_done"
+
+	componentReminderSnooze = "reminder_snooze"
+	componentReminderDone   = "reminder_done"
+)
+
+type snoozeOption struct {
+	ID    string
+	Label string
+	Date  string
+}
+
+var snoozeOptions = []snoozeOption{
+	{ID: "10m", Label: "Snooze 10m", Date: "in 10m"},
+	{ID: "1h", Label: "Snooze 1h", Date: "in 1h"},
+	{ID: "tomorrow", Label: "Tomorrow", Date: "tomorrow"},
+}
+
+// DeliveredReminderComponents returns the buttons attached to a pushed reminder.
+func DeliveredReminderComponents(rem *reminder.Reminder) []discordgo.MessageComponent {
+	buttons := make([]discordgo.MessageComponent, 0, len(snoozeOptions)+1)
+
+	for _, opt := range snoozeOptions {
+		buttons = append(buttons, discordgo.Button{
+			Label:    opt.Label,
+			Style:    discordgo.SecondaryButton,
+			CustomID: common.NewCustomID(componentReminderSnooze, opt.ID, rem.ChannelID, rem.ID),
+		})
+	}
+
+	buttons = append(buttons, discordgo.Button{
+		Label:    "Done",
+		Style:    discordgo.SuccessButton,
+		CustomID: common.NewCustomID(componentReminderDone, rem.ChannelID, rem.ID),
+	})
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: buttons,
+		},
+	}
+}
+
+func (m *Module) reminderSnoozeComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	metrics.CountExecutedCommand(LabelReminderSnooze)
+
+	_, args := common.ParseCustomID(i.MessageComponentData().CustomID)
+	if len(args) != 3 {
+		metrics.CountClientErroredCommand(LabelReminderSnooze)
+		common.UnknownCommandHandler(m.logger, s, i)
+		return
+	}
+
+	var option *snoozeOption
+	for idx := range snoozeOptions {
+		if snoozeOptions[idx].ID == args[0] {
+			option = &snoozeOptions[idx]
+		}
+	}
+
+	if option == nil {
+		metrics.CountClientErroredCommand(LabelReminderSnooze)
+		common.UnknownCommandHandler(m.logger, s, i)
+		return
+	}
+
+	delivered, err := m.reminderRepository.GetDeliveredReminder(ctx, args[1], args[2])
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelReminderSnooze)
+		m.logger.WithError(err).Error("failed to get delivered reminder")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	if delivered == nil {
+		metrics.CountClientErroredCommand(LabelReminderSnooze)
+		common.ClientErrorCommandHandler(m.logger, s, i, "This reminder cannot be snoozed anymore.")
+		return
+	}
+
+	location, err := m.timezoneRepository.GetCurrentTimezone(ctx, delivered.ChannelID)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelReminderSnooze)
+		m.logger.WithError(err).Error("failed to get current timezone")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	if location == nil {
+		location = time.UTC
+	}
+
+	date, err := organizer.ParseDate(option.Date, time.Now(), location)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelReminderSnooze)
+		m.logger.WithError(err).Error("failed to parse snooze date")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	_, err = m.reminderRepository.AddReminder(ctx, delivered.ChannelID, &reminder.Reminder{
+		Title:    delivered.Title,
+		Date:     date,
+		UserID:   delivered.UserID,
+		Mentions: delivered.Mentions,
+	})
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelReminderSnooze)
+		m.logger.WithError(err).Error("failed to add reminder")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	msg := fmt.Sprintf("%s\n💤 Snoozed until **%s** by <@%s>", i.Message.Content, date.Format(datetimeFormat), common.InteractionUserID(i))
+	m.updateDeliveredMessage(s, i, msg)
+}
+
+func (m *Module) reminderDoneComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	metrics.CountExecutedCommand(LabelReminderDone)
+
+	_, args := common.ParseCustomID(i.MessageComponentData().CustomID)
+	if len(args) != 2 {
+		metrics.CountClientErroredCommand(LabelReminderDone)
+		common.UnknownCommandHandler(m.logger, s, i)
+		return
+	}
+
+	if err := m.reminderRepository.RemoveDeliveredReminder(ctx, args[0], args[1]); err != nil {
+		metrics.CountServerErroredCommand(LabelReminderDone)
+		m.logger.WithError(err).Error("failed to remove delivered reminder")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	msg := fmt.Sprintf("%s\n✅ Done by <@%s>", i.Message.Content, common.InteractionUserID(i))
+	m.updateDeliveredMessage(s, i, msg)
+}
+
+// updateDeliveredMessage replaces the content of the pushed reminder and removes the buttons.
+func (m *Module) updateDeliveredMessage(s *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    msg,
+			Components: []discordgo.MessageComponent{},
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Parse: []discordgo.AllowedMentionType{},
+			},
+		},
+	})
+	if err != nil {
+		m.logger.WithError(err).
+			Error("cannot update delivered reminder message")
+	}
+}
@@ -0,0 +1,209 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/discord/common"
+	"github.com/Trojan295/organizer-bot/internal/metrics"
+	"github.com/Trojan295/organizer-bot/internal/organizer"
+	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	LabelReminderEdit = "reminder_edit"
+
+	componentReminderEdit = "reminder_edit"
+	modalReminderEdit     = "reminder_edit_modal"
+	reminderEditContent   = "Select the reminder to edit:"
+
+	editInputTitle      = "title"
+	editInputDate       = "date"
+	editInputRecurrence = "recurrence"
+)
+
+func (m *Module) reminderEditCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	metrics.CountExecutedCommand(LabelReminderEdit)
+
+	m.reminderSelectHandler(ctx, s, i, LabelReminderEdit, componentReminderEdit, reminderEditContent)
+}
+
+func (m *Module) reminderEditPageHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	m.reminderSelectHandler(ctx, s, i, LabelReminderEdit, componentReminderEdit, reminderEditContent)
+}
+
+func (m *Module) reminderEditComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	reminderID := i.MessageComponentData().Values[0]
+
+	rem, ok := m.getEditedReminder(ctx, s, i, reminderID)
+	if !ok {
+		return
+	}
+
+	location, err := m.timezoneRepository.GetCurrentTimezone(ctx, i.ChannelID)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelReminderEdit)
+		m.logger.WithError(err).Error("failed to get current timezone")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	if location == nil {
+		location = time.UTC
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: common.NewCustomID(modalReminderEdit, rem.ID),
+			Title:    "Edit reminder",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  editInputTitle,
+							Label:     "Text",
+							Style:     discordgo.TextInputParagraph,
+							Value:     rem.Title,
+							Required:  true,
+							MaxLength: 1000,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    editInputDate,
+							Label:       "Date",
+							Style:       discordgo.TextInputShort,
+							Value:       rem.Date.In(location).Format(datetimeFormat),
+							Placeholder: "20.12.2021 15:48, in 2h30m, tomorrow 9am",
+							Required:    true,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    editInputRecurrence,
+							Label:       "Recurrence",
+							Style:       discordgo.TextInputShort,
+							Value:       rem.Recurrence,
+							Placeholder: "daily, weekly, weekdays, FREQ=WEEKLY;BYDAY=MO",
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		m.logger.WithError(err).
+			Error("cannot respond with edit modal")
+	}
+}
+
+func (m *Module) reminderEditModalHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+
+	_, args := common.ParseCustomID(data.CustomID)
+	if len(args) != 1 {
+		metrics.CountClientErroredCommand(LabelReminderEdit)
+		common.UnknownCommandHandler(m.logger, s, i)
+		return
+	}
+
+	rem, ok := m.getEditedReminder(ctx, s, i, args[0])
+	if !ok {
+		return
+	}
+
+	values := common.ModalValues(data)
+
+	location, err := m.timezoneRepository.GetCurrentTimezone(ctx, i.ChannelID)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelReminderEdit)
+		m.logger.WithError(err).Error("failed to get current timezone")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	if location == nil {
+		metrics.CountClientErroredCommand(LabelReminderEdit)
+		common.ClientErrorCommandHandler(m.logger, s, i, "You have to first set your timezone!\nUse `/organizer config timezone` to set the timezone.")
+		return
+	}
+
+	date, err := organizer.ParseDate(values[editInputDate], time.Now(), location)
+	if err != nil {
+		metrics.CountClientErroredCommand(LabelReminderEdit)
+		common.ClientErrorCommandHandler(m.logger, s, i, "Date is wrong. Use `20.12.2021 15:48`, `2021-12-20T15:48`, `in 2h30m`, `tomorrow 9am` or `next friday 14:00`.")
+		return
+	}
+
+	if !date.After(time.Now()) {
+		metrics.CountClientErroredCommand(LabelReminderEdit)
+		common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("Date %s is in the past.", date.Format(datetimeFormat)))
+		return
+	}
+
+	recurrence := ""
+	if values[editInputRecurrence] != "" {
+		rec, err := reminder.ParseRecurrence(values[editInputRecurrence])
+		if err != nil {
+			metrics.CountClientErroredCommand(LabelReminderEdit)
+			common.ClientErrorCommandHandler(m.logger, s, i, "Recurrence is wrong. Use daily, weekly, weekdays, monthly, yearly or an RRULE like `FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR`.")
+			return
+		}
+		recurrence = rec.String()
+	}
+
+	rem.Title = values[editInputTitle]
+	rem.Date = date
+	rem.Recurrence = recurrence
+
+	err = m.reminderRepository.UpdateReminder(ctx, rem)
+	if err == reminder.ErrReminderNotFound {
+		metrics.CountClientErroredCommand(LabelReminderEdit)
+		common.ClientErrorCommandHandler(m.logger, s, i, "This reminder does not exist anymore.")
+		return
+	} else if err != nil {
+		metrics.CountServerErroredCommand(LabelReminderEdit)
+		m.logger.WithError(err).Error("failed to update reminder")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	msg := fmt.Sprintf("✏️ **Reminder updated!**\n%s at %s", rem.Title, date.Format(datetimeFormat))
+	if recurrence != "" {
+		msg += fmt.Sprintf("\n🔁 %s", recurrence)
+	}
+
+	// the modal was opened from the select menu, which gets replaced
+	common.UpdateMessageResponseHandler(m.logger, s, i, msg)
+}
+
+// getEditedReminder gets the reminder and checks, if the user can edit it.
+// When it fails, it responds to the interaction and returns false.
+func (m *Module) getEditedReminder(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, reminderID string) (*reminder.Reminder, bool) {
+	rem, err := m.reminderRepository.GetReminder(ctx, i.ChannelID, reminderID)
+	if err == reminder.ErrReminderNotFound {
+		metrics.CountClientErroredCommand(LabelReminderEdit)
+		common.ClientErrorCommandHandler(m.logger, s, i, "This reminder does not exist anymore.")
+		return nil, false
+	} else if err != nil {
+		metrics.CountServerErroredCommand(LabelReminderEdit)
+		m.logger.WithError(err).Error("failed to get reminder")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return nil, false
+	}
+
+	if rem.UserID != "" && rem.UserID != common.InteractionUserID(i) {
+		metrics.CountClientErroredCommand(LabelReminderEdit)
+		common.ClientErrorCommandHandler(m.logger, s, i, "You cannot edit personal reminders of other users.")
+		return nil, false
+	}
+
+	return rem, true
+}
@@ -0,0 +1,79 @@
+package reminder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/organizer"
+)
+
+// parseLeadTimes parses comma separated durations, e.g. "15m, 1d".
+func parseLeadTimes(text string) ([]time.Duration, error) {
+	var (
+		leadTimes []time.Duration
+		seen      = make(map[time.Duration]bool)
+	)
+
+	for _, part := range strings.Split(text, ",") {
+		leadTime, err := organizer.ParseDuration(part)
+		if err != nil {
+			return nil, err
+		}
+
+		if leadTime <= 0 {
+			return nil, fmt.Errorf("lead time must be positive")
+		}
+
+		if seen[leadTime] {
+			continue
+		}
+
+		seen[leadTime] = true
+		leadTimes = append(leadTimes, leadTime)
+	}
+
+	sort.Slice(leadTimes, func(i, j int) bool {
+		return leadTimes[i] > leadTimes[j]
+	})
+
+	return leadTimes, nil
+}
+
+// FormatLeadTime renders the lead time in words, e.g. "1 day 2 hours".
+func FormatLeadTime(leadTime time.Duration) string {
+	units := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{name: "day", duration: 24 * time.Hour},
+		{name: "hour", duration: time.Hour},
+		{name: "minute", duration: time.Minute},
+	}
+
+	var parts []string
+
+	for _, unit := range units {
+		value := leadTime / unit.duration
+		leadTime -= value * unit.duration
+
+		switch {
+		case value == 1:
+			parts = append(parts, fmt.Sprintf("1 %s", unit.name))
+		case value > 1:
+			parts = append(parts, fmt.Sprintf("%d %ss", value, unit.name))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func formatLeadTimes(leadTimes []time.Duration) string {
+	parts := make([]string, 0, len(leadTimes))
+	for _, leadTime := range leadTimes {
+		parts = append(parts, FormatLeadTime(leadTime))
+	}
+
+	return strings.Join(parts, ", ")
+}
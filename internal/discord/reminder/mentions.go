@@ -0,0 +1,89 @@
+package reminder
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	userMentionRegexp = regexp.MustCompile(`^<@!?(\d+)>$`)
+	roleMentionRegexp = regexp.MustCompile(`^<@&(\d+)>$`)
+
+	// zero-width space breaks mass mentions typed in reminder texts
+	massMentionReplacer = strings.NewReplacer(
+		"@everyone", "@\u200beveryone",
+		"@here", "@\u200bhere",
+	)
+)
+
+// parseMentions parses space separated user, role and @here mentions.
+// @everyone is not allowed.
+func parseMentions(text string) (reminder.Mentions, error) {
+	mentions := reminder.Mentions{}
+
+	for _, token := range strings.Fields(text) {
+		if match := userMentionRegexp.FindStringSubmatch(token); match != nil {
+			mentions.UserIDs = append(mentions.UserIDs, match[1])
+			continue
+		}
+
+		if match := roleMentionRegexp.FindStringSubmatch(token); match != nil {
+			mentions.RoleIDs = append(mentions.RoleIDs, match[1])
+			continue
+		}
+
+		if token == "@here" {
+			mentions.Here = true
+			continue
+		}
+
+		return reminder.Mentions{}, fmt.Errorf("invalid mention %s", token)
+	}
+
+	return mentions, nil
+}
+
+// FormatMentions renders the mentions, so they can be put into a message.
+func FormatMentions(mentions reminder.Mentions) string {
+	parts := make([]string, 0, len(mentions.UserIDs)+len(mentions.RoleIDs)+1)
+
+	for _, ID := range mentions.UserIDs {
+		parts = append(parts, fmt.Sprintf("<@%s>", ID))
+	}
+
+	for _, ID := range mentions.RoleIDs {
+		parts = append(parts, fmt.Sprintf("<@&%s>", ID))
+	}
+
+	if mentions.Here {
+		parts = append(parts, "@here")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// AllowedMentions allows Discord to ping only the mentions set on the reminder.
+// Mass mentions in the reminder text must be escaped with EscapeMassMentions,
+// as @here cannot be allowed without @everyone.
+func AllowedMentions(mentions reminder.Mentions) *discordgo.MessageAllowedMentions {
+	allowed := &discordgo.MessageAllowedMentions{
+		Parse: []discordgo.AllowedMentionType{},
+		Users: mentions.UserIDs,
+		Roles: mentions.RoleIDs,
+	}
+
+	if mentions.Here {
+		allowed.Parse = append(allowed.Parse, discordgo.AllowedMentionTypeEveryone)
+	}
+
+	return allowed
+}
+
+// EscapeMassMentions prevents @everyone and @here in the text from pinging.
+func EscapeMassMentions(text string) string {
+	return massMentionReplacer.Replace(text)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/Trojan295/organizer-bot/internal/discord/common"
 	"github.com/Trojan295/organizer-bot/internal/metrics"
+	"github.com/Trojan295/organizer-bot/internal/organizer"
 	"github.com/Trojan295/organizer-bot/internal/reminder"
 	"github.com/bwmarrin/discordgo"
 	log "github.com/sirupsen/logrus"
@@ -18,12 +19,22 @@ const (
 	LabelReminderAdd    = "reminder_add"
 	LabelReminderShow   = "reminder_show"
 	LabelReminderRemove = "reminder_remove"
+	LabelMeRemind       = "me_remind"
+
+	componentReminderRemove = "reminder_remove"
+	reminderRemoveContent   = "Select the reminder to remove:"
 )
 
 type Repository interface {
 	AddReminder(ctx context.Context, channelID string, r *reminder.Reminder) (string, error)
 	GetReminders(ctx context.Context, channelID string) ([]*reminder.Reminder, error)
 	RemoveReminder(ctx context.Context, channelID, reminderID string) error
+	GetReminder(ctx context.Context, channelID, reminderID string) (*reminder.Reminder, error)
+	UpdateReminder(ctx context.Context, r *reminder.Reminder) error
+	GetDeliveredReminder(ctx context.Context, channelID, reminderID string) (*reminder.Reminder, error)
+	RemoveDeliveredReminder(ctx context.Context, channelID, reminderID string) error
+	GetDeadLetters(ctx context.Context, channelID string) ([]*reminder.DeadLetter, error)
+	RequeueDeadLetter(ctx context.Context, channelID, reminderID string) error
 }
 
 type TimezoneRepository interface {
@@ -78,48 +89,118 @@ func (m *Module) GetApplicationCommandSubgroups() []*discordgo.ApplicationComman
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Name:        "add",
 					Description: "Add new reminder",
-					Options: []*discordgo.ApplicationCommandOption{
-						{
-							Type:        discordgo.ApplicationCommandOptionString,
-							Name:        "date",
-							Description: "Date in format: 20.12.2021 15:48",
-							Required:    true,
-						},
-						{
-							Type:        discordgo.ApplicationCommandOptionString,
-							Name:        "text",
-							Description: "Text",
-							Required:    true,
-						},
-					},
+					Options: append(reminderAddOptions(), &discordgo.ApplicationCommandOption{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "private",
+						Description: "Only for you, delivered by a direct message",
+					}),
 				},
 				{
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Name:        "show",
 					Description: "Show set reminders",
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "edit",
+					Description: "Edit a reminder",
+				},
 				{
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Name:        "remove",
 					Description: "Remove a reminder",
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "failed",
+					Description: "Show reminders, which could not be delivered",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "retry",
+					Description: "Deliver again a reminder, which could not be delivered",
+				},
 			},
 		},
+		{
+			Name:        "me",
+			Description: "Personal commands",
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remind",
+					Description: "Add a personal reminder delivered by a direct message",
+					Options:     reminderAddOptions(),
+				},
+			},
+		},
+	}
+}
+
+func reminderAddOptions() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "date",
+			Description: "Date, e.g.: 20.12.2021 15:48, in 2h30m, tomorrow 9am, next friday 14:00",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "text",
+			Description: "Text",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "recurrence",
+			Description: "Repeat: daily, weekly, weekdays, monthly, yearly or RRULE, e.g. FREQ=WEEKLY;BYDAY=MO",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "mention",
+			Description: "Users, roles or @here to ping",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "before",
+			Description: "Also warn before the date, e.g.: 15m, 1d",
+		},
 	}
 }
 
 func (m *Module) GetApplicationCommandInteractionHandlers() map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
 	return map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption){
 		"reminder": m.reminderHandler,
+		"me":       m.meHandler,
 	}
 }
 
 func (m *Module) GetMessageComponentInteractionHandlers() map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	return map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate){
-		"reminder_remove": m.reminderRemoveComponentHandler,
+		componentReminderRemove: m.reminderRemoveComponentHandler,
+		componentReminderSnooze: m.reminderSnoozeComponentHandler,
+		componentReminderDone:   m.reminderDoneComponentHandler,
+		componentReminderEdit:   m.reminderEditComponentHandler,
+		componentReminderRetry:  m.reminderRetryComponentHandler,
+
+		common.PickerPageName(componentReminderRemove): m.reminderRemovePageHandler,
+		common.PickerPageName(componentReminderEdit):   m.reminderEditPageHandler,
+		common.PickerPageName(componentReminderRetry):  m.reminderRetryPageHandler,
+	}
+}
+
+func (m *Module) GetModalSubmitInteractionHandlers() map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	return map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate){
+		modalReminderEdit: m.reminderEditModalHandler,
 	}
 }
 
+func (m *Module) GetAutocompleteInteractionHandlers() map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	return map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption){}
+}
+
 func (m *Module) reminderHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
 	cmdOpt := opt.Options[0]
 
@@ -128,53 +209,141 @@ func (m *Module) reminderHandler(ctx context.Context, s *discordgo.Session, i *d
 		m.reminderAddHandler(ctx, s, i, cmdOpt)
 	case "show":
 		m.reminderShowHandler(ctx, s, i)
+	case "edit":
+		m.reminderEditCommandHandler(ctx, s, i)
 	case "remove":
 		m.reminderRemoveCommandHandler(ctx, s, i)
+	case "failed":
+		m.reminderFailedCommandHandler(ctx, s, i)
+	case "retry":
+		m.reminderRetryCommandHandler(ctx, s, i)
+	default:
+		common.UnknownCommandHandler(m.logger, s, i)
+	}
+}
+
+func (m *Module) meHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	cmdOpt := opt.Options[0]
+
+	switch cmdOpt.Name {
+	case "remind":
+		m.addReminder(ctx, s, i, cmdOpt, LabelMeRemind, true)
 	default:
 		common.UnknownCommandHandler(m.logger, s, i)
 	}
 }
 
 func (m *Module) reminderAddHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
-	metrics.CountExecutedCommand(LabelReminderAdd)
+	private := false
+	if privateOpt := common.FindOption(opt.Options, "private"); privateOpt != nil {
+		private = privateOpt.BoolValue()
+	}
 
-	datetimeStr := opt.Options[0].StringValue()
+	m.addReminder(ctx, s, i, opt, LabelReminderAdd, private)
+}
+
+func (m *Module) addReminder(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption, label string, private bool) {
+	metrics.CountExecutedCommand(label)
+
+	datetimeStr := common.FindOption(opt.Options, "date").StringValue()
 
 	location, err := m.timezoneRepository.GetCurrentTimezone(ctx, i.ChannelID)
 	if err != nil {
-		metrics.CountServerErroredCommand(LabelReminderAdd)
+		metrics.CountServerErroredCommand(label)
 		m.logger.WithError(err).Error("failed to get current timezone")
 		common.ServerErrorCommandHandler(m.logger, s, i)
 		return
 	}
 
 	if location == nil {
-		metrics.CountClientErroredCommand(LabelReminderAdd)
+		metrics.CountClientErroredCommand(label)
 		common.ClientErrorCommandHandler(m.logger, s, i, "You have to first set your timezone!\nUse `/organizer config timezone` to set the timezone.")
 		return
 	}
 
-	datetime, err := time.ParseInLocation(datetimeFormat, datetimeStr, location)
+	date, err := organizer.ParseDate(datetimeStr, time.Now(), location)
 	if err != nil {
-		metrics.CountClientErroredCommand(LabelReminderAdd)
-		common.ClientErrorCommandHandler(m.logger, s, i, `Date is wrong. Should be in "day.month.year hour:minute" format.`)
+		metrics.CountClientErroredCommand(label)
+		common.ClientErrorCommandHandler(m.logger, s, i, "Date is wrong. Use `20.12.2021 15:48`, `2021-12-20T15:48`, `in 2h30m`, `tomorrow 9am` or `next friday 14:00`.")
+		return
+	}
+
+	if !date.After(time.Now()) {
+		metrics.CountClientErroredCommand(label)
+		common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("Date %s is in the past.", date.Format(datetimeFormat)))
 		return
 	}
 
-	title := opt.Options[1].StringValue()
+	datetime := *date
+
+	title := common.FindOption(opt.Options, "text").StringValue()
 
-	_, err = m.reminderRepository.AddReminder(ctx, i.ChannelID, &reminder.Reminder{
-		Title: title,
-		Date:  &datetime,
-	})
+	var recurrence string
+	if recurrenceOpt := common.FindOption(opt.Options, "recurrence"); recurrenceOpt != nil {
+		rec, err := reminder.ParseRecurrence(recurrenceOpt.StringValue())
+		if err != nil {
+			metrics.CountClientErroredCommand(label)
+			common.ClientErrorCommandHandler(m.logger, s, i, "Recurrence is wrong. Use daily, weekly, weekdays, monthly, yearly or an RRULE like `FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR`.")
+			return
+		}
+		recurrence = rec.String()
+	}
+
+	var mentions reminder.Mentions
+	if mentionOpt := common.FindOption(opt.Options, "mention"); mentionOpt != nil {
+		mentions, err = parseMentions(mentionOpt.StringValue())
+		if err != nil {
+			metrics.CountClientErroredCommand(label)
+			common.ClientErrorCommandHandler(m.logger, s, i, "Mentions are wrong. Mention users, roles or @here separated by spaces. @everyone is not allowed.")
+			return
+		}
+	}
+
+	var leadTimes []time.Duration
+	if beforeOpt := common.FindOption(opt.Options, "before"); beforeOpt != nil {
+		leadTimes, err = parseLeadTimes(beforeOpt.StringValue())
+		if err != nil {
+			metrics.CountClientErroredCommand(label)
+			common.ClientErrorCommandHandler(m.logger, s, i, "Warning times are wrong. Use durations separated by commas, e.g. `15m, 1h, 1d`.")
+			return
+		}
+	}
+
+	rem := &reminder.Reminder{
+		Title:      title,
+		Date:       &datetime,
+		Recurrence: recurrence,
+		Mentions:   mentions,
+		LeadTimes:  leadTimes,
+	}
+
+	if private {
+		rem.UserID = common.InteractionUserID(i)
+	}
+
+	_, err = m.reminderRepository.AddReminder(ctx, i.ChannelID, rem)
 	if err != nil {
-		metrics.CountServerErroredCommand(LabelReminderAdd)
+		metrics.CountServerErroredCommand(label)
 		m.logger.WithError(err).Error("failed to add reminder")
 		common.ServerErrorCommandHandler(m.logger, s, i)
 		return
 	}
 
 	msg := fmt.Sprintf("🚀 **Reminder added!**\n%s at %s", title, datetime.Format(datetimeFormat))
+	if recurrence != "" {
+		msg += fmt.Sprintf("\n🔁 %s", recurrence)
+	}
+	if !mentions.IsEmpty() {
+		msg += fmt.Sprintf("\n🔔 %s", FormatMentions(mentions))
+	}
+	if len(leadTimes) > 0 {
+		msg += fmt.Sprintf("\n⏳ %s before", formatLeadTimes(leadTimes))
+	}
+
+	if private {
+		common.EphemeralStringResponseHandler(m.logger, s, i, "🔒 "+msg)
+		return
+	}
 
 	common.StringResponseHandler(m.logger, s, i, msg)
 }
@@ -191,11 +360,33 @@ func (m *Module) reminderShowHandler(ctx context.Context, s *discordgo.Session,
 		return
 	}
 
+	reminders, hasPrivate := visibleReminders(reminders, common.InteractionUserID(i))
+
+	location := m.channelLocation(ctx, i.ChannelID)
+
 	builder := strings.Builder{}
 	builder.WriteString("⏰ **Reminders:**\n")
 
 	for _, reminder := range reminders {
-		builder.WriteString(fmt.Sprintf("**%s:** %s\n", reminder.Date.Format(datetimeFormat), reminder.Title))
+		builder.WriteString(fmt.Sprintf("**%s:** %s", reminder.Date.In(location).Format(datetimeFormat), reminder.Title))
+		if reminder.Recurrence != "" {
+			builder.WriteString(fmt.Sprintf(" 🔁 `%s`", reminder.Recurrence))
+		}
+		if !reminder.Mentions.IsEmpty() {
+			builder.WriteString(fmt.Sprintf(" 🔔 %s", FormatMentions(reminder.Mentions)))
+		}
+		if len(reminder.LeadTimes) > 0 {
+			builder.WriteString(fmt.Sprintf(" ⏳ %s", formatLeadTimes(reminder.LeadTimes)))
+		}
+		if reminder.UserID != "" {
+			builder.WriteString(" 🔒")
+		}
+		builder.WriteString("\n")
+	}
+
+	if hasPrivate {
+		common.EphemeralStringResponseHandler(m.logger, s, i, builder.String())
+		return
 	}
 
 	common.StringResponseHandler(m.logger, s, i, builder.String())
@@ -204,60 +395,71 @@ func (m *Module) reminderShowHandler(ctx context.Context, s *discordgo.Session,
 func (m *Module) reminderRemoveCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	metrics.CountExecutedCommand(LabelReminderRemove)
 
-	var options []discordgo.SelectMenuOption
+	m.reminderSelectHandler(ctx, s, i, LabelReminderRemove, componentReminderRemove, reminderRemoveContent)
+}
+
+func (m *Module) reminderRemovePageHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	m.reminderSelectHandler(ctx, s, i, LabelReminderRemove, componentReminderRemove, reminderRemoveContent)
+}
 
+// reminderSelectHandler responds with a picker of the reminders visible to the user.
+// It also handles switching the pages of the picker.
+func (m *Module) reminderSelectHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, label, customID, content string) {
 	reminders, err := m.reminderRepository.GetReminders(ctx, i.ChannelID)
 	if err != nil {
-		metrics.CountServerErroredCommand(LabelReminderRemove)
+		metrics.CountServerErroredCommand(label)
 
 		m.logger.WithError(err).Error("failed to get reminders")
 		common.ServerErrorCommandHandler(m.logger, s, i)
 		return
 	}
 
+	reminders, hasPrivate := visibleReminders(reminders, common.InteractionUserID(i))
+
 	if len(reminders) == 0 {
+		if i.Type == discordgo.InteractionMessageComponent {
+			common.UpdateMessageResponseHandler(m.logger, s, i, "**There are no reminders!**")
+			return
+		}
+
 		common.StringResponseHandler(m.logger, s, i, "**There are no reminders!**")
 		return
 	}
 
+	picker := &common.Picker{
+		Name:    customID,
+		Content: content,
+		Page:    common.PickerPage(i),
+	}
+
+	location := m.channelLocation(ctx, i.ChannelID)
 	for _, reminder := range reminders {
-		options = append(options, discordgo.SelectMenuOption{
+		picker.Options = append(picker.Options, discordgo.SelectMenuOption{
 			Label:       reminder.Title,
-			Description: reminder.Date.Format(datetimeFormat),
+			Description: reminder.Date.In(location).Format(datetimeFormat),
 			Value:       reminder.ID,
 		})
 	}
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: "Select the reminder to remove:",
-			Components: []discordgo.MessageComponent{
-				discordgo.ActionsRow{
-					Components: []discordgo.MessageComponent{
-						discordgo.SelectMenu{
-							CustomID: "reminder_remove",
-							Options:  options,
-						},
-					},
-				},
-			},
-		},
-	})
-	if err != nil {
-		m.logger.WithError(err).
-			Error("cannot respond to remove command")
+	var flags discordgo.MessageFlags
+	if hasPrivate {
+		flags = discordgo.MessageFlagsEphemeral
 	}
+
+	common.PickerResponseHandler(m.logger, s, i, picker, flags)
 }
 
 func (m *Module) reminderRemoveComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	reminderID := i.MessageComponentData().Values[0]
-
-	if err := s.ChannelMessageDelete(i.Message.ChannelID, i.Message.ID); err != nil {
-		metrics.CountServerErroredCommand(LabelReminderRemove)
-		m.logger.WithError(err).Error("failed to delete message")
-		common.ServerErrorCommandHandler(m.logger, s, i)
-		return
+	ephemeral := common.IsEphemeral(i.Message)
+
+	if !ephemeral {
+		if err := s.ChannelMessageDelete(i.Message.ChannelID, i.Message.ID); err != nil {
+			metrics.CountServerErroredCommand(LabelReminderRemove)
+			m.logger.WithError(err).Error("failed to delete message")
+			common.ServerErrorCommandHandler(m.logger, s, i)
+			return
+		}
 	}
 
 	if err := m.reminderRepository.RemoveReminder(ctx, i.ChannelID, reminderID); err != nil {
@@ -267,5 +469,48 @@ func (m *Module) reminderRemoveComponentHandler(ctx context.Context, s *discordg
 		return
 	}
 
+	if ephemeral {
+		common.UpdateMessageResponseHandler(m.logger, s, i, "Reminder removed!")
+		return
+	}
+
 	common.StringResponseHandler(m.logger, s, i, "Reminder removed!")
 }
+
+// channelLocation returns the timezone of the channel, in which the dates are shown.
+// The dates are only shown, so UTC is used, when the timezone is not set or cannot be read.
+func (m *Module) channelLocation(ctx context.Context, channelID string) *time.Location {
+	location, err := m.timezoneRepository.GetCurrentTimezone(ctx, channelID)
+	if err != nil {
+		m.logger.WithError(err).WithField("channelID", channelID).Warn("failed to get current timezone")
+	}
+
+	if location == nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+// visibleReminders filters out personal reminders of other users. It also
+// returns, if any of the visible reminders is personal.
+func visibleReminders(reminders []*reminder.Reminder, userID string) ([]*reminder.Reminder, bool) {
+	var (
+		visible    = make([]*reminder.Reminder, 0, len(reminders))
+		hasPrivate bool
+	)
+
+	for _, rem := range reminders {
+		if rem.UserID == "" {
+			visible = append(visible, rem)
+			continue
+		}
+
+		if rem.UserID == userID {
+			visible = append(visible, rem)
+			hasPrivate = true
+		}
+	}
+
+	return visible, hasPrivate
+}
@@ -0,0 +1,95 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Sender pushes the reminders to Discord channels and DMs. It implements reminder.Pusher.
+type Sender struct {
+	session *discordgo.Session
+}
+
+func NewSender(ds *discordgo.Session) *Sender {
+	return &Sender{
+		session: ds,
+	}
+}
+
+func (send *Sender) PushReminder(ctx context.Context, rem *reminder.Reminder, leadTime time.Duration) error {
+	msg := fmt.Sprintf(`🚨 **Reminder!** <#%s>
+%s
+`, rem.ChannelID, EscapeMassMentions(rem.Title))
+
+	var components []discordgo.MessageComponent
+
+	if leadTime > 0 {
+		msg = fmt.Sprintf(`⏳ **In %s:** <#%s>
+%s
+`, FormatLeadTime(leadTime), rem.ChannelID, EscapeMassMentions(rem.Title))
+	} else {
+		components = DeliveredReminderComponents(rem)
+	}
+
+	if !rem.Mentions.IsEmpty() {
+		msg += FormatMentions(rem.Mentions) + "\n"
+	}
+
+	channelID := rem.ChannelID
+
+	if rem.UserID != "" {
+		dmChannel, err := send.session.UserChannelCreate(rem.UserID)
+		if err != nil {
+			return classifyError(fmt.Errorf("while creating DM channel: %w", err))
+		}
+
+		channelID = dmChannel.ID
+		msg = "🔒 " + msg
+	}
+
+	_, err := send.session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:         msg,
+		Components:      components,
+		AllowedMentions: AllowedMentions(rem.Mentions),
+	})
+	if err != nil {
+		return classifyError(err)
+	}
+
+	return nil
+}
+
+// classifyError marks errors, which will not go away on retry, as permanent.
+func classifyError(err error) error {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) {
+		return err
+	}
+
+	if restErr.Message != nil {
+		switch restErr.Message.Code {
+		case discordgo.ErrCodeUnknownChannel,
+			discordgo.ErrCodeUnknownGuild,
+			discordgo.ErrCodeUnknownUser,
+			discordgo.ErrCodeMissingAccess,
+			discordgo.ErrCodeMissingPermissions,
+			discordgo.ErrCodeCannotSendMessagesToThisUser:
+			return &reminder.PermanentError{Err: err}
+		}
+	}
+
+	if restErr.Response != nil {
+		switch restErr.Response.StatusCode {
+		case http.StatusForbidden, http.StatusNotFound:
+			return &reminder.PermanentError{Err: err}
+		}
+	}
+
+	return err
+}
@@ -11,6 +11,8 @@ type Submodule interface {
 	GetApplicationCommandSubgroups() []*discordgo.ApplicationCommandOption
 	GetApplicationCommandInteractionHandlers() map[string]func(context.Context, *discordgo.Session, *discordgo.InteractionCreate, *discordgo.ApplicationCommandInteractionDataOption)
 	GetMessageComponentInteractionHandlers() map[string]func(context.Context, *discordgo.Session, *discordgo.InteractionCreate)
+	GetModalSubmitInteractionHandlers() map[string]func(context.Context, *discordgo.Session, *discordgo.InteractionCreate)
+	GetAutocompleteInteractionHandlers() map[string]func(context.Context, *discordgo.Session, *discordgo.InteractionCreate, *discordgo.ApplicationCommandInteractionDataOption)
 }
 
 type Module struct {
@@ -89,3 +91,31 @@ func (module *Module) GetMessageComponentInteractionHandlers() map[string]func(c
 
 	return handlers
 }
+
+func (module *Module) GetModalSubmitInteractionHandlers() map[string]func(context.Context, *discordgo.Session, *discordgo.InteractionCreate) {
+	handlers := make(map[string]func(context.Context, *discordgo.Session, *discordgo.InteractionCreate))
+
+	for _, submodule := range module.submodules {
+		modHandlers := submodule.GetModalSubmitInteractionHandlers()
+
+		for ID, handler := range modHandlers {
+			handlers[ID] = handler
+		}
+	}
+
+	return handlers
+}
+
+func (module *Module) GetAutocompleteInteractionHandlers() map[string]func(context.Context, *discordgo.Session, *discordgo.InteractionCreate, *discordgo.ApplicationCommandInteractionDataOption) {
+	handlers := make(map[string]func(context.Context, *discordgo.Session, *discordgo.InteractionCreate, *discordgo.ApplicationCommandInteractionDataOption))
+
+	for _, submodule := range module.submodules {
+		modHandlers := submodule.GetAutocompleteInteractionHandlers()
+
+		for ID, handler := range modHandlers {
+			handlers[ID] = handler
+		}
+	}
+
+	return handlers
+}
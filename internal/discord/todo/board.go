@@ -0,0 +1,324 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Trojan295/organizer-bot/internal/discord/common"
+	"github.com/Trojan295/organizer-bot/internal/metrics"
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	LabelTodoBoard     = "todo_board"
+	LabelTodoBoardDone = "todo_board_done"
+
+	componentTodoBoardDone = "todo_board_done"
+
+	// Discord limits on embeds
+	maxEmbedFields      = 25
+	maxEmbedFieldLength = 1024
+	maxEmbedLength      = 6000
+
+	// maxHiddenFieldLength is the length of the field with the lists left out of the board
+	maxHiddenFieldLength = 64
+
+	boardFooterWithoutButtons = "Use /organizer todo done for tasks without a button · Updated"
+)
+
+func (m *Module) todoBoardHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	messageID, err := m.todoRepository.GetBoardMessageID(ctx, i.ChannelID)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoBoard)
+		m.logger.WithError(err).Error("cannot get board message")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	if messageID != "" {
+		err := m.updateBoard(ctx, s, i.ChannelID, messageID)
+		if err == nil {
+			metrics.CountExecutedCommand(LabelTodoBoard)
+			common.EphemeralStringResponseHandler(m.logger, s, i, fmt.Sprintf("📌 The board is already pinned: %s", messageLink(i.GuildID, i.ChannelID, messageID)))
+			return
+		} else if !isUnknownMessage(err) {
+			metrics.CountServerErroredCommand(LabelTodoBoard)
+			m.logger.WithError(err).Error("cannot update board")
+			common.ServerErrorCommandHandler(m.logger, s, i)
+			return
+		}
+	}
+
+	messageID, err = m.createBoard(ctx, s, i.ChannelID)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoBoard)
+		m.logger.WithError(err).Error("cannot create board")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	metrics.CountExecutedCommand(LabelTodoBoard)
+
+	common.EphemeralStringResponseHandler(m.logger, s, i, fmt.Sprintf("📌 **Board created!** %s", messageLink(i.GuildID, i.ChannelID, messageID)))
+}
+
+func (m *Module) todoBoardDoneComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	_, args := common.ParseCustomID(i.MessageComponentData().CustomID)
+	if len(args) != 2 {
+		metrics.CountClientErroredCommand(LabelTodoBoardDone)
+		common.UnknownCommandHandler(m.logger, s, i)
+		return
+	}
+
+	// the board is refreshed also, when the task was completed already
+	_, err := m.todoRepository.CompleteEntry(ctx, i.ChannelID, args[0], args[1], common.InteractionUserID(i))
+	if err != nil && err != todo.ErrEntryNotFound {
+		metrics.CountServerErroredCommand(LabelTodoBoardDone)
+		m.logger.WithError(err).Error("failed to complete entry")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	embed, components, err := m.boardContent(ctx, i.ChannelID)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoBoardDone)
+		m.logger.WithError(err).Error("failed to get board content")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	metrics.CountExecutedCommand(LabelTodoBoardDone)
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+	if err != nil {
+		m.logger.WithError(err).
+			Error("cannot update board")
+	}
+}
+
+// refreshBoard updates the board in the channel after the entries changed. When the board
+// message was deleted, it is created again. Channels without a board are skipped.
+func (m *Module) refreshBoard(ctx context.Context, s *discordgo.Session, channelID string) {
+	logger := m.logger.WithField("channelID", channelID)
+
+	messageID, err := m.todoRepository.GetBoardMessageID(ctx, channelID)
+	if err != nil {
+		logger.WithError(err).Error("cannot get board message")
+		return
+	}
+
+	if messageID == "" {
+		return
+	}
+
+	err = m.updateBoard(ctx, s, channelID, messageID)
+	if err == nil {
+		return
+	} else if !isUnknownMessage(err) {
+		logger.WithError(err).Error("cannot update board")
+		return
+	}
+
+	if _, err := m.createBoard(ctx, s, channelID); err != nil {
+		logger.WithError(err).Error("cannot create board")
+	}
+}
+
+func (m *Module) updateBoard(ctx context.Context, s *discordgo.Session, channelID, messageID string) error {
+	embed, components, err := m.boardContent(ctx, channelID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         messageID,
+		Channel:    channelID,
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+
+	return err
+}
+
+// createBoard sends and pins a new board message and saves its ID.
+func (m *Module) createBoard(ctx context.Context, s *discordgo.Session, channelID string) (string, error) {
+	embed, components, err := m.boardContent(ctx, channelID)
+	if err != nil {
+		return "", err
+	}
+
+	message, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+	if err != nil {
+		return "", fmt.Errorf("while sending board: %w", err)
+	}
+
+	// the board works also without the pin, e.g. without the Manage Messages permission
+	if err := s.ChannelMessagePin(channelID, message.ID); err != nil {
+		m.logger.WithError(err).WithField("channelID", channelID).Warn("cannot pin board")
+	}
+
+	if err := m.todoRepository.SetBoardMessageID(ctx, channelID, message.ID); err != nil {
+		return "", fmt.Errorf("while saving board message: %w", err)
+	}
+
+	return message.ID, nil
+}
+
+// boardContent returns the embed with all lists in the channel and the Done buttons.
+// The entries are numbered per list, like in /organizer todo done. Lists, which do not
+// fit into the embed, are left out and counted in the last field.
+func (m *Module) boardContent(ctx context.Context, channelID string) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	names, err := m.todoRepository.GetLists(ctx, channelID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("while getting lists: %w", err)
+	}
+
+	now := m.channelNow(ctx, channelID)
+
+	embed := &discordgo.MessageEmbed{
+		Title:     "📌 Todo board",
+		Timestamp: now.Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Updated",
+		},
+	}
+
+	var (
+		buttons []discordgo.MessageComponent
+		tasks   int
+		hidden  int
+	)
+
+	// the longer footer is reserved, as it is known only after all lists
+	length := utf8.RuneCountInString(embed.Title) + utf8.RuneCountInString(boardFooterWithoutButtons)
+
+	for _, name := range names {
+		list, err := m.todoRepository.GetEntries(ctx, channelID, name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("while getting list %s: %w", name, err)
+		}
+
+		if len(list.Entries) == 0 {
+			continue
+		}
+
+		lines := make([]string, 0, len(list.Entries))
+		for idx, entry := range list.Entries {
+			lines = append(lines, fmt.Sprintf("%d. %s", idx+1, FormatEntry(entry, now)))
+		}
+
+		field := &discordgo.MessageEmbedField{
+			Name:  ListTitle(name),
+			Value: truncateFieldValue(strings.Join(lines, "\n")),
+		}
+
+		// room for the field with the left out lists is kept
+		fieldLength := utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+		if hidden > 0 || len(embed.Fields) == maxEmbedFields-1 || length+fieldLength+maxHiddenFieldLength > maxEmbedLength {
+			hidden++
+			continue
+		}
+
+		embed.Fields = append(embed.Fields, field)
+		length += fieldLength
+
+		for idx, entry := range list.Entries {
+			tasks++
+
+			if len(buttons) < maxButtons {
+				buttons = append(buttons, discordgo.Button{
+					Label:    boardButtonLabel(name, idx+1),
+					Style:    discordgo.SecondaryButton,
+					CustomID: common.NewCustomID(componentTodoBoardDone, name, entry.ID),
+				})
+			}
+		}
+	}
+
+	if hidden > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("…and %d more", hidden),
+			Value: "Use /organizer todo show to see the other lists",
+		})
+	}
+
+	if len(embed.Fields) == 0 {
+		embed.Description = "There are no tasks! 🎉"
+	} else if tasks > maxButtons {
+		embed.Footer.Text = boardFooterWithoutButtons
+	}
+
+	return embed, buttonRows(buttons), nil
+}
+
+// boardButtonLabel returns the label of the Done button of an entry. The number is
+// prefixed with the list name, as every list is numbered from 1.
+func boardButtonLabel(listName string, number int) string {
+	if listName == todo.DefaultList {
+		return fmt.Sprintf("✓ %d", number)
+	}
+
+	return fmt.Sprintf("✓ %s %d", listName, number)
+}
+
+// buttonRows splits the buttons into action rows.
+func buttonRows(buttons []discordgo.MessageComponent) []discordgo.MessageComponent {
+	rows := make([]discordgo.MessageComponent, 0)
+
+	for start := 0; start < len(buttons); start += maxButtonsInRow {
+		end := start + maxButtonsInRow
+		if end > len(buttons) {
+			end = len(buttons)
+		}
+
+		rows = append(rows, discordgo.ActionsRow{Components: buttons[start:end]})
+	}
+
+	return rows
+}
+
+func truncateFieldValue(value string) string {
+	runes := []rune(value)
+	if len(runes) <= maxEmbedFieldLength {
+		return value
+	}
+
+	return string(runes[:maxEmbedFieldLength-3]) + "..."
+}
+
+// isUnknownMessage returns true, if the error means the message does not exist anymore.
+func isUnknownMessage(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) {
+		return false
+	}
+
+	if restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMessage {
+		return true
+	}
+
+	return restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
+
+func messageLink(guildID, channelID, messageID string) string {
+	if guildID == "" {
+		guildID = "@me"
+	}
+
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, messageID)
+}
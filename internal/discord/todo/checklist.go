@@ -0,0 +1,276 @@
+package todo
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Trojan295/organizer-bot/internal/discord/common"
+	"github.com/Trojan295/organizer-bot/internal/metrics"
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	LabelTodoChecklist = "todo_checklist"
+
+	componentTodoChecklist = "todo_checklist"
+)
+
+func checklistSubcommand() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        "checklist",
+		Description: "Show, check and add subtasks of a task",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "task",
+				Description: "Number of the task",
+				Required:    true,
+				MinValue:    &minPosition,
+			},
+			listOption("List with the task"),
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "add",
+				Description: "Subtasks to add, separated by commas",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "auto-complete",
+				Description: "Mark the task as done, when all subtasks are done",
+			},
+		},
+	}
+}
+
+func (m *Module) todoChecklistHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	listName, ok := m.getListName(ctx, s, i, opt, LabelTodoChecklist)
+	if !ok {
+		return
+	}
+
+	list, err := m.todoRepository.GetEntries(ctx, i.ChannelID, listName)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoChecklist)
+		m.logger.WithError(err).Error("cannot get Todo list")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	number := int(common.FindOption(opt.Options, "task").IntValue())
+	if number > len(list.Entries) {
+		metrics.CountClientErroredCommand(LabelTodoChecklist)
+		common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("There is no task %d.", number))
+		return
+	}
+
+	entry := list.Entries[number-1]
+	changed := false
+
+	if addOpt := common.FindOption(opt.Options, "add"); addOpt != nil {
+		if err := entry.AddSubtasks(todo.ParseSubtasks(addOpt.StringValue())); err != nil {
+			metrics.CountClientErroredCommand(LabelTodoChecklist)
+			common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("A task can have at most %d subtasks.", todo.MaxSubtasks))
+			return
+		}
+		changed = true
+	}
+
+	if autoCompleteOpt := common.FindOption(opt.Options, "auto-complete"); autoCompleteOpt != nil {
+		entry.AutoComplete = autoCompleteOpt.BoolValue()
+		changed = true
+	}
+
+	if len(entry.Subtasks) == 0 {
+		metrics.CountClientErroredCommand(LabelTodoChecklist)
+		common.ClientErrorCommandHandler(m.logger, s, i, "The task has no subtasks. Use the `add` option to add them.")
+		return
+	}
+
+	if changed {
+		err := m.todoRepository.UpdateEntry(ctx, i.ChannelID, listName, entry)
+		if err == todo.ErrEntryNotFound {
+			metrics.CountClientErroredCommand(LabelTodoChecklist)
+			common.ClientErrorCommandHandler(m.logger, s, i, "This task does not exist anymore.")
+			return
+		} else if err != nil {
+			metrics.CountServerErroredCommand(LabelTodoChecklist)
+			m.logger.WithError(err).Error("failed to update entry")
+			common.ServerErrorCommandHandler(m.logger, s, i)
+			return
+		}
+	}
+
+	metrics.CountExecutedCommand(LabelTodoChecklist)
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    checklistContent(entry, m.channelNow(ctx, i.ChannelID)),
+			Components: checklistComponents(listName, entry),
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Parse: []discordgo.AllowedMentionType{},
+			},
+		},
+	})
+	if err != nil {
+		m.logger.WithError(err).
+			Error("cannot respond with checklist")
+	}
+
+	if changed {
+		m.refreshBoard(ctx, s, i.ChannelID)
+	}
+}
+
+func (m *Module) todoChecklistComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+
+	_, args := common.ParseCustomID(data.CustomID)
+	if len(args) != 2 {
+		metrics.CountClientErroredCommand(LabelTodoChecklist)
+		common.UnknownCommandHandler(m.logger, s, i)
+		return
+	}
+
+	listName, entryID := args[0], args[1]
+
+	entry, err := m.todoRepository.GetEntry(ctx, i.ChannelID, listName, entryID)
+	if err == todo.ErrEntryNotFound {
+		metrics.CountClientErroredCommand(LabelTodoChecklist)
+		common.UpdateMessageResponseHandler(m.logger, s, i, "**This task does not exist anymore.**")
+		return
+	} else if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoChecklist)
+		m.logger.WithError(err).Error("failed to get entry")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	entry.SetDoneSubtasks(data.Values)
+
+	if entry.AutoComplete && entry.IsChecklistDone() {
+		m.completeChecklist(ctx, s, i, listName, entry)
+		return
+	}
+
+	err = m.todoRepository.UpdateEntry(ctx, i.ChannelID, listName, entry)
+	if err == todo.ErrEntryNotFound {
+		metrics.CountClientErroredCommand(LabelTodoChecklist)
+		common.UpdateMessageResponseHandler(m.logger, s, i, "**This task does not exist anymore.**")
+		return
+	} else if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoChecklist)
+		m.logger.WithError(err).Error("failed to update entry")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	metrics.CountExecutedCommand(LabelTodoChecklist)
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    checklistContent(entry, m.channelNow(ctx, i.ChannelID)),
+			Components: checklistComponents(listName, entry),
+		},
+	})
+	if err != nil {
+		m.logger.WithError(err).
+			Error("cannot update checklist")
+	}
+
+	m.refreshBoard(ctx, s, i.ChannelID)
+}
+
+// completeChecklist completes the entry, which has all subtasks done.
+func (m *Module) completeChecklist(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, listName string, entry *todo.Entry) {
+	// the subtasks are saved first, so they are done in the archive and after an undo
+	err := m.todoRepository.UpdateEntry(ctx, i.ChannelID, listName, entry)
+	if err == nil {
+		entry, err = m.todoRepository.CompleteEntry(ctx, i.ChannelID, listName, entry.ID, common.InteractionUserID(i))
+	}
+
+	if err == todo.ErrEntryNotFound {
+		metrics.CountClientErroredCommand(LabelTodoChecklist)
+		common.UpdateMessageResponseHandler(m.logger, s, i, "**This task does not exist anymore.**")
+		return
+	} else if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoChecklist)
+		m.logger.WithError(err).Error("failed to complete entry")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	metrics.CountExecutedCommand(LabelTodoChecklist)
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("**Task done!** All subtasks are done.\n%s", entry.Text),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{
+							Label:    "Undo",
+							Style:    discordgo.SecondaryButton,
+							CustomID: common.NewCustomID(componentTodoUndo, entry.ID),
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		m.logger.WithError(err).
+			Error("cannot respond with done task")
+	}
+
+	m.refreshBoard(ctx, s, i.ChannelID)
+}
+
+func checklistContent(entry *todo.Entry, now time.Time) string {
+	content := fmt.Sprintf("☑️ **Checklist:** %s\n%s", FormatEntry(entry, now), FormatSubtasks(entry))
+
+	if entry.AutoComplete {
+		content += "_The task is marked as done, when all subtasks are done._"
+	}
+
+	return content
+}
+
+// checklistComponents returns a select menu, in which the selected subtasks are done.
+func checklistComponents(listName string, entry *todo.Entry) []discordgo.MessageComponent {
+	minValues := 0
+
+	options := make([]discordgo.SelectMenuOption, 0, len(entry.Subtasks))
+	for _, subtask := range entry.Subtasks {
+		label := subtask.Text
+		if utf8.RuneCountInString(label) > 100 {
+			label = string([]rune(label)[:97]) + "..."
+		}
+
+		options = append(options, discordgo.SelectMenuOption{
+			Label:   label,
+			Value:   subtask.ID,
+			Default: subtask.Done,
+		})
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    common.NewCustomID(componentTodoChecklist, listName, entry.ID),
+					Placeholder: "Select the done subtasks",
+					MinValues:   &minValues,
+					MaxValues:   len(options),
+					Options:     options,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,452 @@
+package todo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/discord/common"
+	"github.com/Trojan295/organizer-bot/internal/metrics"
+	"github.com/Trojan295/organizer-bot/internal/organizer"
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	LabelTodoEdit = "todo_edit"
+	LabelTodoMove = "todo_move"
+
+	componentTodoEdit = "todo_edit"
+	modalTodoEdit     = "todo_edit_modal"
+	todoEditContent   = "Select the task to edit:"
+
+	editInputText     = "text"
+	editInputDue      = "due"
+	editInputPriority = "priority"
+)
+
+// moveTargetPermissions are required in the channel, to which a task is moved
+const moveTargetPermissions = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages
+
+// minPosition is the number of the first task on a list
+var minPosition = float64(1)
+
+func editSubcommand() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        "edit",
+		Description: "Edit the text, due date and priority of a task",
+		Options: []*discordgo.ApplicationCommandOption{
+			listOption("List with the task"),
+		},
+	}
+}
+
+func moveSubcommand() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        "move",
+		Description: "Move a task to another position, list or channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "from",
+				Description: "Number of the task",
+				Required:    true,
+				MinValue:    &minPosition,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "to",
+				Description: "New number of the task",
+				MinValue:    &minPosition,
+			},
+			listOption("List with the task"),
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "to-list",
+				Description:  "List to move the task to",
+				Autocomplete: true,
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "to-channel",
+				Description:  "Channel to move the task to",
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+			},
+		},
+	}
+}
+
+func (m *Module) todoEditCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	listName, ok := m.getListName(ctx, s, i, opt, LabelTodoEdit)
+	if !ok {
+		return
+	}
+
+	m.entryPicker(ctx, s, i, listName, componentTodoEdit, todoEditContent, LabelTodoEdit)
+}
+
+func (m *Module) todoEditPageHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	m.entryPicker(ctx, s, i, pickerListName(i), componentTodoEdit, todoEditContent, LabelTodoEdit)
+}
+
+func (m *Module) todoEditComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	entryID := i.MessageComponentData().Values[0]
+	listName := pickerListName(i)
+
+	entry, ok := m.getEditedEntry(ctx, s, i, listName, entryID)
+	if !ok {
+		return
+	}
+
+	location, err := m.timezoneRepository.GetCurrentTimezone(ctx, i.ChannelID)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoEdit)
+		m.logger.WithError(err).Error("failed to get current timezone")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	due := ""
+	if entry.DueDate != nil {
+		due = entry.DueDate.In(timezoneOrUTC(location)).Format(datetimeFormat)
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: common.NewCustomID(modalTodoEdit, listName, entry.ID),
+			Title:    "Edit task",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  editInputText,
+							Label:     "Text",
+							Style:     discordgo.TextInputParagraph,
+							Value:     entry.Text,
+							Required:  true,
+							MaxLength: 1000,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    editInputDue,
+							Label:       "Due date",
+							Style:       discordgo.TextInputShort,
+							Value:       due,
+							Placeholder: "20.12.2021 15:48, in 3 days, empty for none",
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    editInputPriority,
+							Label:       "Priority",
+							Style:       discordgo.TextInputShort,
+							Value:       entry.Priority.String(),
+							Placeholder: "low, normal, high or urgent",
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		m.logger.WithError(err).
+			Error("cannot respond with edit modal")
+	}
+}
+
+func (m *Module) todoEditModalHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+
+	_, args := common.ParseCustomID(data.CustomID)
+	if len(args) != 2 {
+		metrics.CountClientErroredCommand(LabelTodoEdit)
+		common.UnknownCommandHandler(m.logger, s, i)
+		return
+	}
+
+	listName := args[0]
+
+	entry, ok := m.getEditedEntry(ctx, s, i, listName, args[1])
+	if !ok {
+		return
+	}
+
+	values := common.ModalValues(data)
+
+	entry.SetText(values[editInputText])
+
+	entry.Priority = todo.PriorityNormal
+	if priorityValue := strings.TrimSpace(values[editInputPriority]); priorityValue != "" {
+		priority, err := todo.ParsePriority(priorityValue)
+		if err != nil {
+			metrics.CountClientErroredCommand(LabelTodoEdit)
+			common.ClientErrorCommandHandler(m.logger, s, i, "Priority is wrong. Use low, normal, high or urgent.")
+			return
+		}
+
+		entry.Priority = priority
+	}
+
+	dueDate, ok := m.parseEditedDueDate(ctx, s, i, entry.DueDate, strings.TrimSpace(values[editInputDue]))
+	if !ok {
+		return
+	}
+	entry.DueDate = dueDate
+
+	err := m.todoRepository.UpdateEntry(ctx, i.ChannelID, listName, entry)
+	if err == todo.ErrEntryNotFound {
+		metrics.CountClientErroredCommand(LabelTodoEdit)
+		common.ClientErrorCommandHandler(m.logger, s, i, "This task does not exist anymore.")
+		return
+	} else if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoEdit)
+		m.logger.WithError(err).Error("failed to update entry")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	metrics.CountExecutedCommand(LabelTodoEdit)
+
+	common.StringResponseHandler(m.logger, s, i, fmt.Sprintf("✏️ **Task edited!**\n%s", FormatEntry(entry, m.channelNow(ctx, i.ChannelID))))
+
+	m.refreshBoard(ctx, s, i.ChannelID)
+}
+
+// parseEditedDueDate parses the due date from the edit modal. An unchanged due date is kept,
+// so an overdue task can be edited. When it fails, it responds to the interaction and returns false.
+func (m *Module) parseEditedDueDate(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, current *time.Time, value string) (*time.Time, bool) {
+	if value == "" {
+		return nil, true
+	}
+
+	location, err := m.timezoneRepository.GetCurrentTimezone(ctx, i.ChannelID)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoEdit)
+		m.logger.WithError(err).Error("failed to get current timezone")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return nil, false
+	}
+
+	if current != nil && current.In(timezoneOrUTC(location)).Format(datetimeFormat) == value {
+		return current, true
+	}
+
+	if location == nil {
+		metrics.CountClientErroredCommand(LabelTodoEdit)
+		common.ClientErrorCommandHandler(m.logger, s, i, "You have to first set your timezone to use due dates!\nUse `/organizer config timezone` to set the timezone.")
+		return nil, false
+	}
+
+	dueDate, err := organizer.ParseDate(value, time.Now(), location)
+	if err != nil {
+		metrics.CountClientErroredCommand(LabelTodoEdit)
+		common.ClientErrorCommandHandler(m.logger, s, i, "Due date is wrong. Use `20.12.2021 15:48`, `2021-12-20T15:48`, `in 3 days`, `tomorrow 9am` or `next friday 14:00`.")
+		return nil, false
+	}
+
+	if !dueDate.After(time.Now()) {
+		metrics.CountClientErroredCommand(LabelTodoEdit)
+		common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("Due date %s is in the past.", dueDate.Format(datetimeFormat)))
+		return nil, false
+	}
+
+	return dueDate, true
+}
+
+// channelNow returns the current time in the timezone of the channel, in which the dates are shown.
+// The dates are only shown, so UTC is used, when the timezone cannot be read.
+func (m *Module) channelNow(ctx context.Context, channelID string) time.Time {
+	location, err := m.timezoneRepository.GetCurrentTimezone(ctx, channelID)
+	if err != nil {
+		m.logger.WithError(err).WithField("channelID", channelID).Warn("failed to get current timezone")
+	}
+
+	return time.Now().In(timezoneOrUTC(location))
+}
+
+func timezoneOrUTC(location *time.Location) *time.Location {
+	if location == nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+// getEditedEntry gets the entry. When it fails, it responds to the interaction and returns false.
+func (m *Module) getEditedEntry(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, listName, entryID string) (*todo.Entry, bool) {
+	entry, err := m.todoRepository.GetEntry(ctx, i.ChannelID, listName, entryID)
+	if err == todo.ErrEntryNotFound {
+		metrics.CountClientErroredCommand(LabelTodoEdit)
+		common.ClientErrorCommandHandler(m.logger, s, i, "This task does not exist anymore.")
+		return nil, false
+	} else if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoEdit)
+		m.logger.WithError(err).Error("failed to get entry")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return nil, false
+	}
+
+	return entry, true
+}
+
+func (m *Module) todoMoveHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	listName, ok := m.getListName(ctx, s, i, opt, LabelTodoMove)
+	if !ok {
+		return
+	}
+
+	from := int(common.FindOption(opt.Options, "from").IntValue()) - 1
+
+	to := -1
+	if toOpt := common.FindOption(opt.Options, "to"); toOpt != nil {
+		to = int(toOpt.IntValue()) - 1
+	}
+
+	toChannelID := i.ChannelID
+	if channelOpt := common.FindOption(opt.Options, "to-channel"); channelOpt != nil {
+		toChannelID = channelOpt.ChannelValue(nil).ID
+	}
+
+	toListName := listName
+	if toListOpt := common.FindOption(opt.Options, "to-list"); toListOpt != nil {
+		toListName = normalizeListName(toListOpt.StringValue())
+	} else if toChannelID != i.ChannelID {
+		toListName = todo.DefaultList
+	}
+
+	if toChannelID == i.ChannelID && toListName == listName {
+		m.reorderEntry(ctx, s, i, listName, from, to)
+		return
+	}
+
+	if toChannelID != i.ChannelID {
+		allowed, err := common.HasChannelPermissions(s, i, toChannelID, moveTargetPermissions)
+		if err != nil {
+			metrics.CountServerErroredCommand(LabelTodoMove)
+			m.logger.WithError(err).Error("cannot check permissions in target channel")
+			common.ServerErrorCommandHandler(m.logger, s, i)
+			return
+		}
+
+		if !allowed {
+			metrics.CountClientErroredCommand(LabelTodoMove)
+			common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("You cannot send messages in <#%s>.", toChannelID))
+			return
+		}
+	}
+
+	exists, err := m.todoRepository.ListExists(ctx, toChannelID, toListName)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoMove)
+		m.logger.WithError(err).Error("cannot check list")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	if !exists {
+		metrics.CountClientErroredCommand(LabelTodoMove)
+		common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("List %s does not exist in <#%s>.", toListName, toChannelID))
+		return
+	}
+
+	list, err := m.todoRepository.GetEntries(ctx, i.ChannelID, listName)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoMove)
+		m.logger.WithError(err).Error("cannot get Todo list")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	if from >= len(list.Entries) {
+		metrics.CountClientErroredCommand(LabelTodoMove)
+		common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("There is no task %d.", from+1))
+		return
+	}
+
+	entry, err := m.todoRepository.TransferEntry(ctx, i.ChannelID, listName, list.Entries[from].ID, toChannelID, toListName)
+	if err == todo.ErrEntryNotFound {
+		metrics.CountClientErroredCommand(LabelTodoMove)
+		common.ClientErrorCommandHandler(m.logger, s, i, "This task does not exist anymore.")
+		return
+	} else if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoMove)
+		m.logger.WithError(err).Error("cannot transfer entry")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	if to >= 0 {
+		if err := m.moveTransferredEntry(ctx, toChannelID, toListName, entry.ID, to); err != nil {
+			metrics.CountServerErroredCommand(LabelTodoMove)
+			m.logger.WithError(err).Error("cannot move entry")
+			common.ServerErrorCommandHandler(m.logger, s, i)
+			return
+		}
+	}
+
+	metrics.CountExecutedCommand(LabelTodoMove)
+
+	target := ListTitle(toListName)
+	if toChannelID != i.ChannelID {
+		target = fmt.Sprintf("%s in <#%s>", target, toChannelID)
+	}
+
+	common.StringResponseHandler(m.logger, s, i, fmt.Sprintf("📦 **Task moved to %s!**\n%s", target, entry.Text))
+
+	m.refreshBoard(ctx, s, i.ChannelID)
+	if toChannelID != i.ChannelID {
+		m.refreshBoard(ctx, s, toChannelID)
+	}
+}
+
+// moveTransferredEntry moves the entry to the position on the list, to which it was transferred.
+func (m *Module) moveTransferredEntry(ctx context.Context, channelID, listName, entryID string, to int) error {
+	list, err := m.todoRepository.GetEntries(ctx, channelID, listName)
+	if err != nil {
+		return err
+	}
+
+	for idx, entry := range list.Entries {
+		if entry.ID == entryID {
+			_, err := m.todoRepository.MoveEntry(ctx, channelID, listName, idx, to)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Module) reorderEntry(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, listName string, from, to int) {
+	if to < 0 {
+		metrics.CountClientErroredCommand(LabelTodoMove)
+		common.ClientErrorCommandHandler(m.logger, s, i, "Set `to`, `to-list` or `to-channel` to move the task.")
+		return
+	}
+
+	entry, err := m.todoRepository.MoveEntry(ctx, i.ChannelID, listName, from, to)
+	if err == todo.ErrEntryNotFound {
+		metrics.CountClientErroredCommand(LabelTodoMove)
+		common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("There is no task %d.", from+1))
+		return
+	} else if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoMove)
+		m.logger.WithError(err).Error("cannot move entry")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	metrics.CountExecutedCommand(LabelTodoMove)
+
+	common.StringResponseHandler(m.logger, s, i, fmt.Sprintf("↕️ **Task moved!**\n%s", entry.Text))
+
+	m.refreshBoard(ctx, s, i.ChannelID)
+}
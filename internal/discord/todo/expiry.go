@@ -0,0 +1,218 @@
+package todo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Trojan295/organizer-bot/internal/discord/common"
+	"github.com/Trojan295/organizer-bot/internal/metrics"
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	LabelTodoExpiry = "todo_expiry"
+	LabelTodoKeep   = "todo_keep"
+
+	componentTodoKeep = "todo_keep"
+
+	expiryPermissions = discordgo.PermissionManageChannels
+
+	// Discord limits on buttons in a message
+	maxButtonsInRow = 5
+	maxButtons      = 25
+)
+
+func expirySubcommand() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        "expiry",
+		Description: "Show or set, when tasks are removed",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "mode",
+				Description: "When tasks are removed",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "never", Value: string(todo.ExpiryNever)},
+					{Name: "after days of inactivity", Value: string(todo.ExpiryInactivity)},
+					{Name: "days after completion", Value: string(todo.ExpiryCompletion)},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "days",
+				Description: "Number of days",
+			},
+		},
+	}
+}
+
+func (m *Module) todoExpiryHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	modeOpt := common.FindOption(opt.Options, "mode")
+	if modeOpt == nil {
+		m.getExpiryHandler(ctx, s, i)
+		return
+	}
+
+	if !common.HasPermissions(i, expiryPermissions) {
+		metrics.CountClientErroredCommand(LabelTodoExpiry)
+		common.ClientErrorCommandHandler(m.logger, s, i, "You need the Manage Channels permission to change the expiry of tasks.")
+		return
+	}
+
+	var days int
+	if daysOpt := common.FindOption(opt.Options, "days"); daysOpt != nil {
+		days = int(daysOpt.IntValue())
+	}
+
+	policy, err := todo.NewExpiryPolicy(todo.ExpiryMode(modeOpt.StringValue()), days)
+	if err != nil {
+		metrics.CountClientErroredCommand(LabelTodoExpiry)
+		common.ClientErrorCommandHandler(m.logger, s, i, "Days are wrong. Set `days` between 1 and 365.")
+		return
+	}
+
+	if err := m.todoRepository.SetExpiryPolicy(ctx, i.ChannelID, policy); err != nil {
+		metrics.CountServerErroredCommand(LabelTodoExpiry)
+		m.logger.WithError(err).Error("cannot set expiry policy")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	metrics.CountExecutedCommand(LabelTodoExpiry)
+
+	common.StringResponseHandler(m.logger, s, i, fmt.Sprintf("⌛ **Expiry set:** %s", formatExpiryPolicy(policy)))
+}
+
+func (m *Module) getExpiryHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	policy, err := m.todoRepository.GetExpiryPolicy(ctx, i.ChannelID)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoExpiry)
+		m.logger.WithError(err).Error("cannot get expiry policy")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	metrics.CountExecutedCommand(LabelTodoExpiry)
+
+	common.StringResponseHandler(m.logger, s, i, fmt.Sprintf("⌛ **Expiry:** %s", formatExpiryPolicy(policy)))
+}
+
+func formatExpiryPolicy(policy todo.ExpiryPolicy) string {
+	switch policy.Mode {
+	case todo.ExpiryNever:
+		return "tasks and completed tasks are kept forever"
+	case todo.ExpiryCompletion:
+		return fmt.Sprintf("tasks are kept until done, completed tasks are removed after %d days", policy.Days)
+	default:
+		return fmt.Sprintf("tasks are removed after %d days without changes", policy.Days)
+	}
+}
+
+// FormatExpiringEntries returns the warning about entries, which expire soon.
+func FormatExpiringEntries(expiring []*todo.ExpiringEntry) string {
+	builder := strings.Builder{}
+	builder.WriteString("⌛ **Expiring soon:**\n")
+
+	for idx, entry := range expiring {
+		if idx == maxButtons {
+			builder.WriteString(fmt.Sprintf("... and %d more\n", len(expiring)-maxButtons))
+			break
+		}
+
+		builder.WriteString(fmt.Sprintf("%d. %s <t:%d:R>", idx+1, entry.Entry.Text, entry.ExpiresAt.Unix()))
+		if entry.ListName != todo.DefaultList {
+			builder.WriteString(fmt.Sprintf(" (%s)", entry.ListName))
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+// ExpiringEntriesComponents returns the Keep buttons for the entries listed by FormatExpiringEntries.
+func ExpiringEntriesComponents(expiring []*todo.ExpiringEntry) []discordgo.MessageComponent {
+	buttons := make([]discordgo.MessageComponent, 0, len(expiring))
+
+	for idx, entry := range expiring {
+		if idx == maxButtons {
+			break
+		}
+
+		buttons = append(buttons, discordgo.Button{
+			Label:    fmt.Sprintf("Keep %d", idx+1),
+			Style:    discordgo.SecondaryButton,
+			CustomID: common.NewCustomID(componentTodoKeep, entry.ListName, entry.Entry.ID),
+		})
+	}
+
+	return buttonRows(buttons)
+}
+
+func (m *Module) todoKeepComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+
+	_, args := common.ParseCustomID(customID)
+	if len(args) != 2 {
+		metrics.CountClientErroredCommand(LabelTodoKeep)
+		common.UnknownCommandHandler(m.logger, s, i)
+		return
+	}
+
+	_, err := m.todoRepository.TouchEntry(ctx, i.ChannelID, args[0], args[1])
+	if err == todo.ErrEntryNotFound {
+		metrics.CountClientErroredCommand(LabelTodoKeep)
+		common.ClientErrorCommandHandler(m.logger, s, i, "This task does not exist anymore.")
+		return
+	} else if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoKeep)
+		m.logger.WithError(err).Error("failed to keep entry")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	metrics.CountExecutedCommand(LabelTodoKeep)
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    i.Message.Content,
+			Components: withoutButton(i.Message.Components, customID),
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Parse: []discordgo.AllowedMentionType{},
+			},
+		},
+	})
+	if err != nil {
+		m.logger.WithError(err).
+			Error("cannot update expiring entries message")
+	}
+}
+
+// withoutButton returns the message components without the button with the custom ID.
+func withoutButton(components []discordgo.MessageComponent, customID string) []discordgo.MessageComponent {
+	rows := make([]discordgo.MessageComponent, 0, len(components))
+
+	for _, component := range components {
+		row, ok := component.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+
+		buttons := make([]discordgo.MessageComponent, 0, len(row.Components))
+		for _, rowComponent := range row.Components {
+			if button, ok := rowComponent.(*discordgo.Button); ok && button.CustomID == customID {
+				continue
+			}
+			buttons = append(buttons, rowComponent)
+		}
+
+		if len(buttons) > 0 {
+			rows = append(rows, discordgo.ActionsRow{Components: buttons})
+		}
+	}
+
+	return rows
+}
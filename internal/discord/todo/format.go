@@ -0,0 +1,143 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/todo"
+)
+
+const datetimeFormat = "02.01.2006 15:04"
+
+var priorityMarkers = map[todo.Priority]string{
+	todo.PriorityLow:    "🔵 ",
+	todo.PriorityHigh:   "🟠 ",
+	todo.PriorityUrgent: "🔴 ",
+}
+
+// FormatEntry renders the entry with its priority, due date and assignee.
+// Overdue entries are highlighted. The due date is shown in the location of now.
+func FormatEntry(entry *todo.Entry, now time.Time) string {
+	builder := strings.Builder{}
+
+	builder.WriteString(priorityMarkers[entry.Priority])
+	builder.WriteString(entry.Text)
+
+	if done, total := entry.SubtaskProgress(); total > 0 {
+		builder.WriteString(fmt.Sprintf(" (%d/%d)", done, total))
+	}
+
+	if entry.DueDate != nil {
+		due := entry.DueDate.In(now.Location()).Format(datetimeFormat)
+		if entry.IsOverdue(now) {
+			builder.WriteString(fmt.Sprintf(" ⚠️ **overdue since %s**", due))
+		} else {
+			builder.WriteString(fmt.Sprintf(" 📅 %s", due))
+		}
+	}
+
+	if entry.AssigneeID != "" {
+		builder.WriteString(fmt.Sprintf(" 👤 <@%s>", entry.AssigneeID))
+	}
+
+	// the tags given in the text are already visible
+	inText := make(map[string]bool)
+	for _, tag := range todo.ParseTags(entry.Text) {
+		inText[tag] = true
+	}
+
+	for _, tag := range entry.Tags {
+		if !inText[tag] {
+			builder.WriteString(fmt.Sprintf(" `#%s`", tag))
+		}
+	}
+
+	return builder.String()
+}
+
+// FormatEntriesByTag renders the entries grouped by their tags. The entries keep
+// their numbers on the list.
+func FormatEntriesByTag(entries []*todo.Entry, now time.Time) string {
+	numbers := make(map[string]int, len(entries))
+	for idx, entry := range entries {
+		numbers[entry.ID] = idx + 1
+	}
+
+	builder := strings.Builder{}
+
+	for _, group := range todo.GroupByTag(entries) {
+		if group.Tag == "" {
+			builder.WriteString("🏷️ _No tag_\n")
+		} else {
+			builder.WriteString(fmt.Sprintf("🏷️ **#%s**\n", group.Tag))
+		}
+
+		for _, entry := range group.Entries {
+			builder.WriteString(fmt.Sprintf("%d. %s\n", numbers[entry.ID], FormatEntry(entry, now)))
+			builder.WriteString(FormatSubtasks(entry))
+		}
+	}
+
+	return builder.String()
+}
+
+// FormatFilter describes the filter of the shown tasks, e.g. "#ops, assigned to @user, overdue".
+func FormatFilter(filter *todo.Filter) string {
+	parts := make([]string, 0, 3)
+
+	if filter.Tag != "" {
+		parts = append(parts, "#"+filter.Tag)
+	}
+
+	if filter.AssigneeID != "" {
+		parts = append(parts, fmt.Sprintf("assigned to <@%s>", filter.AssigneeID))
+	}
+
+	if filter.Overdue {
+		parts = append(parts, "overdue")
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// FormatSubtasks renders the checklist of the entry, one indented line per subtask.
+func FormatSubtasks(entry *todo.Entry) string {
+	builder := strings.Builder{}
+
+	for _, subtask := range entry.Subtasks {
+		if subtask.Done {
+			builder.WriteString(fmt.Sprintf("    ✅ ~~%s~~\n", subtask.Text))
+		} else {
+			builder.WriteString(fmt.Sprintf("    ⬜ %s\n", subtask.Text))
+		}
+	}
+
+	return builder.String()
+}
+
+// FormatDigestChanges returns the summary of changes since the previous digest,
+// e.g. "2 new, 3 done since yesterday".
+func FormatDigestChanges(changes *todo.DigestChanges, now time.Time) string {
+	since := changes.Since
+	now = now.In(since.Location())
+
+	sinceDay := time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, since.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var when string
+	switch {
+	case sinceDay.Equal(today):
+		when = fmt.Sprintf("since %s", since.Format("15:04"))
+	case sinceDay.Equal(today.AddDate(0, 0, -1)):
+		when = "since yesterday"
+	default:
+		when = fmt.Sprintf("since %s", since.Format(dateFormat))
+	}
+
+	if changes.New == 0 && changes.Done == 0 {
+		return fmt.Sprintf("🔄 _No changes %s_", when)
+	}
+
+	return fmt.Sprintf("🔄 _%d new, %d done %s_", changes.New, changes.Done, when)
+}
@@ -0,0 +1,132 @@
+package todo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/discord/common"
+	"github.com/Trojan295/organizer-bot/internal/metrics"
+	"github.com/Trojan295/organizer-bot/internal/organizer"
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	LabelTodoUndo    = "todo_undo"
+	LabelTodoHistory = "todo_history"
+
+	componentTodoUndo = "todo_undo"
+
+	dateFormat = "02.01.2006"
+
+	defaultHistoryDays = 7
+)
+
+func (m *Module) todoUndoComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	metrics.CountExecutedCommand(LabelTodoUndo)
+
+	_, args := common.ParseCustomID(i.MessageComponentData().CustomID)
+	if len(args) != 1 {
+		metrics.CountClientErroredCommand(LabelTodoUndo)
+		common.UnknownCommandHandler(m.logger, s, i)
+		return
+	}
+
+	archived, err := m.todoRepository.RestoreEntry(ctx, i.ChannelID, args[0])
+	if err == todo.ErrEntryNotFound {
+		metrics.CountClientErroredCommand(LabelTodoUndo)
+		common.ClientErrorCommandHandler(m.logger, s, i, "This task cannot be restored anymore.")
+		return
+	} else if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoUndo)
+		m.logger.WithError(err).Error("failed to restore entry")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	msg := fmt.Sprintf("↩️ **Task restored!**\n%s", archived.Entry.Text)
+	if archived.ListName != todo.DefaultList {
+		msg = fmt.Sprintf("↩️ **Task restored to %s!**\n%s", archived.ListName, archived.Entry.Text)
+	}
+
+	common.UpdateMessageResponseHandler(m.logger, s, i, msg)
+
+	m.refreshBoard(ctx, s, i.ChannelID)
+}
+
+func (m *Module) todoHistoryHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	location, err := m.timezoneRepository.GetCurrentTimezone(ctx, i.ChannelID)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoHistory)
+		m.logger.WithError(err).Error("failed to get current timezone")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	if location == nil {
+		location = time.UTC
+	}
+
+	now := time.Now().In(location)
+	from := time.Date(now.Year(), now.Month(), now.Day()-defaultHistoryDays, 0, 0, 0, 0, location)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+
+	for _, day := range []struct {
+		name string
+		date *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		dayOpt := common.FindOption(opt.Options, day.name)
+		if dayOpt == nil {
+			continue
+		}
+
+		parsed, err := organizer.ParseDay(dayOpt.StringValue(), now, location)
+		if err != nil {
+			metrics.CountClientErroredCommand(LabelTodoHistory)
+			common.ClientErrorCommandHandler(m.logger, s, i, "Day is wrong. Use `01.12.2021`, `2021-12-01`, `yesterday` or `today`.")
+			return
+		}
+
+		*day.date = *parsed
+	}
+
+	// the last day is included
+	until := to.AddDate(0, 0, 1).Add(-time.Second)
+
+	archived, err := m.todoRepository.GetArchivedEntries(ctx, i.ChannelID, from, until)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoHistory)
+		m.logger.WithError(err).Error("failed to get archived entries")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("📜 **Completed tasks %s - %s:**\n", from.Format(dateFormat), to.Format(dateFormat)))
+
+	if len(archived) == 0 {
+		builder.WriteString("Nothing was completed.\n")
+	}
+
+	lines := make([]string, 0, len(archived))
+	for _, entry := range archived {
+		line := fmt.Sprintf("- **%s** %s", entry.Entry.CompletedAt.In(location).Format(datetimeFormat), entry.Entry.Text)
+		if entry.ListName != todo.DefaultList {
+			line += fmt.Sprintf(" 📁 %s", entry.ListName)
+		}
+		if entry.Entry.CompletedBy != "" {
+			line += fmt.Sprintf(" ✅ <@%s>", entry.Entry.CompletedBy)
+		}
+
+		lines = append(lines, line+"\n")
+	}
+
+	// the response is limited by the length, as the texts of the entries can be long
+	common.AppendLines(&builder, lines)
+
+	metrics.CountExecutedCommand(LabelTodoHistory)
+
+	common.StringResponseHandler(m.logger, s, i, builder.String())
+}
@@ -0,0 +1,338 @@
+package todo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Trojan295/organizer-bot/internal/discord/common"
+	"github.com/Trojan295/organizer-bot/internal/metrics"
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	LabelTodoListCreate = "todolist_create"
+	LabelTodoListRename = "todolist_rename"
+	LabelTodoListDelete = "todolist_delete"
+	LabelTodoListShow   = "todolist_show"
+
+	// Discord limit on autocomplete choices
+	maxAutocompleteChoices = 25
+)
+
+func listOption(description string) *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionString,
+		Name:         "list",
+		Description:  description,
+		Autocomplete: true,
+	}
+}
+
+func listsSubgroup() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Name:        "todolist",
+		Description: "Manage todo lists",
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "create",
+				Description: "Create a todo list",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Name, e.g.: backlog, this-sprint",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "rename",
+				Description: "Rename a todo list",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "list",
+						Description:  "List to rename",
+						Required:     true,
+						Autocomplete: true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "New name",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "delete",
+				Description: "Delete a todo list with all its tasks",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "list",
+						Description:  "List to delete",
+						Required:     true,
+						Autocomplete: true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Show todo lists in the channel",
+			},
+		},
+	}
+}
+
+func (m *Module) todoListHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	cmdOpt := opt.Options[0]
+
+	switch cmdOpt.Name {
+	case "create":
+		m.createListHandler(ctx, s, i, cmdOpt)
+	case "rename":
+		m.renameListHandler(ctx, s, i, cmdOpt)
+	case "delete":
+		m.deleteListHandler(ctx, s, i, cmdOpt)
+	case "show":
+		m.showListsHandler(ctx, s, i)
+
+	default:
+		common.UnknownCommandHandler(m.logger, s, i)
+	}
+}
+
+func (m *Module) createListHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	listName := normalizeListName(common.FindOption(opt.Options, "name").StringValue())
+
+	if err := todo.ValidateListName(listName); err != nil {
+		metrics.CountClientErroredCommand(LabelTodoListCreate)
+		common.ClientErrorCommandHandler(m.logger, s, i, "List name is wrong. Use up to 32 letters, digits, `-` or `_`.")
+		return
+	}
+
+	err := m.todoRepository.CreateList(ctx, i.ChannelID, listName)
+	if err == todo.ErrListExists {
+		metrics.CountClientErroredCommand(LabelTodoListCreate)
+		common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("List %s already exists.", listName))
+		return
+	} else if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoListCreate)
+		m.logger.WithError(err).Error("cannot create list")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	metrics.CountExecutedCommand(LabelTodoListCreate)
+
+	common.StringResponseHandler(m.logger, s, i, fmt.Sprintf("📁 **List %s created!**", listName))
+}
+
+func (m *Module) renameListHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	oldName := normalizeListName(common.FindOption(opt.Options, "list").StringValue())
+	newName := normalizeListName(common.FindOption(opt.Options, "name").StringValue())
+
+	if err := todo.ValidateListName(newName); err != nil {
+		metrics.CountClientErroredCommand(LabelTodoListRename)
+		common.ClientErrorCommandHandler(m.logger, s, i, "List name is wrong. Use up to 32 letters, digits, `-` or `_`.")
+		return
+	}
+
+	err := m.todoRepository.RenameList(ctx, i.ChannelID, oldName, newName)
+	if m.handleListError(s, i, err, oldName, newName, LabelTodoListRename) {
+		return
+	}
+
+	metrics.CountExecutedCommand(LabelTodoListRename)
+
+	common.StringResponseHandler(m.logger, s, i, fmt.Sprintf("📁 **List %s renamed to %s!**", oldName, newName))
+
+	m.refreshBoard(ctx, s, i.ChannelID)
+}
+
+func (m *Module) deleteListHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	listName := normalizeListName(common.FindOption(opt.Options, "list").StringValue())
+
+	err := m.todoRepository.DeleteList(ctx, i.ChannelID, listName)
+	if m.handleListError(s, i, err, listName, "", LabelTodoListDelete) {
+		return
+	}
+
+	metrics.CountExecutedCommand(LabelTodoListDelete)
+
+	common.StringResponseHandler(m.logger, s, i, fmt.Sprintf("🗑️ **List %s deleted!**", listName))
+
+	m.refreshBoard(ctx, s, i.ChannelID)
+}
+
+func (m *Module) showListsHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	names, err := m.todoRepository.GetLists(ctx, i.ChannelID)
+	if err != nil {
+		metrics.CountServerErroredCommand(LabelTodoListShow)
+		m.logger.WithError(err).Error("cannot get lists")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString("📁 **Lists:**\n")
+
+	for _, name := range names {
+		list, err := m.todoRepository.GetEntries(ctx, i.ChannelID, name)
+		if err != nil {
+			metrics.CountServerErroredCommand(LabelTodoListShow)
+			m.logger.WithError(err).Error("cannot get Todo list")
+			common.ServerErrorCommandHandler(m.logger, s, i)
+			return
+		}
+
+		builder.WriteString(fmt.Sprintf("- %s (%d)\n", name, len(list.Entries)))
+	}
+
+	metrics.CountExecutedCommand(LabelTodoListShow)
+
+	common.StringResponseHandler(m.logger, s, i, builder.String())
+}
+
+// handleListError responds to the interaction, if changing the list failed.
+// It returns true, if there was an error.
+func (m *Module) handleListError(s *discordgo.Session, i *discordgo.InteractionCreate, err error, listName, newName, label string) bool {
+	switch err {
+	case nil:
+		return false
+	case todo.ErrListNotFound:
+		metrics.CountClientErroredCommand(label)
+		common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("List %s does not exist.", listName))
+	case todo.ErrListExists:
+		metrics.CountClientErroredCommand(label)
+		common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("List %s already exists.", newName))
+	case todo.ErrDefaultList:
+		metrics.CountClientErroredCommand(label)
+		common.ClientErrorCommandHandler(m.logger, s, i, "The default list cannot be renamed or deleted.")
+	default:
+		metrics.CountServerErroredCommand(label)
+		m.logger.WithError(err).Error("cannot change list")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+	}
+
+	return true
+}
+
+// getListName returns the list from the list option or the default list, if it is not set.
+// When the list does not exist, it responds to the interaction and returns false.
+func (m *Module) getListName(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption, label string) (string, bool) {
+	listOpt := common.FindOption(opt.Options, "list")
+	if listOpt == nil {
+		return todo.DefaultList, true
+	}
+
+	listName := normalizeListName(listOpt.StringValue())
+
+	exists, err := m.todoRepository.ListExists(ctx, i.ChannelID, listName)
+	if err != nil {
+		metrics.CountServerErroredCommand(label)
+		m.logger.WithError(err).Error("cannot check list")
+		common.ServerErrorCommandHandler(m.logger, s, i)
+		return "", false
+	}
+
+	if !exists {
+		metrics.CountClientErroredCommand(label)
+		common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("List %s does not exist.\nUse `/organizer todolist create` to create it.", listName))
+		return "", false
+	}
+
+	return listName, true
+}
+
+// listAutocompleteHandler suggests the lists in the channel for the focused list option.
+func (m *Module) listAutocompleteHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	if len(opt.Options) == 0 {
+		return
+	}
+
+	cmdOpt := opt.Options[0]
+
+	var typed string
+	channelID := i.ChannelID
+	for _, option := range cmdOpt.Options {
+		if option.Focused {
+			typed = normalizeListName(option.StringValue())
+
+			// the target list of a move is suggested from the target channel
+			if toChannelOpt := common.FindOption(cmdOpt.Options, "to-channel"); option.Name == "to-list" && toChannelOpt != nil {
+				channelID = toChannelOpt.ChannelValue(nil).ID
+			}
+		}
+	}
+
+	names, err := m.todoRepository.GetLists(ctx, channelID)
+	if err != nil {
+		m.logger.WithError(err).Error("cannot get lists")
+		return
+	}
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(names))
+	for _, name := range names {
+		// the default list cannot be renamed or deleted
+		if opt.Name == "todolist" && name == todo.DefaultList {
+			continue
+		}
+
+		if !strings.HasPrefix(name, typed) {
+			continue
+		}
+
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  name,
+			Value: name,
+		})
+
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		m.logger.WithError(err).
+			Error("cannot respond with list choices")
+	}
+}
+
+// pickerListName returns the list, on which the picker was opened.
+// Pickers sent before lists were added belong to the default list.
+func pickerListName(i *discordgo.InteractionCreate) string {
+	args := common.PickerArgs(i)
+	if len(args) == 0 {
+		return todo.DefaultList
+	}
+
+	return args[0]
+}
+
+// ListTitle returns the heading of the list in messages.
+func ListTitle(listName string) string {
+	if listName == todo.DefaultList {
+		return "Tasks"
+	}
+
+	return fmt.Sprintf("Tasks (%s)", listName)
+}
+
+func normalizeListName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Trojan295/organizer-bot/internal/discord/common"
 	"github.com/Trojan295/organizer-bot/internal/metrics"
+	"github.com/Trojan295/organizer-bot/internal/organizer"
 	"github.com/Trojan295/organizer-bot/internal/todo"
 	"github.com/bwmarrin/discordgo"
 	log "github.com/sirupsen/logrus"
@@ -16,23 +18,48 @@ const (
 	LabelTodoAdd  = "todo_add"
 	LabelTodoShow = "todo_show"
 	LabelTodoDone = "todo_done"
+
+	componentTodoDone = "todo_done"
 )
 
 type Repository interface {
-	GetEntry(ctx context.Context, channelID, entryID string) (*todo.Entry, error)
-	GetEntries(ctx context.Context, channelID string) (*todo.List, error)
-	AddEntry(ctx context.Context, channelID string, entry *todo.Entry) (string, error)
-	RemoveEntry(ctx context.Context, channelID, entryID string) error
+	GetEntry(ctx context.Context, channelID, listName, entryID string) (*todo.Entry, error)
+	GetEntries(ctx context.Context, channelID, listName string) (*todo.List, error)
+	GetEntriesByTag(ctx context.Context, channelID, listName, tag string) (*todo.List, error)
+	AddEntry(ctx context.Context, channelID, listName string, entry *todo.Entry) (string, error)
+	UpdateEntry(ctx context.Context, channelID, listName string, entry *todo.Entry) error
+	MoveEntry(ctx context.Context, channelID, listName string, from, to int) (*todo.Entry, error)
+	TransferEntry(ctx context.Context, channelID, listName, entryID, toChannelID, toListName string) (*todo.Entry, error)
+	RemoveEntry(ctx context.Context, channelID, listName, entryID string) error
+	CompleteEntry(ctx context.Context, channelID, listName, entryID, userID string) (*todo.Entry, error)
+	RestoreEntry(ctx context.Context, channelID, entryID string) (*todo.ArchivedEntry, error)
+	GetArchivedEntries(ctx context.Context, channelID string, from, to time.Time) ([]*todo.ArchivedEntry, error)
+	GetLists(ctx context.Context, channelID string) ([]string, error)
+	ListExists(ctx context.Context, channelID, listName string) (bool, error)
+	CreateList(ctx context.Context, channelID, listName string) error
+	RenameList(ctx context.Context, channelID, oldName, newName string) error
+	DeleteList(ctx context.Context, channelID, listName string) error
+	TouchEntry(ctx context.Context, channelID, listName, entryID string) (*todo.Entry, error)
+	GetExpiryPolicy(ctx context.Context, channelID string) (todo.ExpiryPolicy, error)
+	SetExpiryPolicy(ctx context.Context, channelID string, policy todo.ExpiryPolicy) error
+	GetBoardMessageID(ctx context.Context, channelID string) (string, error)
+	SetBoardMessageID(ctx context.Context, channelID, messageID string) error
+}
+
+type TimezoneRepository interface {
+	GetCurrentTimezone(ctx context.Context, ID string) (*time.Location, error)
 }
 
 type Module struct {
-	todoRepository Repository
-	logger         *log.Entry
+	todoRepository     Repository
+	timezoneRepository TimezoneRepository
+	logger             *log.Entry
 }
 
 type ModuleConfig struct {
-	TodoRepo Repository
-	Logger   *log.Entry
+	TodoRepo           Repository
+	TimezoneRepository TimezoneRepository
+	Logger             *log.Entry
 }
 
 func NewTodoModule(cfg *ModuleConfig) (*Module, error) {
@@ -40,14 +67,19 @@ func NewTodoModule(cfg *ModuleConfig) (*Module, error) {
 		return nil, fmt.Errorf("missing TodoRepo")
 	}
 
+	if cfg.TimezoneRepository == nil {
+		return nil, fmt.Errorf("missing TimezoneRepository")
+	}
+
 	if cfg.Logger == nil {
 		cfg.Logger = log.NewEntry(log.New()).
 			WithField("struct", "TodoModule")
 	}
 
 	return &Module{
-		todoRepository: cfg.TodoRepo,
-		logger:         cfg.Logger,
+		todoRepository:     cfg.TodoRepo,
+		timezoneRepository: cfg.TimezoneRepository,
+		logger:             cfg.Logger,
 	}, nil
 }
 
@@ -69,32 +101,150 @@ func (m *Module) GetApplicationCommandSubgroups() []*discordgo.ApplicationComman
 							Description: "Message",
 							Required:    true,
 						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "due",
+							Description: "Due date, e.g.: 20.12.2021 15:48, in 3 days, next friday 14:00",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "priority",
+							Description: "Priority",
+							Choices:     priorityChoices(),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        "assignee",
+							Description: "User, who should do the task",
+						},
+						listOption("List to add the task to"),
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "subtasks",
+							Description: "Checklist of the task, separated by commas",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "auto-complete",
+							Description: "Mark the task as done, when all subtasks are done",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "tags",
+							Description: "Tags separated by spaces, #tags in the message are added too",
+						},
 					},
 				},
 				{
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Name:        "show",
 					Description: "Show todo list",
+					Options: []*discordgo.ApplicationCommandOption{
+						listOption("List to show"),
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "tag",
+							Description: "Show only tasks with the tag",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        "assignee",
+							Description: "Show only tasks assigned to the user",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "overdue",
+							Description: "Show only overdue tasks",
+						},
+					},
 				},
 				{
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Name:        "done",
 					Description: "Mark task as done",
+					Options: []*discordgo.ApplicationCommandOption{
+						listOption("List with the task"),
+					},
+				},
+				editSubcommand(),
+				moveSubcommand(),
+				checklistSubcommand(),
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "history",
+					Description: "Show completed tasks",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "from",
+							Description: "First day, e.g.: 01.12.2021, yesterday. Default: 7 days ago",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "to",
+							Description: "Last day, e.g.: 07.12.2021, today. Default: today",
+						},
+					},
+				},
+				expirySubcommand(),
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "board",
+					Description: "Pin a board with all tasks, which is updated on every change",
 				},
 			},
 		},
+		listsSubgroup(),
 	}
 }
 
+func priorityChoices() []*discordgo.ApplicationCommandOptionChoice {
+	priorities := []todo.Priority{todo.PriorityLow, todo.PriorityNormal, todo.PriorityHigh, todo.PriorityUrgent}
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(priorities))
+	for _, priority := range priorities {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  priority.String(),
+			Value: priority.String(),
+		})
+	}
+
+	return choices
+}
+
 func (m *Module) GetApplicationCommandInteractionHandlers() map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
 	return map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption){
-		"todo": m.todoHandler,
+		"todo":     m.todoHandler,
+		"todolist": m.todoListHandler,
 	}
 }
 
 func (m *Module) GetMessageComponentInteractionHandlers() map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	return map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate){
-		"todo_done": m.todoDoneComponentHandler,
+		componentTodoDone: m.todoDoneComponentHandler,
+		componentTodoUndo: m.todoUndoComponentHandler,
+		componentTodoKeep: m.todoKeepComponentHandler,
+		componentTodoEdit: m.todoEditComponentHandler,
+
+		componentTodoChecklist: m.todoChecklistComponentHandler,
+
+		componentTodoBoardDone: m.todoBoardDoneComponentHandler,
+
+		common.PickerPageName(componentTodoDone): m.todoDonePageHandler,
+		common.PickerPageName(componentTodoEdit): m.todoEditPageHandler,
+	}
+}
+
+func (m *Module) GetModalSubmitInteractionHandlers() map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	return map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate){
+		modalTodoEdit: m.todoEditModalHandler,
+	}
+}
+
+func (m *Module) GetAutocompleteInteractionHandlers() map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	return map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption){
+		"todo":     m.listAutocompleteHandler,
+		"todolist": m.listAutocompleteHandler,
 	}
 }
 
@@ -108,16 +258,54 @@ func (m *Module) todoHandler(ctx context.Context, s *discordgo.Session, i *disco
 		m.showTodoHandler(ctx, s, i, cmdOpt)
 	case "done":
 		m.todoDoneCommandHandler(ctx, s, i, cmdOpt)
+	case "edit":
+		m.todoEditCommandHandler(ctx, s, i, cmdOpt)
+	case "move":
+		m.todoMoveHandler(ctx, s, i, cmdOpt)
+	case "checklist":
+		m.todoChecklistHandler(ctx, s, i, cmdOpt)
+	case "history":
+		m.todoHistoryHandler(ctx, s, i, cmdOpt)
+	case "expiry":
+		m.todoExpiryHandler(ctx, s, i, cmdOpt)
+	case "board":
+		m.todoBoardHandler(ctx, s, i)
 
 	default:
 		common.UnknownCommandHandler(m.logger, s, i)
 	}
 }
 
-func (m *Module) showTodoHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, _ *discordgo.ApplicationCommandInteractionDataOption) {
+func (m *Module) showTodoHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
 	channelID := i.ChannelID
 
-	list, err := m.todoRepository.GetEntries(ctx, channelID)
+	listName, ok := m.getListName(ctx, s, i, opt, LabelTodoShow)
+	if !ok {
+		return
+	}
+
+	filter := &todo.Filter{}
+	if tagOpt := common.FindOption(opt.Options, "tag"); tagOpt != nil {
+		filter.Tag = todo.NormalizeTag(tagOpt.StringValue())
+	}
+	if assigneeOpt := common.FindOption(opt.Options, "assignee"); assigneeOpt != nil {
+		filter.AssigneeID = assigneeOpt.UserValue(nil).ID
+	}
+	if overdueOpt := common.FindOption(opt.Options, "overdue"); overdueOpt != nil {
+		filter.Overdue = overdueOpt.BoolValue()
+	}
+
+	var (
+		list *todo.List
+		err  error
+	)
+
+	// the tag index is used, so only the entries with the tag are read
+	if filter.Tag != "" {
+		list, err = m.todoRepository.GetEntriesByTag(ctx, channelID, listName, filter.Tag)
+	} else {
+		list, err = m.todoRepository.GetEntries(ctx, channelID, listName)
+	}
 	if err != nil {
 		metrics.CountServerErroredCommand(LabelTodoShow)
 		m.logger.WithError(err).Error("cannot get Todo list")
@@ -126,10 +314,27 @@ func (m *Module) showTodoHandler(ctx context.Context, s *discordgo.Session, i *d
 	}
 
 	builder := strings.Builder{}
-	builder.WriteString("📰 **Tasks:**\n")
+	builder.WriteString(fmt.Sprintf("📰 **%s:**\n", ListTitle(listName)))
 
-	for i, entry := range list.Entries {
-		builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, entry.Text))
+	now := m.channelNow(ctx, channelID)
+	if filter.IsEmpty() {
+		for i, entry := range list.Entries {
+			builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, FormatEntry(entry, now)))
+			builder.WriteString(FormatSubtasks(entry))
+		}
+	} else {
+		builder.WriteString(fmt.Sprintf("🔎 _%s_\n", FormatFilter(filter)))
+
+		filtered := filter.Apply(list.Entries, now)
+		if len(filtered) == 0 {
+			builder.WriteString("No tasks match.\n")
+		}
+
+		// the numbers are not shown, because they are positions on the whole list
+		for _, entry := range filtered {
+			builder.WriteString(fmt.Sprintf("- %s\n", FormatEntry(entry, now)))
+			builder.WriteString(FormatSubtasks(entry))
+		}
 	}
 
 	metrics.CountExecutedCommand(LabelTodoShow)
@@ -138,11 +343,91 @@ func (m *Module) showTodoHandler(ctx context.Context, s *discordgo.Session, i *d
 }
 
 func (m *Module) addTodoHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
-	todoText := opt.Options[0].StringValue()
+	todoText := common.FindOption(opt.Options, "msg").StringValue()
 
-	_, err := m.todoRepository.AddEntry(ctx, i.ChannelID, &todo.Entry{
-		Text: todoText,
-	})
+	listName, ok := m.getListName(ctx, s, i, opt, LabelTodoAdd)
+	if !ok {
+		return
+	}
+
+	entry := &todo.Entry{
+		Text:      todoText,
+		CreatedBy: common.InteractionUserID(i),
+		Tags:      todo.ParseTags(todoText),
+	}
+
+	if tagsOpt := common.FindOption(opt.Options, "tags"); tagsOpt != nil {
+		tags := strings.Fields(tagsOpt.StringValue())
+		for _, tag := range tags {
+			if err := todo.ValidateTag(tag); err != nil {
+				metrics.CountClientErroredCommand(LabelTodoAdd)
+				common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("Tag %s is wrong. Use letters, digits, - or _.", tag))
+				return
+			}
+		}
+
+		entry.Tags = todo.MergeTags(entry.Tags, tags)
+	}
+
+	if dueOpt := common.FindOption(opt.Options, "due"); dueOpt != nil {
+		location, err := m.timezoneRepository.GetCurrentTimezone(ctx, i.ChannelID)
+		if err != nil {
+			metrics.CountServerErroredCommand(LabelTodoAdd)
+			m.logger.WithError(err).Error("failed to get current timezone")
+			common.ServerErrorCommandHandler(m.logger, s, i)
+			return
+		}
+
+		if location == nil {
+			metrics.CountClientErroredCommand(LabelTodoAdd)
+			common.ClientErrorCommandHandler(m.logger, s, i, "You have to first set your timezone to use due dates!\nUse `/organizer config timezone` to set the timezone.")
+			return
+		}
+
+		dueDate, err := organizer.ParseDate(dueOpt.StringValue(), time.Now(), location)
+		if err != nil {
+			metrics.CountClientErroredCommand(LabelTodoAdd)
+			common.ClientErrorCommandHandler(m.logger, s, i, "Due date is wrong. Use `20.12.2021 15:48`, `2021-12-20T15:48`, `in 3 days`, `tomorrow 9am` or `next friday 14:00`.")
+			return
+		}
+
+		if !dueDate.After(time.Now()) {
+			metrics.CountClientErroredCommand(LabelTodoAdd)
+			common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("Due date %s is in the past.", dueDate.Format(datetimeFormat)))
+			return
+		}
+
+		entry.DueDate = dueDate
+	}
+
+	if priorityOpt := common.FindOption(opt.Options, "priority"); priorityOpt != nil {
+		priority, err := todo.ParsePriority(priorityOpt.StringValue())
+		if err != nil {
+			metrics.CountClientErroredCommand(LabelTodoAdd)
+			common.ClientErrorCommandHandler(m.logger, s, i, "Priority is wrong. Use low, normal, high or urgent.")
+			return
+		}
+
+		entry.Priority = priority
+	}
+
+	if assigneeOpt := common.FindOption(opt.Options, "assignee"); assigneeOpt != nil {
+		entry.AssigneeID = assigneeOpt.UserValue(nil).ID
+	}
+
+	if subtasksOpt := common.FindOption(opt.Options, "subtasks"); subtasksOpt != nil {
+		if err := entry.AddSubtasks(todo.ParseSubtasks(subtasksOpt.StringValue())); err != nil {
+			metrics.CountClientErroredCommand(LabelTodoAdd)
+			common.ClientErrorCommandHandler(m.logger, s, i, fmt.Sprintf("A task can have at most %d subtasks.", todo.MaxSubtasks))
+			return
+		}
+	}
+
+	if autoCompleteOpt := common.FindOption(opt.Options, "auto-complete"); autoCompleteOpt != nil {
+		entry.AutoComplete = autoCompleteOpt.BoolValue()
+	}
+
+	_, err := m.todoRepository.AddEntry(ctx, i.ChannelID, listName, entry)
 	if err != nil {
 		metrics.CountServerErroredCommand(LabelTodoAdd)
 		m.logger.WithError(err).Error("cannot add entry")
@@ -152,13 +437,43 @@ func (m *Module) addTodoHandler(ctx context.Context, s *discordgo.Session, i *di
 
 	metrics.CountExecutedCommand(LabelTodoAdd)
 
-	common.StringResponseHandler(m.logger, s, i, fmt.Sprintf("🚀 **Task added!**\n%s", todoText))
+	now := m.channelNow(ctx, i.ChannelID)
+
+	msg := fmt.Sprintf("🚀 **Task added!**\n%s", FormatEntry(entry, now))
+	if listName != todo.DefaultList {
+		msg = fmt.Sprintf("🚀 **Task added to %s!**\n%s", listName, FormatEntry(entry, now))
+	}
+
+	common.StringResponseHandler(m.logger, s, i, msg)
+
+	m.refreshBoard(ctx, s, i.ChannelID)
+}
+
+func (m *Module) todoDoneCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) {
+	listName, ok := m.getListName(ctx, s, i, opt, LabelTodoDone)
+	if !ok {
+		return
+	}
+
+	m.todoDonePicker(ctx, s, i, listName)
+}
+
+func (m *Module) todoDonePageHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	m.todoDonePicker(ctx, s, i, pickerListName(i))
+}
+
+// todoDonePicker responds with a picker of the tasks on the list. It also handles
+// switching the pages of the picker.
+func (m *Module) todoDonePicker(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, listName string) {
+	m.entryPicker(ctx, s, i, listName, componentTodoDone, "Select the task to mark as done:", LabelTodoDone)
 }
 
-func (m *Module) todoDoneCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, _ *discordgo.ApplicationCommandInteractionDataOption) {
-	list, err := m.todoRepository.GetEntries(ctx, i.ChannelID)
+// entryPicker responds with a picker of the tasks on the list, which sends the selected
+// task to the component handler registered under name.
+func (m *Module) entryPicker(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, listName, name, content, label string) {
+	list, err := m.todoRepository.GetEntries(ctx, i.ChannelID, listName)
 	if err != nil {
-		metrics.CountServerErroredCommand(LabelTodoDone)
+		metrics.CountServerErroredCommand(label)
 
 		m.logger.WithError(err).Error("failed to get entries")
 		common.ServerErrorCommandHandler(m.logger, s, i)
@@ -166,52 +481,49 @@ func (m *Module) todoDoneCommandHandler(ctx context.Context, s *discordgo.Sessio
 	}
 
 	if len(list.Entries) == 0 {
+		if i.Type == discordgo.InteractionMessageComponent {
+			common.UpdateMessageResponseHandler(m.logger, s, i, "**There are no tasks!**")
+			return
+		}
+
 		common.StringResponseHandler(m.logger, s, i, "**There are no tasks!**")
 		return
 	}
 
-	var options []discordgo.SelectMenuOption
+	picker := &common.Picker{
+		Name:    name,
+		Args:    []string{listName},
+		Content: content,
+		Page:    common.PickerPage(i),
+	}
+
 	for _, entry := range list.Entries {
-		label := entry.Text
+		optionLabel := entry.Text
 		description := ""
 
-		if len(label) > 90 {
-			label = entry.Text[0:90] + "..."
-			description = "..." + entry.Text[90:]
+		// the label and the description are limited to 100 characters
+		if runes := []rune(entry.Text); len(runes) > 90 {
+			optionLabel = string(runes[:90]) + "..."
+			description = "..." + string(runes[90:])
+
+			if rest := runes[90:]; len(rest) > 94 {
+				description = "..." + string(rest[:94]) + "..."
+			}
 		}
 
-		options = append(options, discordgo.SelectMenuOption{
-			Label:       label,
+		picker.Options = append(picker.Options, discordgo.SelectMenuOption{
+			Label:       optionLabel,
 			Description: description,
 			Value:       entry.ID,
 		})
 	}
 
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: "Select the task to mark as done:",
-			Components: []discordgo.MessageComponent{
-				discordgo.ActionsRow{
-					Components: []discordgo.MessageComponent{
-						discordgo.SelectMenu{
-							CustomID: "todo_done",
-							Options:  options,
-						},
-					},
-				},
-			},
-		},
-	})
-	if err != nil {
-		m.logger.WithError(err).
-			WithField("func", "todoDoneCommandHandler").
-			Error("cannot respond")
-	}
+	common.PickerResponseHandler(m.logger, s, i, picker, 0)
 }
 
 func (m *Module) todoDoneComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	entryID := i.MessageComponentData().Values[0]
+	listName := pickerListName(i)
 
 	if err := s.ChannelMessageDelete(i.Message.ChannelID, i.Message.ID); err != nil {
 		metrics.CountServerErroredCommand(LabelTodoDone)
@@ -219,23 +531,44 @@ func (m *Module) todoDoneComponentHandler(ctx context.Context, s *discordgo.Sess
 		common.ServerErrorCommandHandler(m.logger, s, i)
 	}
 
-	entry, err := m.todoRepository.GetEntry(ctx, i.ChannelID, entryID)
-	if err != nil {
-		metrics.CountServerErroredCommand(LabelTodoDone)
-		m.logger.WithError(err).Error("failed to get entry")
-		common.ServerErrorCommandHandler(m.logger, s, i)
+	entry, err := m.todoRepository.CompleteEntry(ctx, i.ChannelID, listName, entryID, common.InteractionUserID(i))
+	if err == todo.ErrEntryNotFound {
+		metrics.CountClientErroredCommand(LabelTodoDone)
+		common.ClientErrorCommandHandler(m.logger, s, i, "This task is already done.")
 		return
-	}
-
-	if err := m.todoRepository.RemoveEntry(ctx, i.ChannelID, entryID); err != nil {
+	} else if err != nil {
 		metrics.CountServerErroredCommand(LabelTodoDone)
-		m.logger.WithError(err).Error("failed to remove entry")
+		m.logger.WithError(err).Error("failed to complete entry")
 		common.ServerErrorCommandHandler(m.logger, s, i)
 		return
 	}
 
 	metrics.CountExecutedCommand(LabelTodoDone)
 
-	message := fmt.Sprintf("**Task done!**\n%s", entry.Text)
-	common.StringResponseHandler(m.logger, s, i, message)
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("**Task done!**\n%s", entry.Text),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{
+							Label:    "Undo",
+							Style:    discordgo.SecondaryButton,
+							CustomID: common.NewCustomID(componentTodoUndo, entry.ID),
+						},
+					},
+				},
+			},
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Parse: []discordgo.AllowedMentionType{},
+			},
+		},
+	})
+	if err != nil {
+		m.logger.WithError(err).
+			Error("cannot respond with done task")
+	}
+
+	m.refreshBoard(ctx, s, i.ChannelID)
 }
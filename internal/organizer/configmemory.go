@@ -0,0 +1,66 @@
+package organizer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryConfigStore keeps the settings in memory. It is used for local development and tests.
+type MemoryConfigStore struct {
+	mu        sync.Mutex
+	timezones map[string]*time.Location
+	schedules map[string]DigestSchedule
+}
+
+func NewMemoryConfigStore() *MemoryConfigStore {
+	return &MemoryConfigStore{
+		timezones: make(map[string]*time.Location),
+		schedules: make(map[string]DigestSchedule),
+	}
+}
+
+// Returns the time.Location set on an channel. If it is not set, it will return nil.
+func (store *MemoryConfigStore) GetCurrentTimezone(ctx context.Context, id string) (*time.Location, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.timezones[id], nil
+}
+
+func (store *MemoryConfigStore) SetCurrentTimezone(ctx context.Context, id string, loc *time.Location) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.timezones[id] = loc
+	return nil
+}
+
+// Returns the todo digest schedule set on an channel. If it is not set, it will return nil.
+func (store *MemoryConfigStore) GetDigestSchedule(ctx context.Context, id string) (*DigestSchedule, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	schedule, ok := store.schedules[id]
+	if !ok {
+		return nil, nil
+	}
+
+	// a copy is returned, so the caller can change it
+	schedule.Weekdays = append([]time.Weekday{}, schedule.Weekdays...)
+	schedule.Times = append([]int{}, schedule.Times...)
+
+	return &schedule, nil
+}
+
+func (store *MemoryConfigStore) SetDigestSchedule(ctx context.Context, id string, schedule *DigestSchedule) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	stored := *schedule
+	stored.Weekdays = append([]time.Weekday{}, schedule.Weekdays...)
+	stored.Times = append([]int{}, schedule.Times...)
+
+	store.schedules[id] = stored
+	return nil
+}
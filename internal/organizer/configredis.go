@@ -46,3 +46,32 @@ func (store *RedisConfigStore) SetCurrentTimezone(ctx context.Context, id string
 
 	return nil
 }
+
+// Returns the todo digest schedule set on an channel. If it is not set, it will return nil.
+func (store *RedisConfigStore) GetDigestSchedule(ctx context.Context, id string) (*DigestSchedule, error) {
+	key := fmt.Sprintf("config:%s:digestSchedule", id)
+
+	value, err := store.redisClient.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("while getting key %s: %w", key, err)
+	}
+
+	schedule, err := ParseDigestSchedule(value)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing schedule %s: %w", value, err)
+	}
+
+	return schedule, nil
+}
+
+func (store *RedisConfigStore) SetDigestSchedule(ctx context.Context, id string, schedule *DigestSchedule) error {
+	key := fmt.Sprintf("config:%s:digestSchedule", id)
+
+	if err := store.redisClient.Set(ctx, key, schedule.String(), 0).Err(); err != nil {
+		return fmt.Errorf("while setting key %s: %w", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package organizer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/sqlutils"
+)
+
+// SQLConfigStore keeps the settings in the "channel_config" table.
+type SQLConfigStore struct {
+	db *sqlutils.DB
+}
+
+func NewSQLConfigStore(db *sqlutils.DB) *SQLConfigStore {
+	return &SQLConfigStore{
+		db: db,
+	}
+}
+
+// Returns the time.Location set on an channel. If it is not set, it will return nil.
+func (store *SQLConfigStore) GetCurrentTimezone(ctx context.Context, id string) (*time.Location, error) {
+	var tz sql.NullString
+
+	err := store.db.QueryRowContext(ctx, "SELECT timezone FROM channel_config WHERE channel_id = ?", id).Scan(&tz)
+	if err == sql.ErrNoRows || (err == nil && !tz.Valid) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("while selecting timezone of %s: %w", id, err)
+	}
+
+	loc, err := time.LoadLocation(tz.String)
+	if err != nil {
+		return nil, fmt.Errorf("while loading location %s: %w", tz.String, err)
+	}
+
+	return loc, nil
+}
+
+func (store *SQLConfigStore) SetCurrentTimezone(ctx context.Context, id string, loc *time.Location) error {
+	_, err := store.db.ExecContext(ctx, `INSERT INTO channel_config (channel_id, timezone) VALUES (?, ?)
+		ON CONFLICT (channel_id) DO UPDATE SET timezone = excluded.timezone`, id, loc.String())
+	if err != nil {
+		return fmt.Errorf("while setting timezone of %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// Returns the todo digest schedule set on an channel. If it is not set, it will return nil.
+func (store *SQLConfigStore) GetDigestSchedule(ctx context.Context, id string) (*DigestSchedule, error) {
+	var value sql.NullString
+
+	err := store.db.QueryRowContext(ctx, "SELECT digest_schedule FROM channel_config WHERE channel_id = ?", id).Scan(&value)
+	if err == sql.ErrNoRows || (err == nil && !value.Valid) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("while selecting digest schedule of %s: %w", id, err)
+	}
+
+	schedule, err := ParseDigestSchedule(value.String)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing schedule %s: %w", value.String, err)
+	}
+
+	return schedule, nil
+}
+
+func (store *SQLConfigStore) SetDigestSchedule(ctx context.Context, id string, schedule *DigestSchedule) error {
+	_, err := store.db.ExecContext(ctx, `INSERT INTO channel_config (channel_id, digest_schedule) VALUES (?, ?)
+		ON CONFLICT (channel_id) DO UPDATE SET digest_schedule = excluded.digest_schedule`, id, schedule.String())
+	if err != nil {
+		return fmt.Errorf("while setting digest schedule of %s: %w", id, err)
+	}
+
+	return nil
+}
@@ -1,7 +1,279 @@
 package organizer
 
-import "time"
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
 
-func ParseDate(date string) (*time.Time, error) {
-	return nil, nil
+const (
+	datetimeFormat = "02.01.2006 15:04"
+
+	// defaultHour is used, when only the day is given, e.g. "tomorrow".
+	defaultHour = 9
+)
+
+var (
+	// layouts with an explicit offset are converted to the location
+	absoluteLayoutsWithOffset = []string{
+		time.RFC3339,
+		"2006-01-02T15:04Z07:00",
+	}
+
+	absoluteLayouts = []string{
+		datetimeFormat,
+		"02.01.2006",
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	}
+
+	relativeRegexp     = regexp.MustCompile(`^in\s+(.+)$`)
+	durationPartRegexp = regexp.MustCompile(`^(\d+)\s*(weeks?|w|days?|d|hours?|hrs?|h|minutes?|mins?|m)\s*(?:and\s+|,\s*)?`)
+	dayRegexp          = regexp.MustCompile(`^(today|tomorrow|(next\s+)?(monday|mon|tuesday|tues|tue|wednesday|wed|thursday|thurs|thur|thu|friday|fri|saturday|sat|sunday|sun))\b\s*(?:at\s+)?`)
+	clockRegexp        = regexp.MustCompile(`^(?:at\s+)?(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+
+	weekdayNames = map[string]time.Weekday{
+		"monday":    time.Monday,
+		"mon":       time.Monday,
+		"tuesday":   time.Tuesday,
+		"tues":      time.Tuesday,
+		"tue":       time.Tuesday,
+		"wednesday": time.Wednesday,
+		"wed":       time.Wednesday,
+		"thursday":  time.Thursday,
+		"thurs":     time.Thursday,
+		"thur":      time.Thursday,
+		"thu":       time.Thursday,
+		"friday":    time.Friday,
+		"fri":       time.Friday,
+		"saturday":  time.Saturday,
+		"sat":       time.Saturday,
+		"sunday":    time.Sunday,
+		"sun":       time.Sunday,
+	}
+)
+
+// ParseDate parses a date given by a user in the location. Supported are:
+//   - absolute dates: "20.12.2021 15:48", "2021-12-20T15:48", "2021-12-20T15:48:00+01:00"
+//   - relative durations: "in 2h30m", "in 3 days", "in 1 week 2 days"
+//   - days with an optional time: "tomorrow 9am", "next friday 14:00", "mon at 8:30pm", "17:00"
+func ParseDate(date string, now time.Time, loc *time.Location) (*time.Time, error) {
+	date = strings.TrimSpace(date)
+	now = now.In(loc)
+
+	for _, layout := range absoluteLayoutsWithOffset {
+		if t, err := time.Parse(layout, date); err == nil {
+			t = t.In(loc)
+			return &t, nil
+		}
+	}
+
+	for _, layout := range absoluteLayouts {
+		if t, err := time.ParseInLocation(layout, date, loc); err == nil {
+			return &t, nil
+		}
+	}
+
+	date = strings.Join(strings.Fields(strings.ToLower(date)), " ")
+
+	if match := relativeRegexp.FindStringSubmatch(date); match != nil {
+		return parseRelativeDate(match[1], now)
+	}
+
+	return parseDayAndClock(date, now)
+}
+
+func parseRelativeDate(duration string, now time.Time) (*time.Time, error) {
+	days, offset, err := parseDurationParts(duration)
+	if err != nil {
+		return nil, err
+	}
+
+	// days are added in the calendar, so "in 1 day" keeps the time across DST changes
+	t := now.AddDate(0, 0, days).Add(offset)
+	return &t, nil
+}
+
+// ParseDuration parses a duration given by a user, e.g. "15m", "1h30m" or "2 days".
+// A day is always 24 hours long.
+func ParseDuration(duration string) (time.Duration, error) {
+	duration = strings.Join(strings.Fields(strings.ToLower(duration)), " ")
+
+	days, offset, err := parseDurationParts(duration)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(days)*24*time.Hour + offset, nil
+}
+
+func parseDurationParts(duration string) (int, time.Duration, error) {
+	var (
+		days   int
+		offset time.Duration
+	)
+
+	if duration == "" {
+		return 0, 0, fmt.Errorf("empty duration")
+	}
+
+	for duration != "" {
+		match := durationPartRegexp.FindStringSubmatch(duration)
+		if match == nil {
+			return 0, 0, fmt.Errorf("invalid duration %q", duration)
+		}
+
+		value, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid number %s: %w", match[1], err)
+		}
+
+		switch unit := match[2]; {
+		case strings.HasPrefix(unit, "w"):
+			days += 7 * value
+		case strings.HasPrefix(unit, "d"):
+			days += value
+		case strings.HasPrefix(unit, "h"):
+			offset += time.Duration(value) * time.Hour
+		default:
+			offset += time.Duration(value) * time.Minute
+		}
+
+		duration = duration[len(match[0]):]
+	}
+
+	return days, offset, nil
+}
+
+func parseDayAndClock(date string, now time.Time) (*time.Time, error) {
+	var (
+		day        = now
+		dayGiven   bool
+		nextOnly   bool
+		weekday    *time.Weekday
+		hour, min  = defaultHour, 0
+		clockGiven bool
+	)
+
+	if match := dayRegexp.FindStringSubmatch(date); match != nil {
+		dayGiven = true
+
+		switch match[1] {
+		case "today":
+		case "tomorrow":
+			day = now.AddDate(0, 0, 1)
+		default:
+			wd := weekdayNames[match[3]]
+			weekday = &wd
+			nextOnly = match[2] != ""
+		}
+
+		date = date[len(match[0]):]
+	}
+
+	if date != "" {
+		match := clockRegexp.FindStringSubmatch(date)
+		if match == nil {
+			return nil, fmt.Errorf("invalid date %q", date)
+		}
+
+		var err error
+		if hour, min, err = parseClock(match[1], match[2], match[3]); err != nil {
+			return nil, err
+		}
+		clockGiven = true
+	}
+
+	if !dayGiven && !clockGiven {
+		return nil, fmt.Errorf("empty date")
+	}
+
+	at := func(d time.Time) time.Time {
+		year, month, dayOfMonth := d.Date()
+		return time.Date(year, month, dayOfMonth, hour, min, 0, 0, now.Location())
+	}
+
+	if weekday != nil {
+		offset := (int(*weekday) - int(now.Weekday()) + 7) % 7
+		if offset == 0 && (nextOnly || !at(now).After(now)) {
+			offset = 7
+		}
+
+		t := at(now.AddDate(0, 0, offset))
+		return &t, nil
+	}
+
+	t := at(day)
+
+	// only a time was given, which has already passed today
+	if !dayGiven && !t.After(now) {
+		t = at(now.AddDate(0, 0, 1))
+	}
+
+	return &t, nil
+}
+
+func parseClock(hourStr, minStr, ampm string) (int, int, error) {
+	if minStr == "" && ampm == "" {
+		return 0, 0, fmt.Errorf("ambiguous time %s, use 14:00 or 2pm", hourStr)
+	}
+
+	hour, err := strconv.Atoi(hourStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid hour %s: %w", hourStr, err)
+	}
+
+	min := 0
+	if minStr != "" {
+		if min, err = strconv.Atoi(minStr); err != nil {
+			return 0, 0, fmt.Errorf("invalid minute %s: %w", minStr, err)
+		}
+	}
+
+	switch ampm {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, fmt.Errorf("invalid hour %d%s", hour, ampm)
+		}
+
+		hour %= 12
+		if ampm == "pm" {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || min > 59 {
+		return 0, 0, fmt.Errorf("invalid time %02d:%02d", hour, min)
+	}
+
+	return hour, min, nil
+}
+
+// ParseDay parses a day given by a user and returns its start in the location.
+// Supported are "today", "yesterday", "20.12.2021" and "2021-12-20".
+func ParseDay(day string, now time.Time, loc *time.Location) (*time.Time, error) {
+	day = strings.ToLower(strings.TrimSpace(day))
+	now = now.In(loc)
+
+	startOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	switch day {
+	case "today":
+		return &startOfToday, nil
+	case "yesterday":
+		t := startOfToday.AddDate(0, 0, -1)
+		return &t, nil
+	}
+
+	for _, layout := range []string{"02.01.2006", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, day, loc); err == nil {
+			return &t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot parse day %s", day)
 }
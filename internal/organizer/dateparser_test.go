@@ -0,0 +1,206 @@
+package organizer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/organizer"
+	"github.com/stretchr/testify/require"
+)
+
+func RequireLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return loc
+}
+
+func TestParseDate(t *testing.T) {
+	warsaw := RequireLocation("Europe/Warsaw")
+
+	// Tuesday
+	now := time.Date(2021, 11, 2, 10, 15, 0, 0, warsaw)
+
+	tt := map[string]struct {
+		input    string
+		expected time.Time
+		fails    bool
+	}{
+		"LegacyFormat": {
+			input:    "20.12.2021 15:48",
+			expected: time.Date(2021, 12, 20, 15, 48, 0, 0, warsaw),
+		},
+		"ISO8601Local": {
+			input:    "2021-12-20T15:48",
+			expected: time.Date(2021, 12, 20, 15, 48, 0, 0, warsaw),
+		},
+		"ISO8601WithOffset": {
+			input:    "2021-12-20T15:48:00Z",
+			expected: time.Date(2021, 12, 20, 16, 48, 0, 0, warsaw),
+		},
+		"InHoursAndMinutes": {
+			input:    "in 2h30m",
+			expected: time.Date(2021, 11, 2, 12, 45, 0, 0, warsaw),
+		},
+		"InDays": {
+			input:    "in 3 days",
+			expected: time.Date(2021, 11, 5, 10, 15, 0, 0, warsaw),
+		},
+		"InWeekAndHours": {
+			input:    "In 1 week and 2 hours",
+			expected: time.Date(2021, 11, 9, 12, 15, 0, 0, warsaw),
+		},
+		"Tomorrow": {
+			input:    "tomorrow",
+			expected: time.Date(2021, 11, 3, 9, 0, 0, 0, warsaw),
+		},
+		"TomorrowAM": {
+			input:    "tomorrow 9am",
+			expected: time.Date(2021, 11, 3, 9, 0, 0, 0, warsaw),
+		},
+		"TodayAtPM": {
+			input:    "today at 5:30pm",
+			expected: time.Date(2021, 11, 2, 17, 30, 0, 0, warsaw),
+		},
+		"NextFriday": {
+			input:    "next friday 14:00",
+			expected: time.Date(2021, 11, 5, 14, 0, 0, 0, warsaw),
+		},
+		"NextTuesdayIsNextWeek": {
+			input:    "next tue 12:00",
+			expected: time.Date(2021, 11, 9, 12, 0, 0, 0, warsaw),
+		},
+		"WeekdayLaterToday": {
+			input:    "tuesday 12:00",
+			expected: time.Date(2021, 11, 2, 12, 0, 0, 0, warsaw),
+		},
+		"WeekdayPassedToday": {
+			input:    "tuesday 8:00",
+			expected: time.Date(2021, 11, 9, 8, 0, 0, 0, warsaw),
+		},
+		"OnlyClockPassed": {
+			input:    "8:00",
+			expected: time.Date(2021, 11, 3, 8, 0, 0, 0, warsaw),
+		},
+		"AmbiguousHour": {
+			input: "tomorrow 9",
+			fails: true,
+		},
+		"InvalidClock": {
+			input: "13pm",
+			fails: true,
+		},
+		"Gibberish": {
+			input: "someday",
+			fails: true,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			date, err := organizer.ParseDate(test.input, now, warsaw)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, test.expected.Equal(*date), "expected %v, got %v", test.expected, date)
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tt := map[string]struct {
+		input    string
+		expected time.Duration
+		fails    bool
+	}{
+		"Minutes": {
+			input:    "15m",
+			expected: 15 * time.Minute,
+		},
+		"DaysAndHours": {
+			input:    "1 day 2h",
+			expected: 26 * time.Hour,
+		},
+		"Week": {
+			input:    "1w",
+			expected: 7 * 24 * time.Hour,
+		},
+		"Empty": {
+			input: "",
+			fails: true,
+		},
+		"UnknownUnit": {
+			input: "3 months",
+			fails: true,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			duration, err := organizer.ParseDuration(test.input)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, duration)
+		})
+	}
+}
+
+func TestParseDay(t *testing.T) {
+	warsaw := RequireLocation("Europe/Warsaw")
+	now := time.Date(2021, 11, 2, 10, 15, 0, 0, warsaw)
+
+	tt := map[string]struct {
+		input    string
+		expected time.Time
+		fails    bool
+	}{
+		"Today": {
+			input:    "today",
+			expected: time.Date(2021, 11, 2, 0, 0, 0, 0, warsaw),
+		},
+		"Yesterday": {
+			input:    "Yesterday",
+			expected: time.Date(2021, 11, 1, 0, 0, 0, 0, warsaw),
+		},
+		"LegacyFormat": {
+			input:    "20.10.2021",
+			expected: time.Date(2021, 10, 20, 0, 0, 0, 0, warsaw),
+		},
+		"ISO8601": {
+			input:    "2021-10-20",
+			expected: time.Date(2021, 10, 20, 0, 0, 0, 0, warsaw),
+		},
+		"WithTime": {
+			input: "20.10.2021 15:00",
+			fails: true,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			day, err := organizer.ParseDay(test.input, now, warsaw)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, test.expected.Equal(*day), "expected %v, got %v", test.expected, day)
+		})
+	}
+}
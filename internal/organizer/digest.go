@@ -0,0 +1,234 @@
+package organizer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	skipUnchangedFlag = "skip-unchanged"
+	groupByTagFlag    = "group-by-tag"
+)
+
+var (
+	digestTimeRegexp = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?$`)
+	// listSeparatorRegexp matches commas with the spaces around them, e.g. in "9:00, 17:00"
+	listSeparatorRegexp = regexp.MustCompile(`\s*,\s*`)
+
+	allWeekdays = []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+
+	digestDayPresets = []struct {
+		name     string
+		weekdays []time.Weekday
+	}{
+		{"daily", allWeekdays},
+		{"weekdays", []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}},
+		{"weekends", []time.Weekday{time.Sunday, time.Saturday}},
+	}
+
+	// DefaultDigestSchedule is used in channels, which have no schedule set.
+	DefaultDigestSchedule = DigestSchedule{Weekdays: allWeekdays, Times: []int{9 * 60}}
+)
+
+// DigestSchedule tells, when the todo digest is sent to a channel.
+// A schedule without times is disabled.
+type DigestSchedule struct {
+	// Weekdays sorted from Sunday
+	Weekdays []time.Weekday
+	// Times in minutes after midnight, sorted
+	Times []int
+	// SkipUnchanged skips the digest, when the lists did not change since the previous one
+	SkipUnchanged bool
+	// GroupByTag groups the tasks in the digest by their tags
+	GroupByTag bool
+}
+
+// ParseDigestSchedule parses a schedule given by a user. It is "off" or the days and
+// the times separated by a space, e.g. "daily 9:00", "weekdays 9:00,17:00" or "mon 8:30".
+// The days are "daily", "weekdays", "weekends" or day names separated by commas. Spaces
+// around the commas are ignored.
+// The schedule can end with "skip-unchanged" and "group-by-tag" to set SkipUnchanged and GroupByTag.
+func ParseDigestSchedule(schedule string) (*DigestSchedule, error) {
+	fields := strings.Fields(listSeparatorRegexp.ReplaceAllString(strings.ToLower(schedule), ","))
+
+	if len(fields) == 1 && fields[0] == "off" {
+		return &DigestSchedule{}, nil
+	}
+
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("schedule must have days and times")
+	}
+
+	var skipUnchanged, groupByTag bool
+	for _, flag := range fields[2:] {
+		switch flag {
+		case skipUnchangedFlag:
+			skipUnchanged = true
+		case groupByTagFlag:
+			groupByTag = true
+		default:
+			return nil, fmt.Errorf("unknown flag %s", flag)
+		}
+	}
+
+	weekdays, err := parseDigestDays(fields[0])
+	if err != nil {
+		return nil, err
+	}
+
+	times, err := parseDigestTimes(fields[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return &DigestSchedule{Weekdays: weekdays, Times: times, SkipUnchanged: skipUnchanged, GroupByTag: groupByTag}, nil
+}
+
+func parseDigestDays(days string) ([]time.Weekday, error) {
+	for _, preset := range digestDayPresets {
+		if days == preset.name {
+			return preset.weekdays, nil
+		}
+	}
+
+	set := make(map[time.Weekday]bool)
+	for _, name := range strings.Split(days, ",") {
+		weekday, ok := weekdayNames[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown day %s", name)
+		}
+		set[weekday] = true
+	}
+
+	weekdays := make([]time.Weekday, 0, len(set))
+	for _, weekday := range allWeekdays {
+		if set[weekday] {
+			weekdays = append(weekdays, weekday)
+		}
+	}
+
+	return weekdays, nil
+}
+
+func parseDigestTimes(times string) ([]int, error) {
+	set := make(map[int]bool)
+
+	for _, clock := range strings.Split(times, ",") {
+		matches := digestTimeRegexp.FindStringSubmatch(clock)
+		if matches == nil {
+			return nil, fmt.Errorf("invalid time %s", clock)
+		}
+
+		hour, _ := strconv.Atoi(matches[1])
+		minute := 0
+		if matches[2] != "" {
+			minute, _ = strconv.Atoi(matches[2])
+		}
+
+		if hour > 23 || minute > 59 {
+			return nil, fmt.Errorf("invalid time %s", clock)
+		}
+
+		set[hour*60+minute] = true
+	}
+
+	result := make([]int, 0, len(set))
+	for minutes := range set {
+		result = append(result, minutes)
+	}
+	sort.Ints(result)
+
+	return result, nil
+}
+
+// IsDisabled returns true, if the digest is never sent.
+func (s *DigestSchedule) IsDisabled() bool {
+	return len(s.Weekdays) == 0 || len(s.Times) == 0
+}
+
+// String returns the schedule in the format accepted by ParseDigestSchedule.
+func (s *DigestSchedule) String() string {
+	if s.IsDisabled() {
+		return "off"
+	}
+
+	times := make([]string, 0, len(s.Times))
+	for _, minutes := range s.Times {
+		times = append(times, fmt.Sprintf("%d:%02d", minutes/60, minutes%60))
+	}
+
+	schedule := fmt.Sprintf("%s %s", s.daysString(), strings.Join(times, ","))
+	if s.SkipUnchanged {
+		schedule += " " + skipUnchangedFlag
+	}
+
+	if s.GroupByTag {
+		schedule += " " + groupByTagFlag
+	}
+
+	return schedule
+}
+
+func (s *DigestSchedule) daysString() string {
+	for _, preset := range digestDayPresets {
+		if weekdaysEqual(s.Weekdays, preset.weekdays) {
+			return preset.name
+		}
+	}
+
+	names := make([]string, 0, len(s.Weekdays))
+	for _, weekday := range s.Weekdays {
+		names = append(names, strings.ToLower(weekday.String()[:3]))
+	}
+
+	return strings.Join(names, ",")
+}
+
+// LatestSlot returns the latest time today, at or before now, when the digest should be sent.
+// It returns nil, if there is no such time. The day and times are evaluated in the location of now.
+func (s *DigestSchedule) LatestSlot(now time.Time) *time.Time {
+	if !s.hasWeekday(now.Weekday()) {
+		return nil
+	}
+
+	var latest *time.Time
+
+	for _, minutes := range s.Times {
+		slot := time.Date(now.Year(), now.Month(), now.Day(), minutes/60, minutes%60, 0, 0, now.Location())
+		if slot.After(now) {
+			break
+		}
+
+		latest = &slot
+	}
+
+	return latest
+}
+
+func (s *DigestSchedule) hasWeekday(weekday time.Weekday) bool {
+	for _, w := range s.Weekdays {
+		if w == weekday {
+			return true
+		}
+	}
+
+	return false
+}
+
+func weekdaysEqual(a, b []time.Weekday) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,128 @@
+package organizer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/organizer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDigestSchedule(t *testing.T) {
+	tt := map[string]struct {
+		input    string
+		expected *organizer.DigestSchedule
+		output   string
+		fails    bool
+	}{
+		"Off": {
+			input:    "off",
+			expected: &organizer.DigestSchedule{},
+			output:   "off",
+		},
+		"Daily": {
+			input:    "daily 9",
+			expected: &organizer.DigestSchedule{Weekdays: []time.Weekday{0, 1, 2, 3, 4, 5, 6}, Times: []int{540}},
+			output:   "daily 9:00",
+		},
+		"WeekdaysTwice": {
+			input:    "weekdays 17:30,9",
+			expected: &organizer.DigestSchedule{Weekdays: []time.Weekday{1, 2, 3, 4, 5}, Times: []int{540, 1050}},
+			output:   "weekdays 9:00,17:30",
+		},
+		"DayNames": {
+			input:    "Fri,monday 8:15",
+			expected: &organizer.DigestSchedule{Weekdays: []time.Weekday{time.Monday, time.Friday}, Times: []int{495}},
+			output:   "mon,fri 8:15",
+		},
+		"SpacesAfterCommas": {
+			input:    "mon, fri 9:00, 17:00",
+			expected: &organizer.DigestSchedule{Weekdays: []time.Weekday{time.Monday, time.Friday}, Times: []int{540, 1020}},
+			output:   "mon,fri 9:00,17:00",
+		},
+		"SkipUnchanged": {
+			input:    "mon 9:00 skip-unchanged",
+			expected: &organizer.DigestSchedule{Weekdays: []time.Weekday{time.Monday}, Times: []int{540}, SkipUnchanged: true},
+			output:   "mon 9:00 skip-unchanged",
+		},
+		"GroupByTag": {
+			input:    "weekdays 17:00 group-by-tag skip-unchanged",
+			expected: &organizer.DigestSchedule{Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, Times: []int{1020}, SkipUnchanged: true, GroupByTag: true},
+			output:   "weekdays 17:00 skip-unchanged group-by-tag",
+		},
+		"UnknownFlag": {
+			input: "mon 9:00 sometimes",
+			fails: true,
+		},
+		"MissingTimes": {
+			input: "daily",
+			fails: true,
+		},
+		"UnknownDay": {
+			input: "someday 9",
+			fails: true,
+		},
+		"InvalidTime": {
+			input: "daily 24:00",
+			fails: true,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			schedule, err := organizer.ParseDigestSchedule(test.input)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, schedule)
+			require.Equal(t, test.output, schedule.String())
+		})
+	}
+}
+
+func TestDigestSchedule_LatestSlot(t *testing.T) {
+	warsaw := RequireLocation("Europe/Warsaw")
+
+	schedule, err := organizer.ParseDigestSchedule("weekdays 9:00,17:00")
+	require.NoError(t, err)
+
+	tt := map[string]struct {
+		now      time.Time
+		expected *time.Time
+	}{
+		"BeforeFirst": {
+			now: time.Date(2021, 11, 2, 8, 59, 0, 0, warsaw),
+		},
+		"AfterFirst": {
+			now:      time.Date(2021, 11, 2, 12, 0, 0, 0, warsaw),
+			expected: DatePtr(time.Date(2021, 11, 2, 9, 0, 0, 0, warsaw)),
+		},
+		"AtSecond": {
+			now:      time.Date(2021, 11, 2, 17, 0, 0, 0, warsaw),
+			expected: DatePtr(time.Date(2021, 11, 2, 17, 0, 0, 0, warsaw)),
+		},
+		"Weekend": {
+			now: time.Date(2021, 11, 6, 12, 0, 0, 0, warsaw),
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expected, schedule.LatestSlot(test.now))
+		})
+	}
+
+	off := &organizer.DigestSchedule{}
+	require.Nil(t, off.LatestSlot(time.Date(2021, 11, 2, 12, 0, 0, 0, warsaw)))
+}
+
+func DatePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,23 @@
+package organizer
+
+import (
+	"context"
+	"time"
+)
+
+// ConfigStore keeps the settings of the channels. It is implemented by RedisConfigStore,
+// SQLConfigStore and MemoryConfigStore.
+type ConfigStore interface {
+	// GetCurrentTimezone returns the timezone of the channel or nil, if it is not set.
+	GetCurrentTimezone(ctx context.Context, id string) (*time.Location, error)
+	SetCurrentTimezone(ctx context.Context, id string, loc *time.Location) error
+	// GetDigestSchedule returns the todo digest schedule of the channel or nil, if it is not set.
+	GetDigestSchedule(ctx context.Context, id string) (*DigestSchedule, error)
+	SetDigestSchedule(ctx context.Context, id string, schedule *DigestSchedule) error
+}
+
+var (
+	_ ConfigStore = &RedisConfigStore{}
+	_ ConfigStore = &SQLConfigStore{}
+	_ ConfigStore = &MemoryConfigStore{}
+)
@@ -0,0 +1,41 @@
+package organizer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/organizer"
+	"github.com/Trojan295/organizer-bot/internal/storetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigStore(t *testing.T) {
+	storetest.RunConfigStores(t, func(t *testing.T, store organizer.ConfigStore) {
+		ctx := context.Background()
+
+		loc, err := store.GetCurrentTimezone(ctx, "channelID")
+		require.NoError(t, err)
+		require.Nil(t, loc)
+
+		schedule, err := store.GetDigestSchedule(ctx, "channelID")
+		require.NoError(t, err)
+		require.Nil(t, schedule)
+
+		warsaw, err := time.LoadLocation("Europe/Warsaw")
+		require.NoError(t, err)
+		require.NoError(t, store.SetCurrentTimezone(ctx, "channelID", warsaw))
+
+		expected, err := organizer.ParseDigestSchedule("weekdays 9:00 skip-unchanged")
+		require.NoError(t, err)
+		require.NoError(t, store.SetDigestSchedule(ctx, "channelID", expected))
+
+		loc, err = store.GetCurrentTimezone(ctx, "channelID")
+		require.NoError(t, err)
+		require.Equal(t, "Europe/Warsaw", loc.String())
+
+		schedule, err = store.GetDigestSchedule(ctx, "channelID")
+		require.NoError(t, err)
+		require.Equal(t, expected, schedule)
+	})
+}
@@ -0,0 +1,54 @@
+package redisutils
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// envelope wraps the values stored as JSON with the version of their schema,
+// so the readers can tell, how to decode the data.
+type envelope struct {
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// MarshalJSON returns the value as JSON in an envelope with the schema version.
+func MarshalJSON(version int, value interface{}) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "while marshaling value")
+	}
+
+	return json.Marshal(&envelope{
+		Version: version,
+		Data:    data,
+	})
+}
+
+// Unmarshal decodes the data written by MarshalJSON into the value. The data written with
+// gob before the values were stored as JSON is decoded too, then legacy is true.
+// Returns an error for an envelope with a schema version newer than maxVersion.
+func Unmarshal(data []byte, maxVersion int, value interface{}) (legacy bool, err error) {
+	env := &envelope{}
+	if err := json.Unmarshal(data, env); err == nil && env.Version > 0 && len(env.Data) > 0 {
+		if env.Version > maxVersion {
+			return false, fmt.Errorf("unsupported schema version %d", env.Version)
+		}
+
+		if err := json.Unmarshal(env.Data, value); err != nil {
+			return false, errors.Wrap(err, "while unmarshaling value")
+		}
+
+		return false, nil
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(value); err != nil {
+		return false, errors.Wrap(err, "while decoding gob value")
+	}
+
+	return true, nil
+}
@@ -0,0 +1,68 @@
+package redisutils_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/Trojan295/organizer-bot/internal/redisutils"
+	"github.com/stretchr/testify/require"
+)
+
+type value struct {
+	ID   string
+	Tags []string
+}
+
+func TestUnmarshal(t *testing.T) {
+	expected := &value{ID: "id", Tags: []string{"ops"}}
+
+	jsonData, err := redisutils.MarshalJSON(1, expected)
+	require.NoError(t, err)
+
+	gobData := &bytes.Buffer{}
+	require.NoError(t, gob.NewEncoder(gobData).Encode(expected))
+
+	newerData, err := redisutils.MarshalJSON(2, expected)
+	require.NoError(t, err)
+
+	tt := map[string]struct {
+		data   []byte
+		legacy bool
+		err    bool
+	}{
+		"JSON": {
+			data: jsonData,
+		},
+		"Gob": {
+			data:   gobData.Bytes(),
+			legacy: true,
+		},
+		"NewerVersion": {
+			data: newerData,
+			err:  true,
+		},
+		"Invalid": {
+			data: []byte("invalid"),
+			err:  true,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			decoded := &value{}
+
+			legacy, err := redisutils.Unmarshal(test.data, 1, decoded)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.legacy, legacy)
+			require.Equal(t, expected, decoded)
+		})
+	}
+}
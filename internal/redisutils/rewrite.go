@@ -0,0 +1,82 @@
+package redisutils
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// RewriteFunc returns the new value of the key or nil, if the key is kept as it is.
+type RewriteFunc func(key string, data []byte) ([]byte, error)
+
+// RewriteKeys replaces the values of the STRING keys matching the pattern with the values
+// returned by rewrite. The TTL of the keys is kept. Keys, which keep changing concurrently,
+// are skipped. It returns the number of rewritten and skipped keys.
+func RewriteKeys(ctx context.Context, cli *redis.Client, keyPattern string, rewrite RewriteFunc) (int, int, error) {
+	keys, err := ScanKeys(ctx, cli, keyPattern)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rewrittenCount, skippedCount := 0, 0
+	for _, key := range keys {
+		rewritten := false
+
+		// WATCH makes sure a value changed concurrently is not overwritten
+		err := WatchRetry(ctx, cli, func(tx *redis.Tx) error {
+			keyType, err := tx.Type(ctx, key).Result()
+			if err != nil {
+				return errors.Wrapf(err, "while TYPE key %s", key)
+			} else if keyType != "string" {
+				return nil
+			}
+
+			data, err := tx.Get(ctx, key).Bytes()
+			if err == redis.Nil {
+				return nil
+			} else if err != nil {
+				return errors.Wrapf(err, "while GET key %s", key)
+			}
+
+			ttl, err := tx.PTTL(ctx, key).Result()
+			if err != nil {
+				return errors.Wrapf(err, "while PTTL key %s", key)
+			}
+
+			value, err := rewrite(key, data)
+			if err != nil {
+				return errors.Wrapf(err, "while rewriting key %s", key)
+			} else if value == nil {
+				return nil
+			}
+
+			// PTTL is negative for keys without an expiration
+			if ttl < 0 {
+				ttl = 0
+			}
+
+			_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+				return p.Set(ctx, key, value, ttl).Err()
+			})
+			if err != nil {
+				return err
+			}
+
+			rewritten = true
+			return nil
+		}, key)
+		if err == redis.TxFailedErr {
+			skippedCount++
+			continue
+		} else if err != nil {
+			return rewrittenCount, skippedCount, err
+		}
+
+		if rewritten {
+			rewrittenCount++
+		}
+	}
+
+	return rewrittenCount, skippedCount, nil
+}
@@ -0,0 +1,48 @@
+package redisutils_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/redisutils"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteKeys(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+	require.NoError(t, cli.Set(ctx, "key:old", "old", time.Hour).Err())
+	require.NoError(t, cli.Set(ctx, "key:new", "new", 0).Err())
+	require.NoError(t, cli.Set(ctx, "key:busy", "old", 0).Err())
+	require.NoError(t, cli.SAdd(ctx, "key:set", "old").Err())
+
+	rewritten, skipped, err := redisutils.RewriteKeys(ctx, cli, "key:*", func(key string, data []byte) ([]byte, error) {
+		if string(data) != "old" {
+			return nil, nil
+		}
+
+		// the bot keeps changing the key, while it is rewritten
+		if key == "key:busy" {
+			require.NoError(t, cli.Set(ctx, key, "old", 0).Err())
+		}
+
+		return []byte("rewritten"), nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, rewritten)
+	require.Equal(t, 1, skipped)
+
+	value, err := cli.Get(ctx, "key:old").Result()
+	require.NoError(t, err)
+	require.Equal(t, "rewritten", value)
+	require.True(t, server.TTL("key:old") > 0)
+
+	value, err = cli.Get(ctx, "key:busy").Result()
+	require.NoError(t, err)
+	require.Equal(t, "old", value)
+}
@@ -0,0 +1,26 @@
+package redisutils
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// maxWatchRetries is how many times a transaction is run, when the watched keys keep changing.
+const maxWatchRetries = 10
+
+// WatchRetry runs the transaction like redis.Client.Watch and runs it again, when a watched key
+// changed before EXEC. It returns redis.TxFailedErr, if the keys changed maxWatchRetries times.
+func WatchRetry(ctx context.Context, cli *redis.Client, fn func(tx *redis.Tx) error, keys ...string) error {
+	var err error
+
+	for attempt := 0; attempt < maxWatchRetries; attempt++ {
+		err = cli.Watch(ctx, fn, keys...)
+		if errors.Cause(err) != redis.TxFailedErr {
+			return err
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,466 @@
+package reminder
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// memoryLease is the claim of a queue member by a worker.
+type memoryLease struct {
+	workerID string
+	// score is the time, for which the member was queued
+	score     int64
+	expiresAt time.Time
+}
+
+// memoryDeadLetter is a queue member, which could not be pushed.
+type memoryDeadLetter struct {
+	failedAt time.Time
+	reason   string
+}
+
+// memoryDelivered is a copy of a pushed reminder.
+type memoryDelivered struct {
+	reminder  *Reminder
+	expiresAt time.Time
+}
+
+// MemoryReminderStore keeps the reminders and the queue in memory, with the same semantics
+// as RedisReminderStore. It is used for local development and tests, so many workers
+// share it only within one process.
+type MemoryReminderStore struct {
+	mu sync.Mutex
+
+	// reminders by channel ID and reminder ID
+	reminders map[string]map[string]*Reminder
+	// queue members with the timestamp in epoch, when they are due
+	queue       map[string]int64
+	leases      map[string]*memoryLease
+	attempts    map[string]int
+	deadLetters map[string]*memoryDeadLetter
+	// pushed triggers with the time, when the mark expires
+	pushed    map[string]time.Time
+	delivered map[string]*memoryDelivered
+}
+
+func NewMemoryReminderStore() *MemoryReminderStore {
+	return &MemoryReminderStore{
+		reminders:   make(map[string]map[string]*Reminder),
+		queue:       make(map[string]int64),
+		leases:      make(map[string]*memoryLease),
+		attempts:    make(map[string]int),
+		deadLetters: make(map[string]*memoryDeadLetter),
+		pushed:      make(map[string]time.Time),
+		delivered:   make(map[string]*memoryDelivered),
+	}
+}
+
+func (store *MemoryReminderStore) AddReminder(ctx context.Context, channelID string, reminder *Reminder) (string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	reminder.ID = uuid.New().String()
+	reminder.ChannelID = channelID
+
+	if store.reminders[channelID] == nil {
+		store.reminders[channelID] = make(map[string]*Reminder)
+	}
+	store.reminders[channelID][reminder.ID] = cloneReminder(reminder)
+
+	store.enqueue(reminder, time.Now())
+
+	return reminder.ID, nil
+}
+
+// UpdateReminder overwrites the stored reminder and moves it in the queue
+// to the reminder date. Returns ErrReminderNotFound, if the reminder was removed.
+func (store *MemoryReminderStore) UpdateReminder(ctx context.Context, reminder *Reminder) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	oldReminder, ok := store.reminders[reminder.ChannelID][reminder.ID]
+	if !ok {
+		return ErrReminderNotFound
+	}
+
+	store.reminders[reminder.ChannelID][reminder.ID] = cloneReminder(reminder)
+
+	for _, member := range membersToStrings(queueMembers(oldReminder)) {
+		delete(store.queue, member)
+	}
+
+	store.enqueue(reminder, time.Now())
+
+	return nil
+}
+
+func (store *MemoryReminderStore) RemoveReminder(ctx context.Context, channelID, reminderID string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	members := []string{queueMember(channelID, reminderID, 0)}
+	if reminder, ok := store.reminders[channelID][reminderID]; ok {
+		members = membersToStrings(queueMembers(reminder))
+	}
+
+	delete(store.reminders[channelID], reminderID)
+
+	for _, member := range members {
+		delete(store.queue, member)
+		delete(store.deadLetters, member)
+		delete(store.attempts, member)
+	}
+
+	return nil
+}
+
+func (store *MemoryReminderStore) ListReminders(ctx context.Context, channelID string) ([]string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var IDs []string
+	for ID := range store.reminders[channelID] {
+		IDs = append(IDs, ID)
+	}
+
+	return IDs, nil
+}
+
+func (store *MemoryReminderStore) GetReminder(ctx context.Context, channelID, reminderID string) (*Reminder, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.getReminder(channelID, reminderID)
+}
+
+func (store *MemoryReminderStore) getReminder(channelID, reminderID string) (*Reminder, error) {
+	reminder, ok := store.reminders[channelID][reminderID]
+	if !ok {
+		return nil, ErrReminderNotFound
+	}
+
+	return cloneReminder(reminder), nil
+}
+
+func (store *MemoryReminderStore) GetReminders(ctx context.Context, channelID string) ([]*Reminder, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var reminders []*Reminder
+	for _, reminder := range store.reminders[channelID] {
+		reminders = append(reminders, cloneReminder(reminder))
+	}
+
+	return reminders, nil
+}
+
+// ClaimTriggers takes the due triggers from the queue, so no other worker gets them
+// until the lease expires. Claimed triggers have to be acknowledged with AckTrigger.
+func (store *MemoryReminderStore) ClaimTriggers(ctx context.Context, workerID string, lease time.Duration) ([]*Trigger, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+
+	var due []string
+	for member, score := range store.queue {
+		if score <= now.Unix() {
+			due = append(due, member)
+		}
+	}
+
+	// the same order as in a Redis ZSET
+	sort.Slice(due, func(i, j int) bool {
+		if store.queue[due[i]] != store.queue[due[j]] {
+			return store.queue[due[i]] < store.queue[due[j]]
+		}
+		return due[i] < due[j]
+	})
+
+	if len(due) > claimBatchSize {
+		due = due[:claimBatchSize]
+	}
+
+	var triggers []*Trigger
+	for _, member := range due {
+		score := store.queue[member]
+
+		delete(store.queue, member)
+		store.leases[member] = &memoryLease{
+			workerID:  workerID,
+			score:     score,
+			expiresAt: now.Add(lease),
+		}
+
+		channelID, reminderID, leadTime, err := parseQueueMember(member)
+		if err != nil {
+			logrus.WithField("member", member).WithError(err).Error("failed to parse queue member")
+			continue
+		}
+
+		reminder, err := store.getReminder(channelID, reminderID)
+		if err == ErrReminderNotFound {
+			// the reminder was removed, after the member was queued
+			store.ackMember(workerID, member)
+			continue
+		}
+
+		triggers = append(triggers, &Trigger{
+			Reminder:    reminder,
+			LeadTime:    leadTime,
+			ScheduledAt: time.Unix(score, 0),
+		})
+	}
+
+	return triggers, nil
+}
+
+// AckTrigger removes the claim of a handled trigger. It is a no-op, when the lease
+// has expired and the trigger was claimed by another worker.
+func (store *MemoryReminderStore) AckTrigger(ctx context.Context, workerID string, trigger *Trigger) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.ackMember(workerID, queueMember(trigger.Reminder.ChannelID, trigger.Reminder.ID, trigger.LeadTime))
+	return nil
+}
+
+func (store *MemoryReminderStore) ackMember(workerID, member string) {
+	if !store.isOwner(workerID, member) {
+		return
+	}
+
+	delete(store.leases, member)
+	delete(store.attempts, member)
+}
+
+func (store *MemoryReminderStore) isOwner(workerID, member string) bool {
+	lease, ok := store.leases[member]
+	return ok && lease.workerID == workerID
+}
+
+// IncrementAttempts counts a failed push of the trigger and returns the number of failed pushes.
+func (store *MemoryReminderStore) IncrementAttempts(ctx context.Context, trigger *Trigger) (int, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	member := queueMember(trigger.Reminder.ChannelID, trigger.Reminder.ID, trigger.LeadTime)
+	store.attempts[member]++
+
+	return store.attempts[member], nil
+}
+
+// RetryTrigger releases the claim and puts the trigger back to the queue at the given date.
+// If the reminder was rescheduled in the meantime, the new date is kept.
+func (store *MemoryReminderStore) RetryTrigger(ctx context.Context, workerID string, trigger *Trigger, date time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	member := queueMember(trigger.Reminder.ChannelID, trigger.Reminder.ID, trigger.LeadTime)
+	if !store.isOwner(workerID, member) {
+		return nil
+	}
+
+	delete(store.leases, member)
+	if _, ok := store.queue[member]; !ok {
+		store.queue[member] = date.Unix()
+	}
+
+	return nil
+}
+
+// DeadLetterTrigger releases the claim and moves the trigger to the dead letters,
+// where it stays until it is requeued with RequeueDeadLetter or the reminder is removed.
+func (store *MemoryReminderStore) DeadLetterTrigger(ctx context.Context, workerID string, trigger *Trigger, reason string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	member := queueMember(trigger.Reminder.ChannelID, trigger.Reminder.ID, trigger.LeadTime)
+
+	if store.isOwner(workerID, member) {
+		delete(store.leases, member)
+		if _, ok := store.deadLetters[member]; !ok {
+			store.deadLetters[member] = &memoryDeadLetter{failedAt: time.Now()}
+		}
+	}
+
+	if deadLetter, ok := store.deadLetters[member]; ok {
+		deadLetter.reason = reason
+	}
+
+	return nil
+}
+
+// GetDeadLetters returns the triggers of the channel, which could not be pushed.
+func (store *MemoryReminderStore) GetDeadLetters(ctx context.Context, channelID string) ([]*DeadLetter, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var deadLetters []*DeadLetter
+	for member, deadLetter := range store.deadLetters {
+		memberChannelID, reminderID, leadTime, err := parseQueueMember(member)
+		if err != nil || memberChannelID != channelID {
+			continue
+		}
+
+		reminder, err := store.getReminder(channelID, reminderID)
+		if err == ErrReminderNotFound {
+			continue
+		}
+
+		deadLetters = append(deadLetters, &DeadLetter{
+			Trigger: &Trigger{
+				Reminder: reminder,
+				LeadTime: leadTime,
+			},
+			Error:    deadLetter.reason,
+			FailedAt: deadLetter.failedAt.Truncate(time.Second),
+		})
+	}
+
+	sort.Slice(deadLetters, func(i, j int) bool {
+		return deadLetters[i].FailedAt.Before(deadLetters[j].FailedAt)
+	})
+
+	return deadLetters, nil
+}
+
+// RequeueDeadLetter moves all dead letters of the reminder back to the queue,
+// so they are pushed immediately. Returns ErrReminderNotFound, if there are none.
+func (store *MemoryReminderStore) RequeueDeadLetter(ctx context.Context, channelID, reminderID string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	reminder, err := store.getReminder(channelID, reminderID)
+	if err != nil {
+		return err
+	}
+
+	requeued := 0
+	for _, member := range membersToStrings(queueMembers(reminder)) {
+		if _, ok := store.deadLetters[member]; !ok {
+			continue
+		}
+
+		delete(store.deadLetters, member)
+		delete(store.attempts, member)
+		store.queue[member] = time.Now().Unix()
+		requeued++
+	}
+
+	if requeued == 0 {
+		return ErrReminderNotFound
+	}
+
+	return nil
+}
+
+// RecoverExpiredClaims puts back to the queue triggers claimed by workers,
+// which did not acknowledge them before the lease expired.
+func (store *MemoryReminderStore) RecoverExpiredClaims(ctx context.Context) (int, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+	count := 0
+
+	for member, lease := range store.leases {
+		if lease.expiresAt.After(now) {
+			continue
+		}
+
+		delete(store.leases, member)
+		if _, ok := store.queue[member]; !ok {
+			store.queue[member] = lease.score
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// IsTriggerPushed checks, if the trigger was already pushed by any worker.
+func (store *MemoryReminderStore) IsTriggerPushed(ctx context.Context, trigger *Trigger) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	expiresAt, ok := store.pushed[pushedTriggerKey(trigger)]
+	return ok && expiresAt.After(time.Now()), nil
+}
+
+// MarkTriggerPushed records the trigger as pushed, so it is not pushed again,
+// when it is recovered after a crash.
+func (store *MemoryReminderStore) MarkTriggerPushed(ctx context.Context, trigger *Trigger) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.pushed[pushedTriggerKey(trigger)] = time.Now().Add(pushedTriggerExpirationTime)
+	return nil
+}
+
+// SaveDeliveredReminder keeps a copy of a pushed reminder for a limited time,
+// so it can be snoozed after it was removed or rescheduled.
+func (store *MemoryReminderStore) SaveDeliveredReminder(ctx context.Context, reminder *Reminder) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.delivered[deliveredKey(reminder.ChannelID, reminder.ID)] = &memoryDelivered{
+		reminder:  cloneReminder(reminder),
+		expiresAt: time.Now().Add(deliveredReminderExpirationTime),
+	}
+
+	return nil
+}
+
+// GetDeliveredReminder returns the copy of a pushed reminder. If it has expired, it will return nil.
+func (store *MemoryReminderStore) GetDeliveredReminder(ctx context.Context, channelID, reminderID string) (*Reminder, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delivered, ok := store.delivered[deliveredKey(channelID, reminderID)]
+	if !ok || !delivered.expiresAt.After(time.Now()) {
+		return nil, nil
+	}
+
+	return cloneReminder(delivered.reminder), nil
+}
+
+func (store *MemoryReminderStore) RemoveDeliveredReminder(ctx context.Context, channelID, reminderID string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.delivered, deliveredKey(channelID, reminderID))
+	return nil
+}
+
+func (store *MemoryReminderStore) enqueue(reminder *Reminder, now time.Time) {
+	for _, item := range queueItems(reminder, now) {
+		store.queue[item.member] = item.date.Unix()
+	}
+}
+
+func deliveredKey(channelID, reminderID string) string {
+	return channelID + ":" + reminderID
+}
+
+// cloneReminder returns a deep copy of the reminder, so the stored reminders
+// are not changed by the callers.
+func cloneReminder(reminder *Reminder) *Reminder {
+	clone := *reminder
+
+	if reminder.Date != nil {
+		date := *reminder.Date
+		clone.Date = &date
+	}
+
+	clone.LeadTimes = append([]time.Duration(nil), reminder.LeadTimes...)
+	clone.Mentions.UserIDs = append([]string(nil), reminder.Mentions.UserIDs...)
+	clone.Mentions.RoleIDs = append([]string(nil), reminder.Mentions.RoleIDs...)
+
+	return &clone
+}
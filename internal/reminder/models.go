@@ -7,4 +7,38 @@ type Reminder struct {
 	ChannelID string
 	Title     string
 	Date      *time.Time
+	// Recurrence is an RRULE, see ParseRecurrence. Empty for one-time reminders.
+	Recurrence string
+	// UserID is set for personal reminders, which are delivered by a direct message.
+	UserID string
+	// Mentions are pinged, when the reminder is pushed.
+	Mentions Mentions
+	// LeadTimes are offsets before the date, when a warning is pushed.
+	LeadTimes []time.Duration
+}
+
+// Trigger is a single firing of a reminder. LeadTime is zero for the reminder
+// itself, otherwise it is a warning pushed before the reminder date.
+type Trigger struct {
+	Reminder *Reminder
+	LeadTime time.Duration
+	// ScheduledAt is the time the trigger was queued for.
+	ScheduledAt time.Time
+}
+
+// DeadLetter is a trigger, which could not be pushed.
+type DeadLetter struct {
+	Trigger  *Trigger
+	Error    string
+	FailedAt time.Time
+}
+
+type Mentions struct {
+	UserIDs []string
+	RoleIDs []string
+	Here    bool
+}
+
+func (m *Mentions) IsEmpty() bool {
+	return len(m.UserIDs) == 0 && len(m.RoleIDs) == 0 && !m.Here
 }
@@ -0,0 +1,225 @@
+package reminder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+	FrequencyYearly  Frequency = "YEARLY"
+
+	recurrenceUntilFormat = "20060102T150405Z"
+)
+
+var (
+	recurrenceAliases = map[string]string{
+		"daily":    "FREQ=DAILY",
+		"weekly":   "FREQ=WEEKLY",
+		"monthly":  "FREQ=MONTHLY",
+		"yearly":   "FREQ=YEARLY",
+		"weekdays": "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+	}
+
+	rruleWeekdays = map[string]time.Weekday{
+		"MO": time.Monday,
+		"TU": time.Tuesday,
+		"WE": time.Wednesday,
+		"TH": time.Thursday,
+		"FR": time.Friday,
+		"SA": time.Saturday,
+		"SU": time.Sunday,
+	}
+)
+
+// Recurrence is a subset of the iCalendar RRULE (RFC 5545) supporting
+// the FREQ, INTERVAL, BYDAY (weekly only) and UNTIL parts.
+type Recurrence struct {
+	Frequency Frequency
+	Interval  int
+	Weekdays  []time.Weekday
+	Until     *time.Time
+}
+
+// ParseRecurrence parses an RRULE, e.g. "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+// or one of the aliases: daily, weekly, monthly, yearly, weekdays.
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	rule = strings.TrimSpace(rule)
+	if alias, ok := recurrenceAliases[strings.ToLower(rule)]; ok {
+		rule = alias
+	}
+	rule = strings.TrimPrefix(strings.ToUpper(rule), "RRULE:")
+
+	rec := &Recurrence{
+		Interval: 1,
+	}
+
+	for _, part := range strings.Split(rule, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		key, value := kv[0], kv[1]
+
+		switch key {
+		case "FREQ":
+			switch freq := Frequency(value); freq {
+			case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+				rec.Frequency = freq
+			default:
+				return nil, fmt.Errorf("unsupported frequency %s", value)
+			}
+
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("invalid interval %s", value)
+			}
+			rec.Interval = interval
+
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := rruleWeekdays[day]
+				if !ok {
+					return nil, fmt.Errorf("invalid weekday %s", day)
+				}
+				rec.Weekdays = append(rec.Weekdays, weekday)
+			}
+
+		case "UNTIL":
+			until, err := time.Parse(recurrenceUntilFormat, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid until date %s", value)
+			}
+			rec.Until = &until
+
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", key)
+		}
+	}
+
+	if rec.Frequency == "" {
+		return nil, fmt.Errorf("FREQ is missing")
+	}
+
+	if len(rec.Weekdays) > 0 && rec.Frequency != FrequencyWeekly {
+		return nil, fmt.Errorf("BYDAY is only supported with FREQ=WEEKLY")
+	}
+
+	return rec, nil
+}
+
+// String returns the RRULE representation of the recurrence.
+func (rec *Recurrence) String() string {
+	parts := []string{fmt.Sprintf("FREQ=%s", rec.Frequency)}
+
+	if rec.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", rec.Interval))
+	}
+
+	if len(rec.Weekdays) > 0 {
+		days := make([]string, 0, len(rec.Weekdays))
+		for _, weekday := range rec.Weekdays {
+			days = append(days, strings.ToUpper(weekday.String()[:2]))
+		}
+		parts = append(parts, fmt.Sprintf("BYDAY=%s", strings.Join(days, ",")))
+	}
+
+	if rec.Until != nil {
+		parts = append(parts, fmt.Sprintf("UNTIL=%s", rec.Until.UTC().Format(recurrenceUntilFormat)))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence after the given time, following the
+// occurrence at last. The wall clock time of last is kept in loc, so a
+// reminder set for 9:00 stays at 9:00 across DST changes. Returns nil, when
+// the recurrence has ended.
+func (rec *Recurrence) Next(last, after time.Time, loc *time.Location) *time.Time {
+	next := last.In(loc)
+
+	for !next.After(after) {
+		stepped := rec.step(next)
+		if !stepped.After(next) {
+			return nil
+		}
+		next = stepped
+
+		if rec.Until != nil && next.After(*rec.Until) {
+			return nil
+		}
+	}
+
+	return &next
+}
+
+func (rec *Recurrence) step(t time.Time) time.Time {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	loc := t.Location()
+
+	switch rec.Frequency {
+	case FrequencyDaily:
+		return time.Date(year, month, day+rec.Interval, hour, min, sec, 0, loc)
+
+	case FrequencyWeekly:
+		if len(rec.Weekdays) == 0 {
+			return time.Date(year, month, day+7*rec.Interval, hour, min, sec, 0, loc)
+		}
+
+		// weeks start on Monday, so Sunday is the 7th day
+		weekdayIdx := (int(t.Weekday()) + 6) % 7
+
+		for offset := 1; weekdayIdx+offset < 7; offset++ {
+			candidate := time.Date(year, month, day+offset, hour, min, sec, 0, loc)
+			if rec.hasWeekday(candidate.Weekday()) {
+				return candidate
+			}
+		}
+
+		monday := day - weekdayIdx + 7*rec.Interval
+		for offset := 0; ; offset++ {
+			candidate := time.Date(year, month, monday+offset, hour, min, sec, 0, loc)
+			if rec.hasWeekday(candidate.Weekday()) {
+				return candidate
+			}
+		}
+
+	case FrequencyMonthly:
+		// months without the day are skipped, like in RFC 5545
+		for k := 1; ; k++ {
+			candidate := time.Date(year, month+time.Month(k*rec.Interval), day, hour, min, sec, 0, loc)
+			if candidate.Day() == day {
+				return candidate
+			}
+		}
+
+	case FrequencyYearly:
+		for k := 1; ; k++ {
+			candidate := time.Date(year+k*rec.Interval, month, day, hour, min, sec, 0, loc)
+			if candidate.Day() == day {
+				return candidate
+			}
+		}
+	}
+
+	return t
+}
+
+func (rec *Recurrence) hasWeekday(weekday time.Weekday) bool {
+	for _, wd := range rec.Weekdays {
+		if wd == weekday {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,157 @@
+package reminder_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/stretchr/testify/require"
+)
+
+func RequireLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return loc
+}
+
+func TestParseRecurrence(t *testing.T) {
+	tt := map[string]struct {
+		rule     string
+		expected string
+		fails    bool
+	}{
+		"Alias": {
+			rule:     "weekdays",
+			expected: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		},
+		"RRULEPrefix": {
+			rule:     "RRULE:FREQ=MONTHLY;INTERVAL=2",
+			expected: "FREQ=MONTHLY;INTERVAL=2",
+		},
+		"Until": {
+			rule:     "freq=daily;until=20211224T000000Z",
+			expected: "FREQ=DAILY;UNTIL=20211224T000000Z",
+		},
+		"MissingFrequency": {
+			rule:  "INTERVAL=2",
+			fails: true,
+		},
+		"UnsupportedFrequency": {
+			rule:  "FREQ=SECONDLY",
+			fails: true,
+		},
+		"ByDayNotWeekly": {
+			rule:  "FREQ=MONTHLY;BYDAY=MO",
+			fails: true,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			rec, err := reminder.ParseRecurrence(test.rule)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, rec.String())
+		})
+	}
+}
+
+func TestRecurrence_Next(t *testing.T) {
+	warsaw := RequireLocation("Europe/Warsaw")
+
+	tt := map[string]struct {
+		rule     string
+		last     time.Time
+		after    time.Time
+		location *time.Location
+		expected *time.Time
+	}{
+		"Daily": {
+			rule:     "daily",
+			last:     time.Date(2021, 11, 2, 9, 0, 0, 0, time.UTC),
+			after:    time.Date(2021, 11, 2, 9, 0, 0, 0, time.UTC),
+			location: time.UTC,
+			expected: DatePtr(time.Date(2021, 11, 3, 9, 0, 0, 0, time.UTC)),
+		},
+		"DailySkipsMissed": {
+			rule:     "daily",
+			last:     time.Date(2021, 11, 2, 9, 0, 0, 0, time.UTC),
+			after:    time.Date(2021, 11, 5, 10, 0, 0, 0, time.UTC),
+			location: time.UTC,
+			expected: DatePtr(time.Date(2021, 11, 6, 9, 0, 0, 0, time.UTC)),
+		},
+		"DailyAcrossDST": {
+			rule:     "daily",
+			last:     time.Date(2021, 10, 30, 9, 0, 0, 0, warsaw),
+			after:    time.Date(2021, 10, 30, 9, 0, 0, 0, warsaw),
+			location: warsaw,
+			expected: DatePtr(time.Date(2021, 10, 31, 9, 0, 0, 0, warsaw)),
+		},
+		"WeeklyByDaySameWeek": {
+			rule:     "FREQ=WEEKLY;BYDAY=MO,TH",
+			last:     time.Date(2021, 11, 1, 10, 0, 0, 0, time.UTC),
+			after:    time.Date(2021, 11, 1, 10, 0, 0, 0, time.UTC),
+			location: time.UTC,
+			expected: DatePtr(time.Date(2021, 11, 4, 10, 0, 0, 0, time.UTC)),
+		},
+		"WeeklyByDayIntervalNextWeek": {
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+			last:     time.Date(2021, 11, 4, 10, 0, 0, 0, time.UTC),
+			after:    time.Date(2021, 11, 4, 10, 0, 0, 0, time.UTC),
+			location: time.UTC,
+			expected: DatePtr(time.Date(2021, 11, 15, 10, 0, 0, 0, time.UTC)),
+		},
+		"WeekdaysFromFriday": {
+			rule:     "weekdays",
+			last:     time.Date(2021, 11, 5, 8, 30, 0, 0, time.UTC),
+			after:    time.Date(2021, 11, 5, 8, 30, 0, 0, time.UTC),
+			location: time.UTC,
+			expected: DatePtr(time.Date(2021, 11, 8, 8, 30, 0, 0, time.UTC)),
+		},
+		"MonthlySkipsShortMonths": {
+			rule:     "monthly",
+			last:     time.Date(2021, 1, 31, 12, 0, 0, 0, time.UTC),
+			after:    time.Date(2021, 1, 31, 12, 0, 0, 0, time.UTC),
+			location: time.UTC,
+			expected: DatePtr(time.Date(2021, 3, 31, 12, 0, 0, 0, time.UTC)),
+		},
+		"UntilEnded": {
+			rule:     "FREQ=DAILY;UNTIL=20211102T235959Z",
+			last:     time.Date(2021, 11, 2, 9, 0, 0, 0, time.UTC),
+			after:    time.Date(2021, 11, 2, 9, 0, 0, 0, time.UTC),
+			location: time.UTC,
+			expected: nil,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			rec, err := reminder.ParseRecurrence(test.rule)
+			require.NoError(t, err)
+
+			next := rec.Next(test.last, test.after, test.location)
+			if test.expected == nil {
+				require.Nil(t, next)
+				return
+			}
+
+			require.NotNil(t, next)
+			require.True(t, test.expected.Equal(*next), "expected %v, got %v", test.expected, next)
+		})
+	}
+}
+
+func DatePtr(t time.Time) *time.Time {
+	return &t
+}
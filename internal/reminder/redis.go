@@ -1,26 +1,71 @@
 package reminder
 
 import (
-	"bytes"
 	"context"
-	"encoding/gob"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Trojan295/organizer-bot/internal/redisutils"
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	deliveredReminderExpirationTime = 24 * time.Hour
+	pushedTriggerExpirationTime     = 7 * 24 * time.Hour
+
+	claimBatchSize = 100
+
+	ErrReminderNotFound = errors.New("reminder not found")
+)
+
+// reminderSchemaVersion is the version of the JSON, in which the reminders are stored.
+const reminderSchemaVersion = 1
+
 // ZSET serving as a delayed queue for reminders
 // key: "reminder:queue"
-// member: "<channelID>:<reminderID>"
+// member: "<channelID>:<reminderID>" for the reminder,
+// "<channelID>:<reminderID>:<leadTimeSeconds>" for a warning before the reminder
 // score: timestamp in epoch
 //
-// STRING for storing reminders
+// STRING for storing reminders as JSON with the schema version, see redisutils.MarshalJSON
 // key: "reminder:reminders:<channelID>:<reminderID>"
+//
+// SET with the IDs of the reminders in the channel
+// key: "reminder:index:<channelID>"
+//
+// ZSET with queue members claimed by a worker
+// key: "reminder:processing"
+// member: same as in "reminder:queue"
+// score: timestamp in epoch, when the claim lease expires
+//
+// HASH with the claim owners
+// key: "reminder:leases"
+// field: same as in "reminder:queue"
+// value: "<workerID>:<queueScore>"
+//
+// HASH with the number of failed push attempts
+// key: "reminder:attempts"
+// field: same as in "reminder:queue"
+//
+// ZSET with triggers, which could not be pushed
+// key: "reminder:deadletter"
+// member: same as in "reminder:queue"
+// score: timestamp in epoch, when the trigger was moved there
+//
+// HASH with the last push errors of the dead letters
+// key: "reminder:deadletter:errors"
+// field: same as in "reminder:queue"
+//
+// STRING marking a trigger as pushed, so it is not pushed again after a crash
+// key: "reminder:pushed:<queueMember>:<queueScore>"
+//
+// STRING for storing delivered reminders, which can be still snoozed
+// key: "reminder:delivered:<channelID>:<reminderID>"
 type RedisReminderStore struct {
 	redisClient *redis.Client
 }
@@ -39,9 +84,6 @@ func (store *RedisReminderStore) AddReminder(ctx context.Context, channelID stri
 	stringKey := fmt.Sprintf("reminder:reminders:%s:%s", channelID, reminder.ID)
 	zsetKey := "reminder:queue"
 
-	zsetMember := fmt.Sprintf("%s:%s", channelID, reminder.ID)
-	timestamp := reminder.Date.Unix()
-
 	reminderBytes, err := store.serializeReminder(reminder)
 	if err != nil {
 		return "", errors.Wrap(err, "while serializing reminder")
@@ -52,10 +94,11 @@ func (store *RedisReminderStore) AddReminder(ctx context.Context, channelID stri
 			return errors.Wrapf(err, "while SET to %s", stringKey)
 		}
 
-		if err := p.ZAdd(ctx, zsetKey, &redis.Z{
-			Member: zsetMember,
-			Score:  float64(timestamp),
-		}).Err(); err != nil {
+		if err := p.SAdd(ctx, indexKey(channelID), reminder.ID).Err(); err != nil {
+			return errors.Wrapf(err, "while SADD to %s", indexKey(channelID))
+		}
+
+		if err := p.ZAdd(ctx, zsetKey, queueEntries(reminder, time.Now())...).Err(); err != nil {
 			return errors.Wrapf(err, "while adding ZSET member to %s", zsetKey)
 		}
 
@@ -68,21 +111,97 @@ func (store *RedisReminderStore) AddReminder(ctx context.Context, channelID stri
 	return reminder.ID, nil
 }
 
+// UpdateReminder overwrites the stored reminder and moves it in the queue
+// to the reminder date. Returns ErrReminderNotFound, if the reminder was removed.
+func (store *RedisReminderStore) UpdateReminder(ctx context.Context, reminder *Reminder) error {
+	stringKey := fmt.Sprintf("reminder:reminders:%s:%s", reminder.ChannelID, reminder.ID)
+	zsetKey := "reminder:queue"
+
+	reminderBytes, err := store.serializeReminder(reminder)
+	if err != nil {
+		return errors.Wrap(err, "while serializing reminder")
+	}
+
+	// WATCH makes sure the reminder is not brought back, when it is removed concurrently
+	err = store.redisClient.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, stringKey).Bytes()
+		if err == redis.Nil {
+			return ErrReminderNotFound
+		} else if err != nil {
+			return errors.Wrapf(err, "while GET key %s", stringKey)
+		}
+
+		oldReminder, err := store.deserializeReminder(data)
+		if err != nil {
+			return errors.Wrap(err, "while deserializing reminder")
+		}
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			if err := p.Set(ctx, stringKey, reminderBytes, 0).Err(); err != nil {
+				return errors.Wrapf(err, "while SET to %s", stringKey)
+			}
+
+			// warnings, which were not pushed yet, could have a different lead time now
+			if err := p.ZRem(ctx, zsetKey, queueMembers(oldReminder)...).Err(); err != nil {
+				return errors.Wrapf(err, "while ZREM key %s", zsetKey)
+			}
+
+			if err := p.ZAdd(ctx, zsetKey, queueEntries(reminder, time.Now())...).Err(); err != nil {
+				return errors.Wrapf(err, "while adding ZSET member to %s", zsetKey)
+			}
+
+			return nil
+		})
+
+		return err
+	}, stringKey)
+	if err == ErrReminderNotFound {
+		return err
+	} else if err != nil {
+		return errors.Wrap(err, "while executing TX pipeline")
+	}
+
+	return nil
+}
+
 func (store *RedisReminderStore) RemoveReminder(ctx context.Context, channelID, reminderID string) error {
 	stringKey := fmt.Sprintf("reminder:reminders:%s:%s", channelID, reminderID)
 	zsetKey := "reminder:queue"
 
-	zsetMember := fmt.Sprintf("%s:%s", channelID, reminderID)
+	zsetMembers := []interface{}{
+		queueMember(channelID, reminderID, 0),
+	}
 
-	_, err := store.redisClient.TxPipelined(ctx, func(p redis.Pipeliner) error {
+	reminder, err := store.GetReminder(ctx, channelID, reminderID)
+	if err != nil && err != ErrReminderNotFound {
+		return errors.Wrap(err, "while getting reminder")
+	} else if reminder != nil {
+		zsetMembers = queueMembers(reminder)
+	}
+
+	_, err = store.redisClient.TxPipelined(ctx, func(p redis.Pipeliner) error {
 		if err := p.Del(ctx, stringKey).Err(); err != nil {
 			return errors.Wrapf(err, "while DEL key %s", stringKey)
 		}
 
-		if err := p.ZRem(ctx, zsetKey, zsetMember).Err(); err != nil {
+		if err := p.SRem(ctx, indexKey(channelID), reminderID).Err(); err != nil {
+			return errors.Wrapf(err, "while SREM key %s", indexKey(channelID))
+		}
+
+		if err := p.ZRem(ctx, zsetKey, zsetMembers...).Err(); err != nil {
 			return errors.Wrapf(err, "while ZREM key %s", zsetKey)
 		}
 
+		if err := p.ZRem(ctx, "reminder:deadletter", zsetMembers...).Err(); err != nil {
+			return errors.Wrap(err, "while ZREM key reminder:deadletter")
+		}
+
+		for _, hashKey := range []string{"reminder:attempts", "reminder:deadletter:errors"} {
+			if err := p.HDel(ctx, hashKey, membersToStrings(zsetMembers)...).Err(); err != nil {
+				return errors.Wrapf(err, "while HDEL key %s", hashKey)
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -93,39 +212,23 @@ func (store *RedisReminderStore) RemoveReminder(ctx context.Context, channelID,
 }
 
 func (store *RedisReminderStore) ListReminders(ctx context.Context, channelID string) ([]string, error) {
-	var (
-		allIDs        []string
-		currentCursor uint64
-	)
-
-	keyPattern := fmt.Sprintf("reminder:reminders:%s:*", channelID)
+	key := indexKey(channelID)
 
-	for {
-		keys, cursor, err := store.redisClient.Scan(ctx, currentCursor, keyPattern, 0).Result()
-		if err != nil {
-			return nil, errors.Wrapf(err, "while SCANing %s", keyPattern)
-		}
-
-		for _, key := range keys {
-			parts := strings.Split(key, ":")
-			allIDs = append(allIDs, parts[len(parts)-1])
-		}
-
-		if cursor == 0 {
-			break
-		}
-
-		currentCursor = cursor
+	IDs, err := store.redisClient.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "while SMEMBERS key %s", key)
 	}
 
-	return allIDs, nil
+	return IDs, nil
 }
 
 func (store *RedisReminderStore) GetReminder(ctx context.Context, channelID, reminderID string) (*Reminder, error) {
 	stringKey := fmt.Sprintf("reminder:reminders:%s:%s", channelID, reminderID)
 
 	data, err := store.redisClient.Get(ctx, stringKey).Bytes()
-	if err != nil {
+	if err == redis.Nil {
+		return nil, ErrReminderNotFound
+	} else if err != nil {
 		return nil, errors.Wrapf(err, "while GET key %s", stringKey)
 	}
 
@@ -137,17 +240,38 @@ func (store *RedisReminderStore) GetReminder(ctx context.Context, channelID, rem
 	return r, nil
 }
 
+// GetReminders reads the reminders of the channel with a single MGET.
 func (store *RedisReminderStore) GetReminders(ctx context.Context, channelID string) ([]*Reminder, error) {
 	IDs, err := store.ListReminders(ctx, channelID)
 	if err != nil {
 		return nil, errors.Wrap(err, "while listing reminders")
 	}
 
-	var reminders []*Reminder
+	if len(IDs) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(IDs))
 	for _, ID := range IDs {
-		reminder, err := store.GetReminder(ctx, channelID, ID)
+		keys = append(keys, fmt.Sprintf("reminder:reminders:%s:%s", channelID, ID))
+	}
+
+	values, err := store.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "while MGET reminders")
+	}
+
+	var reminders []*Reminder
+	for idx, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			// the reminder was removed after it was listed
+			continue
+		}
+
+		reminder, err := store.deserializeReminder([]byte(data))
 		if err != nil {
-			return nil, errors.Wrapf(err, "while getting reminder %s", ID)
+			return nil, errors.Wrapf(err, "while deserializing reminder %s", IDs[idx])
 		}
 
 		reminders = append(reminders, reminder)
@@ -156,58 +280,538 @@ func (store *RedisReminderStore) GetReminders(ctx context.Context, channelID str
 	return reminders, nil
 }
 
-func (store *RedisReminderStore) GetTriggeredReminders(ctx context.Context) ([]*Reminder, error) {
-	zsetKey := "reminder:queue"
-	timestampNow := time.Now().Unix()
+// Reindex adds the reminders stored before the index was kept to the index of their channel.
+// It scans the whole keyspace, so it is meant to be run once. It returns the number of reminders.
+func (store *RedisReminderStore) Reindex(ctx context.Context) (int, error) {
+	keys, err := redisutils.ScanKeys(ctx, store.redisClient, "reminder:reminders:*")
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = store.redisClient.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, key := range keys {
+			parts := strings.Split(key, ":")
+			if len(parts) != 4 {
+				continue
+			}
+
+			if err := p.SAdd(ctx, indexKey(parts[2]), parts[3]).Err(); err != nil {
+				return errors.Wrapf(err, "while SADD to %s", indexKey(parts[2]))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "while executing pipeline")
+	}
 
-	members, err := store.redisClient.ZRangeByScore(ctx, zsetKey, &redis.ZRangeBy{
-		Max: fmt.Sprintf("%d", timestampNow),
-	}).Result()
+	return len(keys), nil
+}
+
+// claimScript moves due queue members to the processing set and records
+// the worker and the original score in the leases hash.
+var claimScript = redis.NewScript(`
+local members = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'WITHSCORES', 'LIMIT', 0, ARGV[4])
+for i = 1, #members, 2 do
+	redis.call('ZREM', KEYS[1], members[i])
+	redis.call('ZADD', KEYS[2], ARGV[2], members[i])
+	redis.call('HSET', KEYS[3], members[i], ARGV[3] .. ':' .. members[i + 1])
+end
+return members
+`)
+
+// ackScript removes a claimed member and its failed attempts, if it is still owned by the worker.
+var ackScript = redis.NewScript(`
+local lease = redis.call('HGET', KEYS[2], ARGV[1])
+if lease and string.sub(lease, 1, #ARGV[2] + 1) == ARGV[2] .. ':' then
+	redis.call('ZREM', KEYS[1], ARGV[1])
+	redis.call('HDEL', KEYS[2], ARGV[1])
+	redis.call('HDEL', KEYS[3], ARGV[1])
+	return 1
+end
+return 0
+`)
+
+// moveScript moves a claimed member to another ZSET, if it is still owned by the worker.
+// Members already in the target ZSET keep their score.
+var moveScript = redis.NewScript(`
+local lease = redis.call('HGET', KEYS[2], ARGV[1])
+if lease and string.sub(lease, 1, #ARGV[2] + 1) == ARGV[2] .. ':' then
+	redis.call('ZREM', KEYS[1], ARGV[1])
+	redis.call('HDEL', KEYS[2], ARGV[1])
+	redis.call('ZADD', KEYS[3], 'NX', ARGV[3], ARGV[1])
+	return 1
+end
+return 0
+`)
+
+// recoverScript moves members with an expired lease back to the queue with
+// their original score. Members scheduled again in the meantime are kept as they are.
+var recoverScript = redis.NewScript(`
+local members = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+for _, member in ipairs(members) do
+	local score = ARGV[1]
+	local lease = redis.call('HGET', KEYS[3], member)
+	if lease then
+		score = string.match(lease, ':([^:]+)$') or score
+	end
+	redis.call('ZREM', KEYS[2], member)
+	redis.call('HDEL', KEYS[3], member)
+	redis.call('ZADD', KEYS[1], 'NX', score, member)
+end
+return #members
+`)
+
+// ClaimTriggers atomically takes the due triggers from the queue, so no other worker
+// gets them until the lease expires. Claimed triggers have to be acknowledged with AckTrigger.
+func (store *RedisReminderStore) ClaimTriggers(ctx context.Context, workerID string, lease time.Duration) ([]*Trigger, error) {
+	now := time.Now()
+	keys := []string{"reminder:queue", "reminder:processing", "reminder:leases"}
+
+	result, err := claimScript.Run(ctx, store.redisClient, keys,
+		now.Unix(), now.Add(lease).Unix(), workerID, claimBatchSize).StringSlice()
 	if err != nil {
-		return nil, errors.Wrapf(err, "while ZRANGE on key %s", zsetKey)
+		return nil, errors.Wrap(err, "while running claim script")
 	}
 
-	var reminders []*Reminder
-	for _, member := range members {
-		parts := strings.Split(member, ":")
+	var triggers []*Trigger
+	for i := 0; i+1 < len(result); i += 2 {
+		member, score := result[i], result[i+1]
 
-		if len(parts) < 2 {
-			logrus.WithField("member", member).Error("failed to parse channel and reminder ID")
+		channelID, reminderID, leadTime, err := parseQueueMember(member)
+		if err != nil {
+			logrus.WithField("member", member).WithError(err).Error("failed to parse queue member")
 			continue
 		}
 
-		channelID := parts[0]
-		reminderID := parts[1]
+		timestamp, err := strconv.ParseFloat(score, 64)
+		if err != nil {
+			logrus.WithField("member", member).WithError(err).Error("failed to parse queue score")
+			continue
+		}
 
 		reminder, err := store.GetReminder(ctx, channelID, reminderID)
+		if err == ErrReminderNotFound {
+			// the reminder was removed, after the member was claimed
+			if err := store.ackMember(ctx, workerID, member); err != nil {
+				return nil, err
+			}
+			continue
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "while getting reminder %s", reminderID)
+		}
+
+		triggers = append(triggers, &Trigger{
+			Reminder:    reminder,
+			LeadTime:    leadTime,
+			ScheduledAt: time.Unix(int64(timestamp), 0),
+		})
+	}
+
+	return triggers, nil
+}
+
+// AckTrigger removes the claim of a handled trigger. It is a no-op, when the lease
+// has expired and the trigger was claimed by another worker.
+func (store *RedisReminderStore) AckTrigger(ctx context.Context, workerID string, trigger *Trigger) error {
+	member := queueMember(trigger.Reminder.ChannelID, trigger.Reminder.ID, trigger.LeadTime)
+	return store.ackMember(ctx, workerID, member)
+}
+
+func (store *RedisReminderStore) ackMember(ctx context.Context, workerID, member string) error {
+	keys := []string{"reminder:processing", "reminder:leases", "reminder:attempts"}
+
+	if err := ackScript.Run(ctx, store.redisClient, keys, member, workerID).Err(); err != nil {
+		return errors.Wrapf(err, "while acknowledging %s", member)
+	}
+
+	return nil
+}
+
+// IncrementAttempts counts a failed push of the trigger and returns the number of failed pushes.
+func (store *RedisReminderStore) IncrementAttempts(ctx context.Context, trigger *Trigger) (int, error) {
+	hashKey := "reminder:attempts"
+	member := queueMember(trigger.Reminder.ChannelID, trigger.Reminder.ID, trigger.LeadTime)
+
+	attempts, err := store.redisClient.HIncrBy(ctx, hashKey, member, 1).Result()
+	if err != nil {
+		return 0, errors.Wrapf(err, "while HINCRBY key %s", hashKey)
+	}
+
+	return int(attempts), nil
+}
+
+// RetryTrigger releases the claim and puts the trigger back to the queue at the given date.
+// If the reminder was rescheduled in the meantime, the new date is kept.
+func (store *RedisReminderStore) RetryTrigger(ctx context.Context, workerID string, trigger *Trigger, date time.Time) error {
+	keys := []string{"reminder:processing", "reminder:leases", "reminder:queue"}
+	member := queueMember(trigger.Reminder.ChannelID, trigger.Reminder.ID, trigger.LeadTime)
+
+	if err := moveScript.Run(ctx, store.redisClient, keys, member, workerID, date.Unix()).Err(); err != nil {
+		return errors.Wrapf(err, "while moving %s to the queue", member)
+	}
+
+	return nil
+}
+
+// DeadLetterTrigger releases the claim and moves the trigger to the dead letters,
+// where it stays until it is requeued with RequeueDeadLetter or the reminder is removed.
+func (store *RedisReminderStore) DeadLetterTrigger(ctx context.Context, workerID string, trigger *Trigger, reason string) error {
+	keys := []string{"reminder:processing", "reminder:leases", "reminder:deadletter"}
+	hashKey := "reminder:deadletter:errors"
+	member := queueMember(trigger.Reminder.ChannelID, trigger.Reminder.ID, trigger.LeadTime)
+
+	if err := moveScript.Run(ctx, store.redisClient, keys, member, workerID, time.Now().Unix()).Err(); err != nil {
+		return errors.Wrapf(err, "while moving %s to the dead letters", member)
+	}
+
+	if err := store.redisClient.HSet(ctx, hashKey, member, reason).Err(); err != nil {
+		return errors.Wrapf(err, "while HSET key %s", hashKey)
+	}
+
+	return nil
+}
+
+// GetDeadLetters returns the triggers of the channel, which could not be pushed.
+func (store *RedisReminderStore) GetDeadLetters(ctx context.Context, channelID string) ([]*DeadLetter, error) {
+	zsetKey := "reminder:deadletter"
+	hashKey := "reminder:deadletter:errors"
+
+	entries, err := store.redisClient.ZRangeWithScores(ctx, zsetKey, 0, -1).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "while ZRANGE on key %s", zsetKey)
+	}
+
+	var deadLetters []*DeadLetter
+	for _, entry := range entries {
+		member, _ := entry.Member.(string)
+
+		memberChannelID, reminderID, leadTime, err := parseQueueMember(member)
 		if err != nil {
+			logrus.WithField("member", member).WithError(err).Error("failed to parse dead letter")
+			continue
+		}
+
+		if memberChannelID != channelID {
+			continue
+		}
+
+		reminder, err := store.GetReminder(ctx, channelID, reminderID)
+		if err == ErrReminderNotFound {
+			continue
+		} else if err != nil {
 			return nil, errors.Wrapf(err, "while getting reminder %s", reminderID)
 		}
 
-		reminders = append(reminders, reminder)
+		reason, err := store.redisClient.HGet(ctx, hashKey, member).Result()
+		if err != nil && err != redis.Nil {
+			return nil, errors.Wrapf(err, "while HGET key %s", hashKey)
+		}
+
+		deadLetters = append(deadLetters, &DeadLetter{
+			Trigger: &Trigger{
+				Reminder: reminder,
+				LeadTime: leadTime,
+			},
+			Error:    reason,
+			FailedAt: time.Unix(int64(entry.Score), 0),
+		})
 	}
 
-	return reminders, nil
+	return deadLetters, nil
+}
+
+// RequeueDeadLetter moves all dead letters of the reminder back to the queue,
+// so they are pushed immediately. Returns ErrReminderNotFound, if there are none.
+func (store *RedisReminderStore) RequeueDeadLetter(ctx context.Context, channelID, reminderID string) error {
+	zsetKey := "reminder:deadletter"
+
+	reminder, err := store.GetReminder(ctx, channelID, reminderID)
+	if err != nil {
+		return err
+	}
+
+	members := queueMembers(reminder)
+
+	cmds, err := store.redisClient.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, member := range members {
+			p.ZScore(ctx, zsetKey, member.(string))
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return errors.Wrapf(err, "while ZSCORE on key %s", zsetKey)
+	}
+
+	now := float64(time.Now().Unix())
+
+	var entries []*redis.Z
+	for idx, cmd := range cmds {
+		if cmd.Err() == redis.Nil {
+			continue
+		}
+
+		entries = append(entries, &redis.Z{Member: members[idx], Score: now})
+	}
+
+	if len(entries) == 0 {
+		return ErrReminderNotFound
+	}
+
+	_, err = store.redisClient.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		for _, entry := range entries {
+			if err := p.ZRem(ctx, zsetKey, entry.Member).Err(); err != nil {
+				return errors.Wrapf(err, "while ZREM key %s", zsetKey)
+			}
+
+			for _, hashKey := range []string{"reminder:attempts", "reminder:deadletter:errors"} {
+				if err := p.HDel(ctx, hashKey, entry.Member.(string)).Err(); err != nil {
+					return errors.Wrapf(err, "while HDEL key %s", hashKey)
+				}
+			}
+		}
+
+		if err := p.ZAdd(ctx, "reminder:queue", entries...).Err(); err != nil {
+			return errors.Wrap(err, "while adding ZSET member to reminder:queue")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "while executing TX pipeline")
+	}
+
+	return nil
+}
+
+// RecoverExpiredClaims puts back to the queue triggers claimed by workers,
+// which did not acknowledge them before the lease expired.
+func (store *RedisReminderStore) RecoverExpiredClaims(ctx context.Context) (int, error) {
+	keys := []string{"reminder:queue", "reminder:processing", "reminder:leases"}
+
+	count, err := recoverScript.Run(ctx, store.redisClient, keys, time.Now().Unix()).Int()
+	if err != nil {
+		return 0, errors.Wrap(err, "while running recover script")
+	}
+
+	return count, nil
+}
+
+// IsTriggerPushed checks, if the trigger was already pushed by any worker.
+func (store *RedisReminderStore) IsTriggerPushed(ctx context.Context, trigger *Trigger) (bool, error) {
+	stringKey := pushedTriggerKey(trigger)
+
+	count, err := store.redisClient.Exists(ctx, stringKey).Result()
+	if err != nil {
+		return false, errors.Wrapf(err, "while EXISTS key %s", stringKey)
+	}
+
+	return count > 0, nil
+}
+
+// MarkTriggerPushed records the trigger as pushed, so it is not pushed again,
+// when it is recovered after a crash.
+func (store *RedisReminderStore) MarkTriggerPushed(ctx context.Context, trigger *Trigger) error {
+	stringKey := pushedTriggerKey(trigger)
+
+	if err := store.redisClient.Set(ctx, stringKey, 1, pushedTriggerExpirationTime).Err(); err != nil {
+		return errors.Wrapf(err, "while SET to %s", stringKey)
+	}
+
+	return nil
+}
+
+// SaveDeliveredReminder keeps a copy of a pushed reminder for a limited time,
+// so it can be snoozed after it was removed or rescheduled.
+func (store *RedisReminderStore) SaveDeliveredReminder(ctx context.Context, reminder *Reminder) error {
+	stringKey := fmt.Sprintf("reminder:delivered:%s:%s", reminder.ChannelID, reminder.ID)
+
+	reminderBytes, err := store.serializeReminder(reminder)
+	if err != nil {
+		return errors.Wrap(err, "while serializing reminder")
+	}
+
+	if err := store.redisClient.Set(ctx, stringKey, reminderBytes, deliveredReminderExpirationTime).Err(); err != nil {
+		return errors.Wrapf(err, "while SET to %s", stringKey)
+	}
+
+	return nil
+}
+
+// GetDeliveredReminder returns the copy of a pushed reminder. If it has expired, it will return nil.
+func (store *RedisReminderStore) GetDeliveredReminder(ctx context.Context, channelID, reminderID string) (*Reminder, error) {
+	stringKey := fmt.Sprintf("reminder:delivered:%s:%s", channelID, reminderID)
+
+	data, err := store.redisClient.Get(ctx, stringKey).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "while GET key %s", stringKey)
+	}
+
+	r, err := store.deserializeReminder(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "while deserializing reminder")
+	}
+
+	return r, nil
+}
+
+func (store *RedisReminderStore) RemoveDeliveredReminder(ctx context.Context, channelID, reminderID string) error {
+	stringKey := fmt.Sprintf("reminder:delivered:%s:%s", channelID, reminderID)
+
+	if err := store.redisClient.Del(ctx, stringKey).Err(); err != nil {
+		return errors.Wrapf(err, "while DEL key %s", stringKey)
+	}
+
+	return nil
+}
+
+// indexKey returns the key of the SET with the IDs of the reminders in the channel.
+func indexKey(channelID string) string {
+	return fmt.Sprintf("reminder:index:%s", channelID)
+}
+
+func queueMember(channelID, reminderID string, leadTime time.Duration) string {
+	if leadTime == 0 {
+		return fmt.Sprintf("%s:%s", channelID, reminderID)
+	}
+
+	return fmt.Sprintf("%s:%s:%d", channelID, reminderID, int64(leadTime/time.Second))
+}
+
+func parseQueueMember(member string) (channelID, reminderID string, leadTime time.Duration, err error) {
+	parts := strings.Split(member, ":")
+
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", "", 0, fmt.Errorf("invalid queue member %s", member)
+	}
+
+	if len(parts) == 3 {
+		seconds, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return "", "", 0, errors.Wrap(err, "while parsing lead time")
+		}
+		leadTime = time.Duration(seconds) * time.Second
+	}
+
+	return parts[0], parts[1], leadTime, nil
+}
+
+func membersToStrings(members []interface{}) []string {
+	strs := make([]string, 0, len(members))
+	for _, member := range members {
+		strs = append(strs, member.(string))
+	}
+
+	return strs
+}
+
+func pushedTriggerKey(trigger *Trigger) string {
+	member := queueMember(trigger.Reminder.ChannelID, trigger.Reminder.ID, trigger.LeadTime)
+	return fmt.Sprintf("reminder:pushed:%s:%d", member, trigger.ScheduledAt.Unix())
+}
+
+// queueMembers returns all possible queue members of the reminder.
+func queueMembers(reminder *Reminder) []interface{} {
+	members := []interface{}{
+		queueMember(reminder.ChannelID, reminder.ID, 0),
+	}
+
+	for _, leadTime := range reminder.LeadTimes {
+		members = append(members, queueMember(reminder.ChannelID, reminder.ID, leadTime))
+	}
+
+	return members
+}
+
+// queueItem is a queue member with the time, when it is due.
+type queueItem struct {
+	member   string
+	leadTime time.Duration
+	date     time.Time
+}
+
+// queueItems returns the queue members for the reminder and its warnings,
+// which are not yet due.
+func queueItems(reminder *Reminder, now time.Time) []queueItem {
+	items := []queueItem{
+		{
+			member: queueMember(reminder.ChannelID, reminder.ID, 0),
+			date:   *reminder.Date,
+		},
+	}
+
+	for _, leadTime := range reminder.LeadTimes {
+		warningDate := reminder.Date.Add(-leadTime)
+		if !warningDate.After(now) {
+			continue
+		}
+
+		items = append(items, queueItem{
+			member:   queueMember(reminder.ChannelID, reminder.ID, leadTime),
+			leadTime: leadTime,
+			date:     warningDate,
+		})
+	}
+
+	return items
+}
+
+// queueEntries returns the queue entries for the reminder and its warnings,
+// which are not yet due.
+func queueEntries(reminder *Reminder, now time.Time) []*redis.Z {
+	items := queueItems(reminder, now)
+
+	entries := make([]*redis.Z, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, &redis.Z{
+			Member: item.member,
+			Score:  float64(item.date.Unix()),
+		})
+	}
+
+	return entries
 }
 
 func (store *RedisReminderStore) serializeReminder(r *Reminder) ([]byte, error) {
-	buf := bytes.Buffer{}
-	enc := gob.NewEncoder(&buf)
+	return redisutils.MarshalJSON(reminderSchemaVersion, r)
+}
 
-	if err := enc.Encode(*r); err != nil {
+func (store *RedisReminderStore) deserializeReminder(data []byte) (*Reminder, error) {
+	reminder := &Reminder{}
+	if _, err := redisutils.Unmarshal(data, reminderSchemaVersion, reminder); err != nil {
 		return nil, err
 	}
 
-	return buf.Bytes(), nil
+	return reminder, nil
 }
 
-func (store *RedisReminderStore) deserializeReminder(data []byte) (*Reminder, error) {
-	buf := bytes.NewBuffer(data)
+// MigrateToJSON rewrites the reminders stored with gob as JSON. It returns the number
+// of rewritten keys and of keys skipped, because they kept changing during the rewrite.
+func (store *RedisReminderStore) MigrateToJSON(ctx context.Context) (int, int, error) {
+	count, skippedCount := 0, 0
 
+	for _, pattern := range []string{"reminder:reminders:*", "reminder:delivered:*"} {
+		rewritten, skipped, err := redisutils.RewriteKeys(ctx, store.redisClient, pattern, store.rewriteLegacyReminder)
+		count += rewritten
+		skippedCount += skipped
+		if err != nil {
+			return count, skippedCount, err
+		}
+	}
+
+	return count, skippedCount, nil
+}
+
+func (store *RedisReminderStore) rewriteLegacyReminder(key string, data []byte) ([]byte, error) {
 	reminder := &Reminder{}
-	if err := gob.NewDecoder(buf).Decode(reminder); err != nil {
+
+	legacy, err := redisutils.Unmarshal(data, reminderSchemaVersion, reminder)
+	if err != nil || !legacy {
 		return nil, err
 	}
 
-	return reminder, nil
+	return store.serializeReminder(reminder)
 }
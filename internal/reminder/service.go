@@ -2,49 +2,225 @@ package reminder
 
 import (
 	"context"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
+var (
+	// claimLeaseTime is how long other workers wait, before they take over
+	// the triggers claimed by a worker, which has crashed.
+	claimLeaseTime = 5 * time.Minute
+
+	// failed pushes are retried with an exponential backoff, until
+	// maxPushAttempts is reached and the trigger is moved to the dead letters
+	maxPushAttempts = 8
+	retryBaseDelay  = 30 * time.Second
+	retryMaxDelay   = time.Hour
+)
+
+// PermanentError is returned by a Pusher, when retrying the push will not help,
+// e.g. the bot was removed from the channel.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+func IsPermanentError(err error) bool {
+	var permanentErr *PermanentError
+	return errors.As(err, &permanentErr)
+}
+
+// RetryDelay returns the delay before retrying a push, which failed the given number of times.
+func RetryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+
+	return delay
+}
+
 type Pusher interface {
-	PushReminder(ctx context.Context, reminder *Reminder) error
+	// PushReminder pushes the reminder or, if leadTime is not zero, a warning before it.
+	PushReminder(ctx context.Context, reminder *Reminder, leadTime time.Duration) error
 }
 
+type TimezoneStore interface {
+	GetCurrentTimezone(ctx context.Context, channelID string) (*time.Location, error)
+}
+
+// Service pushes due reminders. Many services can run against the same store,
+// as each of them claims the triggers before pushing them.
 type Service struct {
-	pusher Pusher
-	store  *RedisReminderStore
+	workerID      string
+	pusher        Pusher
+	store         Store
+	timezoneStore TimezoneStore
 }
 
-func NewService(pusher Pusher, store *RedisReminderStore) *Service {
+func NewService(pusher Pusher, store Store, timezoneStore TimezoneStore) *Service {
 	return &Service{
-		pusher: pusher,
-		store:  store,
+		workerID:      uuid.New().String(),
+		pusher:        pusher,
+		store:         store,
+		timezoneStore: timezoneStore,
 	}
 }
 
 func (svc *Service) Run(ctx context.Context) error {
-	reminders, err := svc.store.GetTriggeredReminders(ctx)
+	recovered, err := svc.store.RecoverExpiredClaims(ctx)
 	if err != nil {
-		return errors.Wrap(err, "while getting triggered reminders")
+		return errors.Wrap(err, "while recovering expired claims")
+	}
+
+	if recovered > 0 {
+		logrus.WithField("count", recovered).Warn("recovered reminders with expired claims")
+	}
+
+	triggers, err := svc.store.ClaimTriggers(ctx, svc.workerID, claimLeaseTime)
+	if err != nil {
+		return errors.Wrap(err, "while claiming triggered reminders")
 	}
 
 	var pushErr error
 
-	for _, rem := range reminders {
-		if err := svc.pusher.PushReminder(ctx, rem); err != nil {
+	for _, trigger := range triggers {
+		if err := svc.handleTrigger(ctx, trigger); err != nil {
 			pushErr = multierror.Append(pushErr, err)
-			continue
 		}
+	}
 
-		if err := svc.store.RemoveReminder(ctx, rem.ChannelID, rem.ID); err != nil {
-			pushErr = multierror.Append(pushErr, err)
-			continue
+	if pushErr != nil {
+		return errors.Wrap(pushErr, "while sending reminders")
+	}
+
+	return nil
+}
+
+// handleTrigger pushes a claimed trigger, unless it was already pushed before a crash,
+// and acknowledges it. When the push fails, the trigger is retried with a backoff,
+// other failures are retried after the claim lease expires.
+func (svc *Service) handleTrigger(ctx context.Context, trigger *Trigger) error {
+	rem := trigger.Reminder
+
+	pushed, err := svc.store.IsTriggerPushed(ctx, trigger)
+	if err != nil {
+		return errors.Wrapf(err, "while checking, if reminder %s was pushed", rem.ID)
+	}
+
+	// warnings, which are late because the reminder is already due, are dropped
+	if !pushed && (trigger.LeadTime == 0 || rem.Date.After(time.Now())) {
+		if err := svc.pusher.PushReminder(ctx, rem, trigger.LeadTime); err != nil {
+			return svc.handlePushError(ctx, trigger, err)
+		}
+
+		if err := svc.store.MarkTriggerPushed(ctx, trigger); err != nil {
+			return errors.Wrapf(err, "while marking reminder %s as pushed", rem.ID)
 		}
 	}
 
-	if pushErr != nil {
-		return errors.Wrap(err, "while sending reminders")
+	if trigger.LeadTime == 0 {
+		if err := svc.store.SaveDeliveredReminder(ctx, rem); err != nil {
+			return errors.Wrapf(err, "while saving delivered reminder %s", rem.ID)
+		}
+
+		if err := svc.completeReminder(ctx, rem); err != nil {
+			return err
+		}
+	}
+
+	if err := svc.store.AckTrigger(ctx, svc.workerID, trigger); err != nil {
+		return errors.Wrapf(err, "while acknowledging reminder %s", rem.ID)
+	}
+
+	return nil
+}
+
+// handlePushError schedules a retry of the trigger or moves it to the dead letters,
+// if the error is permanent or there were too many attempts.
+func (svc *Service) handlePushError(ctx context.Context, trigger *Trigger, pushErr error) error {
+	rem := trigger.Reminder
+
+	attempts, err := svc.store.IncrementAttempts(ctx, trigger)
+	if err != nil {
+		return multierror.Append(pushErr, errors.Wrapf(err, "while counting attempts of reminder %s", rem.ID))
+	}
+
+	if IsPermanentError(pushErr) || attempts >= maxPushAttempts {
+		if err := svc.store.DeadLetterTrigger(ctx, svc.workerID, trigger, pushErr.Error()); err != nil {
+			return multierror.Append(pushErr, errors.Wrapf(err, "while moving reminder %s to dead letters", rem.ID))
+		}
+
+		return errors.Wrapf(pushErr, "reminder %s moved to dead letters after %d attempts", rem.ID, attempts)
+	}
+
+	retryDate := time.Now().Add(RetryDelay(attempts))
+	if err := svc.store.RetryTrigger(ctx, svc.workerID, trigger, retryDate); err != nil {
+		return multierror.Append(pushErr, errors.Wrapf(err, "while scheduling retry of reminder %s", rem.ID))
+	}
+
+	return errors.Wrapf(pushErr, "while pushing reminder %s, attempt %d", rem.ID, attempts)
+}
+
+// completeReminder schedules the next occurrence of a recurring reminder
+// or removes the reminder, if there is none. The reminder is read again, so the changes
+// made by users since it was claimed are kept.
+func (svc *Service) completeReminder(ctx context.Context, claimed *Reminder) error {
+	rem, err := svc.store.GetReminder(ctx, claimed.ChannelID, claimed.ID)
+	if err == ErrReminderNotFound {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "while getting reminder %s", claimed.ID)
+	}
+
+	// the reminder was moved to another date, which is already scheduled
+	if rem.Date == nil || !rem.Date.Equal(*claimed.Date) {
+		return nil
+	}
+
+	if rem.Recurrence == "" {
+		return svc.store.RemoveReminder(ctx, rem.ChannelID, rem.ID)
+	}
+
+	recurrence, err := ParseRecurrence(rem.Recurrence)
+	if err != nil {
+		return errors.Wrapf(err, "while parsing recurrence of reminder %s", rem.ID)
+	}
+
+	location, err := svc.timezoneStore.GetCurrentTimezone(ctx, rem.ChannelID)
+	if err != nil {
+		return errors.Wrap(err, "while getting channel timezone")
+	}
+
+	if location == nil {
+		location = rem.Date.Location()
+	}
+
+	next := recurrence.Next(*rem.Date, time.Now(), location)
+	if next == nil {
+		return svc.store.RemoveReminder(ctx, rem.ChannelID, rem.ID)
+	}
+
+	rem.Date = next
+
+	// the reminder could be removed by a user in the meantime
+	if err := svc.store.UpdateReminder(ctx, rem); err != nil && err != ErrReminderNotFound {
+		return errors.Wrapf(err, "while rescheduling reminder %s", rem.ID)
 	}
 
 	return nil
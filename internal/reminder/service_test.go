@@ -0,0 +1,198 @@
+package reminder_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/organizer"
+	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tt := map[string]struct {
+		attempts int
+		expected time.Duration
+	}{
+		"FirstAttempt": {
+			attempts: 1,
+			expected: 30 * time.Second,
+		},
+		"ThirdAttempt": {
+			attempts: 3,
+			expected: 2 * time.Minute,
+		},
+		"Capped": {
+			attempts: 20,
+			expected: time.Hour,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expected, reminder.RetryDelay(test.attempts))
+		})
+	}
+}
+
+type pusherMock struct {
+	err    error
+	pushed []*reminder.Reminder
+	// onPush runs before the push, e.g. to edit the reminder in the meantime
+	onPush func()
+}
+
+func (p *pusherMock) PushReminder(ctx context.Context, rem *reminder.Reminder, leadTime time.Duration) error {
+	if p.onPush != nil {
+		p.onPush()
+	}
+
+	if p.err != nil {
+		return p.err
+	}
+
+	p.pushed = append(p.pushed, rem)
+	return nil
+}
+
+func addDueReminder(t *testing.T, store reminder.Store) *reminder.Reminder {
+	date := time.Now().Add(-time.Minute)
+	rem := &reminder.Reminder{Title: "Standup", Date: &date}
+
+	_, err := store.AddReminder(context.Background(), "channelID", rem)
+	require.NoError(t, err)
+
+	return rem
+}
+
+func TestService_RunPushesDueReminder(t *testing.T) {
+	ctx := context.Background()
+	store := reminder.NewMemoryReminderStore()
+	pusher := &pusherMock{}
+	rem := addDueReminder(t, store)
+
+	svc := reminder.NewService(pusher, store, organizer.NewMemoryConfigStore())
+
+	require.NoError(t, svc.Run(ctx))
+	require.Len(t, pusher.pushed, 1)
+	require.Equal(t, rem.ID, pusher.pushed[0].ID)
+
+	_, err := store.GetReminder(ctx, "channelID", rem.ID)
+	require.Equal(t, reminder.ErrReminderNotFound, err)
+
+	delivered, err := store.GetDeliveredReminder(ctx, "channelID", rem.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Standup", delivered.Title)
+
+	// the reminder is pushed only once
+	require.NoError(t, svc.Run(ctx))
+	require.Len(t, pusher.pushed, 1)
+}
+
+func TestService_RunRetriesFailedPush(t *testing.T) {
+	ctx := context.Background()
+	store := reminder.NewMemoryReminderStore()
+	pusher := &pusherMock{err: errors.New("discord is down")}
+	rem := addDueReminder(t, store)
+
+	svc := reminder.NewService(pusher, store, organizer.NewMemoryConfigStore())
+
+	require.Error(t, svc.Run(ctx))
+
+	// the retry is scheduled with a backoff, so it is not pushed again immediately
+	pusher.err = nil
+	require.NoError(t, svc.Run(ctx))
+	require.Empty(t, pusher.pushed)
+
+	_, err := store.GetReminder(ctx, "channelID", rem.ID)
+	require.NoError(t, err)
+
+	deadLetters, err := store.GetDeadLetters(ctx, "channelID")
+	require.NoError(t, err)
+	require.Empty(t, deadLetters)
+}
+
+func TestService_RunDeadLettersPermanentError(t *testing.T) {
+	ctx := context.Background()
+	store := reminder.NewMemoryReminderStore()
+	pusher := &pusherMock{err: &reminder.PermanentError{Err: errors.New("missing access")}}
+	rem := addDueReminder(t, store)
+
+	svc := reminder.NewService(pusher, store, organizer.NewMemoryConfigStore())
+
+	require.Error(t, svc.Run(ctx))
+
+	deadLetters, err := store.GetDeadLetters(ctx, "channelID")
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 1)
+	require.Equal(t, rem.ID, deadLetters[0].Trigger.Reminder.ID)
+	require.Equal(t, "missing access", deadLetters[0].Error)
+
+	// a requeued dead letter is pushed again
+	require.NoError(t, store.RequeueDeadLetter(ctx, "channelID", rem.ID))
+
+	pusher.err = nil
+	require.NoError(t, svc.Run(ctx))
+	require.Len(t, pusher.pushed, 1)
+}
+
+func TestService_RunKeepsEditsMadeDuringPush(t *testing.T) {
+	editedDate := time.Now().Add(3 * time.Hour).Truncate(time.Second)
+
+	tt := map[string]struct {
+		edit     func(rem *reminder.Reminder)
+		expected func(t *testing.T, rem *reminder.Reminder, due time.Time)
+	}{
+		"Title": {
+			edit: func(rem *reminder.Reminder) {
+				rem.Title = "Retro"
+			},
+			expected: func(t *testing.T, rem *reminder.Reminder, due time.Time) {
+				require.Equal(t, "Retro", rem.Title)
+				require.True(t, rem.Date.After(due), "the next occurrence is scheduled")
+			},
+		},
+		"Date": {
+			edit: func(rem *reminder.Reminder) {
+				rem.Date = &editedDate
+			},
+			expected: func(t *testing.T, rem *reminder.Reminder, due time.Time) {
+				require.True(t, editedDate.Equal(*rem.Date), "the edited date is kept")
+			},
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := reminder.NewMemoryReminderStore()
+
+			due := time.Now().Add(-time.Minute).Truncate(time.Second)
+			rem := &reminder.Reminder{Title: "Standup", Date: &due, Recurrence: "FREQ=DAILY"}
+			_, err := store.AddReminder(ctx, "channelID", rem)
+			require.NoError(t, err)
+
+			pusher := &pusherMock{onPush: func() {
+				edited, err := store.GetReminder(ctx, "channelID", rem.ID)
+				require.NoError(t, err)
+
+				test.edit(edited)
+				require.NoError(t, store.UpdateReminder(ctx, edited))
+			}}
+
+			svc := reminder.NewService(pusher, store, organizer.NewMemoryConfigStore())
+			require.NoError(t, svc.Run(ctx))
+			require.Len(t, pusher.pushed, 1)
+
+			stored, err := store.GetReminder(ctx, "channelID", rem.ID)
+			require.NoError(t, err)
+			test.expected(t, stored, due)
+		})
+	}
+}
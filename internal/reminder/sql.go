@@ -0,0 +1,646 @@
+package reminder
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/sqlutils"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const reminderColumns = "channel_id, id, title, date, recurrence, user_id, mentions, lead_times"
+
+// SQLReminderStore keeps the reminders in the "reminders" table. The triggers are identified
+// by the reminder and the lead time in seconds, like the queue members of RedisReminderStore,
+// and are kept in the tables:
+//
+// "reminder_queue" with the triggers and the time, when they are due
+//
+// "reminder_leases" with the triggers claimed by a worker
+//
+// "reminder_attempts" with the number of failed push attempts
+//
+// "reminder_dead_letters" with the triggers, which could not be pushed
+//
+// "reminder_pushed" marking the triggers as pushed, so they are not pushed again after a crash
+//
+// "reminder_delivered" with the copies of delivered reminders, which can be still snoozed
+type SQLReminderStore struct {
+	db *sqlutils.DB
+}
+
+func NewSQLReminderStore(db *sqlutils.DB) *SQLReminderStore {
+	return &SQLReminderStore{
+		db: db,
+	}
+}
+
+func (store *SQLReminderStore) AddReminder(ctx context.Context, channelID string, reminder *Reminder) (string, error) {
+	reminder.ID = uuid.New().String()
+	reminder.ChannelID = channelID
+
+	values, err := reminderValues(reminder)
+	if err != nil {
+		return "", errors.Wrap(err, "while serializing reminder")
+	}
+
+	err = store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO reminders ("+reminderColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)", values...)
+		if err != nil {
+			return errors.Wrap(err, "while inserting reminder")
+		}
+
+		return enqueueSQL(ctx, tx, reminder, time.Now())
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return reminder.ID, nil
+}
+
+// UpdateReminder overwrites the stored reminder and moves it in the queue
+// to the reminder date. Returns ErrReminderNotFound, if the reminder was removed.
+func (store *SQLReminderStore) UpdateReminder(ctx context.Context, reminder *Reminder) error {
+	values, err := reminderValues(reminder)
+	if err != nil {
+		return errors.Wrap(err, "while serializing reminder")
+	}
+
+	return store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		result, err := tx.ExecContext(ctx, `UPDATE reminders SET title = ?, date = ?, recurrence = ?, user_id = ?, mentions = ?, lead_times = ?
+			WHERE channel_id = ? AND id = ?`, append(values[2:], reminder.ChannelID, reminder.ID)...)
+		if err != nil {
+			return errors.Wrap(err, "while updating reminder")
+		}
+
+		if updated, err := result.RowsAffected(); err != nil {
+			return errors.Wrap(err, "while getting updated rows")
+		} else if updated == 0 {
+			return ErrReminderNotFound
+		}
+
+		// warnings, which were not pushed yet, could have a different lead time now
+		_, err = tx.ExecContext(ctx, "DELETE FROM reminder_queue WHERE channel_id = ? AND reminder_id = ?", reminder.ChannelID, reminder.ID)
+		if err != nil {
+			return errors.Wrap(err, "while removing reminder from queue")
+		}
+
+		return enqueueSQL(ctx, tx, reminder, time.Now())
+	})
+}
+
+func (store *SQLReminderStore) RemoveReminder(ctx context.Context, channelID, reminderID string) error {
+	return store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		for _, table := range []string{"reminder_queue", "reminder_dead_letters", "reminder_attempts"} {
+			_, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE channel_id = ? AND reminder_id = ?", channelID, reminderID)
+			if err != nil {
+				return errors.Wrapf(err, "while deleting from %s", table)
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, "DELETE FROM reminders WHERE channel_id = ? AND id = ?", channelID, reminderID)
+		if err != nil {
+			return errors.Wrap(err, "while deleting reminder")
+		}
+
+		return nil
+	})
+}
+
+func (store *SQLReminderStore) ListReminders(ctx context.Context, channelID string) ([]string, error) {
+	rows, err := store.db.QueryContext(ctx, "SELECT id FROM reminders WHERE channel_id = ?", channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while selecting reminders")
+	}
+	defer rows.Close()
+
+	var IDs []string
+	for rows.Next() {
+		var ID string
+		if err := rows.Scan(&ID); err != nil {
+			return nil, errors.Wrap(err, "while scanning reminder ID")
+		}
+		IDs = append(IDs, ID)
+	}
+
+	return IDs, rows.Err()
+}
+
+func (store *SQLReminderStore) GetReminder(ctx context.Context, channelID, reminderID string) (*Reminder, error) {
+	row := store.db.QueryRowContext(ctx, "SELECT "+reminderColumns+" FROM reminders WHERE channel_id = ? AND id = ?", channelID, reminderID)
+
+	reminder, err := scanReminder(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrReminderNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "while selecting reminder")
+	}
+
+	return reminder, nil
+}
+
+func (store *SQLReminderStore) GetReminders(ctx context.Context, channelID string) ([]*Reminder, error) {
+	rows, err := store.db.QueryContext(ctx, "SELECT "+reminderColumns+" FROM reminders WHERE channel_id = ?", channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while selecting reminders")
+	}
+	defer rows.Close()
+
+	var reminders []*Reminder
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "while scanning reminder")
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, rows.Err()
+}
+
+// ClaimTriggers takes the due triggers from the queue, so no other worker gets them
+// until the lease expires. Claimed triggers have to be acknowledged with AckTrigger.
+func (store *SQLReminderStore) ClaimTriggers(ctx context.Context, workerID string, lease time.Duration) ([]*Trigger, error) {
+	var triggers []*Trigger
+
+	err := store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		now := time.Now()
+
+		rows, err := tx.QueryContext(ctx, `SELECT channel_id, reminder_id, lead_time, due_at FROM reminder_queue
+			WHERE due_at <= ? ORDER BY due_at, channel_id, reminder_id, lead_time LIMIT ?`+store.db.ForUpdateSkipLocked(),
+			now.Unix(), claimBatchSize)
+		if err != nil {
+			return errors.Wrap(err, "while selecting due triggers")
+		}
+
+		var due []*sqlTriggerKey
+		for rows.Next() {
+			key := &sqlTriggerKey{}
+			if err := rows.Scan(&key.channelID, &key.reminderID, &key.leadTime, &key.dueAt); err != nil {
+				rows.Close()
+				return errors.Wrap(err, "while scanning trigger")
+			}
+			due = append(due, key)
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return errors.Wrap(err, "while selecting due triggers")
+		}
+
+		for _, key := range due {
+			_, err := tx.ExecContext(ctx, "DELETE FROM reminder_queue WHERE channel_id = ? AND reminder_id = ? AND lead_time = ?", key.args()...)
+			if err != nil {
+				return errors.Wrap(err, "while removing trigger from queue")
+			}
+
+			_, err = tx.ExecContext(ctx, `INSERT INTO reminder_leases (channel_id, reminder_id, lead_time, worker_id, due_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT (channel_id, reminder_id, lead_time) DO UPDATE SET worker_id = excluded.worker_id, due_at = excluded.due_at, expires_at = excluded.expires_at`,
+				append(key.args(), workerID, key.dueAt, now.Add(lease).Unix())...)
+			if err != nil {
+				return errors.Wrap(err, "while inserting lease")
+			}
+
+			row := tx.QueryRowContext(ctx, "SELECT "+reminderColumns+" FROM reminders WHERE channel_id = ? AND id = ?", key.channelID, key.reminderID)
+
+			reminder, err := scanReminder(row)
+			if err == sql.ErrNoRows {
+				// the reminder was removed, after the trigger was queued
+				if err := ackSQL(ctx, tx, workerID, key.args()); err != nil {
+					return err
+				}
+				continue
+			} else if err != nil {
+				return errors.Wrap(err, "while selecting reminder")
+			}
+
+			triggers = append(triggers, &Trigger{
+				Reminder:    reminder,
+				LeadTime:    time.Duration(key.leadTime) * time.Second,
+				ScheduledAt: time.Unix(key.dueAt, 0),
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return triggers, nil
+}
+
+// AckTrigger removes the claim of a handled trigger. It is a no-op, when the lease
+// has expired and the trigger was claimed by another worker.
+func (store *SQLReminderStore) AckTrigger(ctx context.Context, workerID string, trigger *Trigger) error {
+	return store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		return ackSQL(ctx, tx, workerID, triggerArgs(trigger))
+	})
+}
+
+// IncrementAttempts counts a failed push of the trigger and returns the number of failed pushes.
+func (store *SQLReminderStore) IncrementAttempts(ctx context.Context, trigger *Trigger) (int, error) {
+	var attempts int
+
+	err := store.db.QueryRowContext(ctx, `INSERT INTO reminder_attempts (channel_id, reminder_id, lead_time, attempts) VALUES (?, ?, ?, 1)
+		ON CONFLICT (channel_id, reminder_id, lead_time) DO UPDATE SET attempts = reminder_attempts.attempts + 1
+		RETURNING attempts`, triggerArgs(trigger)...).Scan(&attempts)
+	if err != nil {
+		return 0, errors.Wrap(err, "while incrementing attempts")
+	}
+
+	return attempts, nil
+}
+
+// RetryTrigger releases the claim and puts the trigger back to the queue at the given date.
+// If the reminder was rescheduled in the meantime, the new date is kept.
+func (store *SQLReminderStore) RetryTrigger(ctx context.Context, workerID string, trigger *Trigger, date time.Time) error {
+	return store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		released, err := releaseSQL(ctx, tx, workerID, triggerArgs(trigger))
+		if err != nil || !released {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO reminder_queue (channel_id, reminder_id, lead_time, due_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (channel_id, reminder_id, lead_time) DO NOTHING`, append(triggerArgs(trigger), date.Unix())...)
+		if err != nil {
+			return errors.Wrap(err, "while queueing trigger")
+		}
+
+		return nil
+	})
+}
+
+// DeadLetterTrigger releases the claim and moves the trigger to the dead letters,
+// where it stays until it is requeued with RequeueDeadLetter or the reminder is removed.
+func (store *SQLReminderStore) DeadLetterTrigger(ctx context.Context, workerID string, trigger *Trigger, reason string) error {
+	return store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		released, err := releaseSQL(ctx, tx, workerID, triggerArgs(trigger))
+		if err != nil {
+			return err
+		}
+
+		if released {
+			_, err = tx.ExecContext(ctx, `INSERT INTO reminder_dead_letters (channel_id, reminder_id, lead_time, failed_at, error) VALUES (?, ?, ?, ?, '')
+				ON CONFLICT (channel_id, reminder_id, lead_time) DO NOTHING`, append(triggerArgs(trigger), time.Now().Unix())...)
+			if err != nil {
+				return errors.Wrap(err, "while inserting dead letter")
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE reminder_dead_letters SET error = ? WHERE channel_id = ? AND reminder_id = ? AND lead_time = ?",
+			append([]interface{}{reason}, triggerArgs(trigger)...)...)
+		if err != nil {
+			return errors.Wrap(err, "while updating dead letter error")
+		}
+
+		return nil
+	})
+}
+
+// GetDeadLetters returns the triggers of the channel, which could not be pushed.
+func (store *SQLReminderStore) GetDeadLetters(ctx context.Context, channelID string) ([]*DeadLetter, error) {
+	rows, err := store.db.QueryContext(ctx, `SELECT r.channel_id, r.id, r.title, r.date, r.recurrence, r.user_id, r.mentions, r.lead_times,
+		d.lead_time, d.failed_at, d.error
+		FROM reminder_dead_letters d JOIN reminders r ON r.channel_id = d.channel_id AND r.id = d.reminder_id
+		WHERE d.channel_id = ? ORDER BY d.failed_at`, channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while selecting dead letters")
+	}
+	defer rows.Close()
+
+	var deadLetters []*DeadLetter
+	for rows.Next() {
+		var leadTime, failedAt int64
+		deadLetter := &DeadLetter{}
+
+		reminder, err := scanReminder(rows, &leadTime, &failedAt, &deadLetter.Error)
+		if err != nil {
+			return nil, errors.Wrap(err, "while scanning dead letter")
+		}
+
+		deadLetter.Trigger = &Trigger{
+			Reminder: reminder,
+			LeadTime: time.Duration(leadTime) * time.Second,
+		}
+		deadLetter.FailedAt = time.Unix(failedAt, 0)
+
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	return deadLetters, rows.Err()
+}
+
+// RequeueDeadLetter moves all dead letters of the reminder back to the queue,
+// so they are pushed immediately. Returns ErrReminderNotFound, if there are none.
+func (store *SQLReminderStore) RequeueDeadLetter(ctx context.Context, channelID, reminderID string) error {
+	reminder, err := store.GetReminder(ctx, channelID, reminderID)
+	if err != nil {
+		return err
+	}
+
+	return store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		requeued := 0
+
+		for _, leadTime := range append([]time.Duration{0}, reminder.LeadTimes...) {
+			args := []interface{}{channelID, reminderID, int64(leadTime / time.Second)}
+
+			result, err := tx.ExecContext(ctx, "DELETE FROM reminder_dead_letters WHERE channel_id = ? AND reminder_id = ? AND lead_time = ?", args...)
+			if err != nil {
+				return errors.Wrap(err, "while deleting dead letter")
+			}
+
+			if deleted, err := result.RowsAffected(); err != nil {
+				return errors.Wrap(err, "while getting deleted rows")
+			} else if deleted == 0 {
+				continue
+			}
+
+			_, err = tx.ExecContext(ctx, "DELETE FROM reminder_attempts WHERE channel_id = ? AND reminder_id = ? AND lead_time = ?", args...)
+			if err != nil {
+				return errors.Wrap(err, "while deleting attempts")
+			}
+
+			_, err = tx.ExecContext(ctx, `INSERT INTO reminder_queue (channel_id, reminder_id, lead_time, due_at) VALUES (?, ?, ?, ?)
+				ON CONFLICT (channel_id, reminder_id, lead_time) DO UPDATE SET due_at = excluded.due_at`, append(args, time.Now().Unix())...)
+			if err != nil {
+				return errors.Wrap(err, "while queueing trigger")
+			}
+
+			requeued++
+		}
+
+		if requeued == 0 {
+			return ErrReminderNotFound
+		}
+
+		return nil
+	})
+}
+
+// RecoverExpiredClaims puts back to the queue triggers claimed by workers,
+// which did not acknowledge them before the lease expired.
+func (store *SQLReminderStore) RecoverExpiredClaims(ctx context.Context) (int, error) {
+	count := 0
+
+	err := store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		rows, err := tx.QueryContext(ctx, "SELECT channel_id, reminder_id, lead_time, due_at FROM reminder_leases WHERE expires_at <= ?"+store.db.ForUpdateSkipLocked(),
+			time.Now().Unix())
+		if err != nil {
+			return errors.Wrap(err, "while selecting expired leases")
+		}
+
+		var expired []*sqlTriggerKey
+		for rows.Next() {
+			key := &sqlTriggerKey{}
+			if err := rows.Scan(&key.channelID, &key.reminderID, &key.leadTime, &key.dueAt); err != nil {
+				rows.Close()
+				return errors.Wrap(err, "while scanning lease")
+			}
+			expired = append(expired, key)
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return errors.Wrap(err, "while selecting expired leases")
+		}
+
+		for _, key := range expired {
+			_, err := tx.ExecContext(ctx, "DELETE FROM reminder_leases WHERE channel_id = ? AND reminder_id = ? AND lead_time = ?", key.args()...)
+			if err != nil {
+				return errors.Wrap(err, "while deleting lease")
+			}
+
+			_, err = tx.ExecContext(ctx, `INSERT INTO reminder_queue (channel_id, reminder_id, lead_time, due_at) VALUES (?, ?, ?, ?)
+				ON CONFLICT (channel_id, reminder_id, lead_time) DO NOTHING`, append(key.args(), key.dueAt)...)
+			if err != nil {
+				return errors.Wrap(err, "while queueing trigger")
+			}
+
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// IsTriggerPushed checks, if the trigger was already pushed by any worker.
+func (store *SQLReminderStore) IsTriggerPushed(ctx context.Context, trigger *Trigger) (bool, error) {
+	var count int
+
+	err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM reminder_pushed
+		WHERE channel_id = ? AND reminder_id = ? AND lead_time = ? AND scheduled_at = ? AND expires_at > ?`,
+		append(triggerArgs(trigger), trigger.ScheduledAt.Unix(), time.Now().Unix())...).Scan(&count)
+	if err != nil {
+		return false, errors.Wrap(err, "while selecting pushed trigger")
+	}
+
+	return count > 0, nil
+}
+
+// MarkTriggerPushed records the trigger as pushed, so it is not pushed again,
+// when it is recovered after a crash. The expired marks are removed on the way.
+func (store *SQLReminderStore) MarkTriggerPushed(ctx context.Context, trigger *Trigger) error {
+	now := time.Now()
+
+	return store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM reminder_pushed WHERE expires_at <= ?", now.Unix()); err != nil {
+			return errors.Wrap(err, "while deleting expired pushed triggers")
+		}
+
+		_, err := tx.ExecContext(ctx, `INSERT INTO reminder_pushed (channel_id, reminder_id, lead_time, scheduled_at, expires_at) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (channel_id, reminder_id, lead_time, scheduled_at) DO UPDATE SET expires_at = excluded.expires_at`,
+			append(triggerArgs(trigger), trigger.ScheduledAt.Unix(), now.Add(pushedTriggerExpirationTime).Unix())...)
+		if err != nil {
+			return errors.Wrap(err, "while inserting pushed trigger")
+		}
+
+		return nil
+	})
+}
+
+// SaveDeliveredReminder keeps a copy of a pushed reminder for a limited time,
+// so it can be snoozed after it was removed or rescheduled.
+func (store *SQLReminderStore) SaveDeliveredReminder(ctx context.Context, reminder *Reminder) error {
+	values, err := reminderValues(reminder)
+	if err != nil {
+		return errors.Wrap(err, "while serializing reminder")
+	}
+
+	_, err = store.db.ExecContext(ctx, `INSERT INTO reminder_delivered (channel_id, reminder_id, title, date, recurrence, user_id, mentions, lead_times, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (channel_id, reminder_id) DO UPDATE SET title = excluded.title, date = excluded.date, recurrence = excluded.recurrence,
+		user_id = excluded.user_id, mentions = excluded.mentions, lead_times = excluded.lead_times, expires_at = excluded.expires_at`,
+		append(values, time.Now().Add(deliveredReminderExpirationTime).Unix())...)
+	if err != nil {
+		return errors.Wrap(err, "while inserting delivered reminder")
+	}
+
+	return nil
+}
+
+// GetDeliveredReminder returns the copy of a pushed reminder. If it has expired, it will return nil.
+func (store *SQLReminderStore) GetDeliveredReminder(ctx context.Context, channelID, reminderID string) (*Reminder, error) {
+	row := store.db.QueryRowContext(ctx, `SELECT channel_id, reminder_id, title, date, recurrence, user_id, mentions, lead_times
+		FROM reminder_delivered WHERE channel_id = ? AND reminder_id = ? AND expires_at > ?`, channelID, reminderID, time.Now().Unix())
+
+	reminder, err := scanReminder(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "while selecting delivered reminder")
+	}
+
+	return reminder, nil
+}
+
+func (store *SQLReminderStore) RemoveDeliveredReminder(ctx context.Context, channelID, reminderID string) error {
+	_, err := store.db.ExecContext(ctx, "DELETE FROM reminder_delivered WHERE channel_id = ? AND reminder_id = ?", channelID, reminderID)
+	if err != nil {
+		return errors.Wrap(err, "while deleting delivered reminder")
+	}
+
+	return nil
+}
+
+// sqlTriggerKey identifies a trigger in the tables, like a queue member in Redis.
+type sqlTriggerKey struct {
+	channelID  string
+	reminderID string
+	// leadTime in seconds
+	leadTime int64
+	dueAt    int64
+}
+
+func (key *sqlTriggerKey) args() []interface{} {
+	return []interface{}{key.channelID, key.reminderID, key.leadTime}
+}
+
+func triggerArgs(trigger *Trigger) []interface{} {
+	return []interface{}{trigger.Reminder.ChannelID, trigger.Reminder.ID, int64(trigger.LeadTime / time.Second)}
+}
+
+func enqueueSQL(ctx context.Context, tx *sqlutils.Tx, reminder *Reminder, now time.Time) error {
+	for _, item := range queueItems(reminder, now) {
+		_, err := tx.ExecContext(ctx, `INSERT INTO reminder_queue (channel_id, reminder_id, lead_time, due_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (channel_id, reminder_id, lead_time) DO UPDATE SET due_at = excluded.due_at`,
+			reminder.ChannelID, reminder.ID, int64(item.leadTime/time.Second), item.date.Unix())
+		if err != nil {
+			return errors.Wrapf(err, "while queueing %s", item.member)
+		}
+	}
+
+	return nil
+}
+
+// releaseSQL removes the lease of the worker. It returns false, if the worker does not own the trigger.
+func releaseSQL(ctx context.Context, tx *sqlutils.Tx, workerID string, args []interface{}) (bool, error) {
+	result, err := tx.ExecContext(ctx, "DELETE FROM reminder_leases WHERE channel_id = ? AND reminder_id = ? AND lead_time = ? AND worker_id = ?",
+		append(args, workerID)...)
+	if err != nil {
+		return false, errors.Wrap(err, "while deleting lease")
+	}
+
+	released, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "while getting deleted rows")
+	}
+
+	return released > 0, nil
+}
+
+func ackSQL(ctx context.Context, tx *sqlutils.Tx, workerID string, args []interface{}) error {
+	released, err := releaseSQL(ctx, tx, workerID, args)
+	if err != nil || !released {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM reminder_attempts WHERE channel_id = ? AND reminder_id = ? AND lead_time = ?", args...)
+	if err != nil {
+		return errors.Wrap(err, "while deleting attempts")
+	}
+
+	return nil
+}
+
+// reminderValues returns the values of the reminder in the order of reminderColumns.
+func reminderValues(reminder *Reminder) ([]interface{}, error) {
+	mentions, err := json.Marshal(reminder.Mentions)
+	if err != nil {
+		return nil, errors.Wrap(err, "while marshaling mentions")
+	}
+
+	leadTimes := make([]string, 0, len(reminder.LeadTimes))
+	for _, leadTime := range reminder.LeadTimes {
+		leadTimes = append(leadTimes, strconv.FormatInt(int64(leadTime/time.Second), 10))
+	}
+
+	return []interface{}{
+		reminder.ChannelID,
+		reminder.ID,
+		reminder.Title,
+		reminder.Date.Unix(),
+		reminder.Recurrence,
+		reminder.UserID,
+		string(mentions),
+		strings.Join(leadTimes, ","),
+	}, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanReminder scans the reminderColumns and then the extra columns into dest.
+func scanReminder(row scanner, dest ...interface{}) (*Reminder, error) {
+	var (
+		reminder  = &Reminder{}
+		date      int64
+		mentions  string
+		leadTimes string
+	)
+
+	err := row.Scan(append([]interface{}{
+		&reminder.ChannelID,
+		&reminder.ID,
+		&reminder.Title,
+		&date,
+		&reminder.Recurrence,
+		&reminder.UserID,
+		&mentions,
+		&leadTimes,
+	}, dest...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	reminderDate := time.Unix(date, 0)
+	reminder.Date = &reminderDate
+
+	if err := json.Unmarshal([]byte(mentions), &reminder.Mentions); err != nil {
+		return nil, errors.Wrap(err, "while unmarshaling mentions")
+	}
+
+	if leadTimes != "" {
+		for _, seconds := range strings.Split(leadTimes, ",") {
+			value, err := strconv.ParseInt(seconds, 10, 64)
+			if err != nil {
+				return nil, errors.Wrap(err, "while parsing lead time")
+			}
+			reminder.LeadTimes = append(reminder.LeadTimes, time.Duration(value)*time.Second)
+		}
+	}
+
+	return reminder, nil
+}
@@ -0,0 +1,42 @@
+package reminder
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps the reminders and their delivery queue. It is implemented by
+// RedisReminderStore, SQLReminderStore and MemoryReminderStore.
+type Store interface {
+	AddReminder(ctx context.Context, channelID string, reminder *Reminder) (string, error)
+	// UpdateReminder returns ErrReminderNotFound, if the reminder was removed.
+	UpdateReminder(ctx context.Context, reminder *Reminder) error
+	RemoveReminder(ctx context.Context, channelID, reminderID string) error
+	ListReminders(ctx context.Context, channelID string) ([]string, error)
+	// GetReminder returns ErrReminderNotFound, if the reminder does not exist.
+	GetReminder(ctx context.Context, channelID, reminderID string) (*Reminder, error)
+	GetReminders(ctx context.Context, channelID string) ([]*Reminder, error)
+
+	// ClaimTriggers takes the due triggers, so no other worker gets them until the lease expires.
+	ClaimTriggers(ctx context.Context, workerID string, lease time.Duration) ([]*Trigger, error)
+	AckTrigger(ctx context.Context, workerID string, trigger *Trigger) error
+	IncrementAttempts(ctx context.Context, trigger *Trigger) (int, error)
+	RetryTrigger(ctx context.Context, workerID string, trigger *Trigger, date time.Time) error
+	DeadLetterTrigger(ctx context.Context, workerID string, trigger *Trigger, reason string) error
+	GetDeadLetters(ctx context.Context, channelID string) ([]*DeadLetter, error)
+	RequeueDeadLetter(ctx context.Context, channelID, reminderID string) error
+	RecoverExpiredClaims(ctx context.Context) (int, error)
+	IsTriggerPushed(ctx context.Context, trigger *Trigger) (bool, error)
+	MarkTriggerPushed(ctx context.Context, trigger *Trigger) error
+
+	SaveDeliveredReminder(ctx context.Context, reminder *Reminder) error
+	// GetDeliveredReminder returns nil, if the copy has expired.
+	GetDeliveredReminder(ctx context.Context, channelID, reminderID string) (*Reminder, error)
+	RemoveDeliveredReminder(ctx context.Context, channelID, reminderID string) error
+}
+
+var (
+	_ Store = &RedisReminderStore{}
+	_ Store = &SQLReminderStore{}
+	_ Store = &MemoryReminderStore{}
+)
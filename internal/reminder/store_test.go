@@ -0,0 +1,86 @@
+package reminder_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/reminder"
+	"github.com/Trojan295/organizer-bot/internal/storetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_RoundTrip(t *testing.T) {
+	storetest.RunReminderStores(t, func(t *testing.T, store reminder.Store) {
+		ctx := context.Background()
+		date := time.Now().Add(time.Hour).Truncate(time.Second)
+
+		ID, err := store.AddReminder(ctx, "channelID", &reminder.Reminder{
+			Title:      "Standup",
+			Date:       &date,
+			Recurrence: "FREQ=DAILY",
+			Mentions: reminder.Mentions{
+				UserIDs: []string{"user"},
+				Here:    true,
+			},
+			LeadTimes: []time.Duration{15 * time.Minute, 30 * time.Minute},
+		})
+		require.NoError(t, err)
+
+		rem, err := store.GetReminder(ctx, "channelID", ID)
+		require.NoError(t, err)
+		require.True(t, date.Equal(*rem.Date))
+		require.Equal(t, "FREQ=DAILY", rem.Recurrence)
+		require.Equal(t, []string{"user"}, rem.Mentions.UserIDs)
+		require.True(t, rem.Mentions.Here)
+		require.Equal(t, []time.Duration{15 * time.Minute, 30 * time.Minute}, rem.LeadTimes)
+
+		IDs, err := store.ListReminders(ctx, "channelID")
+		require.NoError(t, err)
+		require.Equal(t, []string{ID}, IDs)
+
+		require.NoError(t, store.RemoveReminder(ctx, "channelID", ID))
+
+		_, err = store.GetReminder(ctx, "channelID", ID)
+		require.Equal(t, reminder.ErrReminderNotFound, err)
+	})
+}
+
+// The SQL stores read the dates in the local timezone, so they are shown
+// in the channel timezone with In.
+func TestStore_DateInChannelLocation(t *testing.T) {
+	storetest.RunReminderStores(t, func(t *testing.T, store reminder.Store) {
+		ctx := context.Background()
+		location := time.FixedZone("UTC+05:30", 5*60*60+30*60)
+		date := time.Date(2030, time.March, 1, 9, 15, 0, 0, location)
+
+		ID, err := store.AddReminder(ctx, "channelID", &reminder.Reminder{Title: "Standup", Date: &date})
+		require.NoError(t, err)
+
+		rem, err := store.GetReminder(ctx, "channelID", ID)
+		require.NoError(t, err)
+		require.True(t, date.Equal(*rem.Date))
+		require.Equal(t, "01.03.2030 09:15", rem.Date.In(location).Format("02.01.2006 15:04"))
+	})
+}
+
+func TestStore_ClaimTriggers(t *testing.T) {
+	storetest.RunReminderStores(t, func(t *testing.T, store reminder.Store) {
+		ctx := context.Background()
+		rem := addDueReminder(t, store)
+
+		triggers, err := store.ClaimTriggers(ctx, "worker", time.Minute)
+		require.NoError(t, err)
+		require.Len(t, triggers, 1)
+		require.Equal(t, rem.ID, triggers[0].Reminder.ID)
+
+		// the claimed trigger is not taken by another worker
+		triggers, err = store.ClaimTriggers(ctx, "other", time.Minute)
+		require.NoError(t, err)
+		require.Empty(t, triggers)
+
+		count, err := store.RecoverExpiredClaims(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, count)
+	})
+}
@@ -0,0 +1,179 @@
+package sqlutils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	// the drivers of the supported dialects
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+)
+
+var driverNames = map[Dialect]string{
+	DialectSQLite:   "sqlite3",
+	DialectPostgres: "postgres",
+}
+
+// Querier is implemented by DB and Tx, so the queries can run in a transaction or without one.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+var (
+	_ Querier = &DB{}
+	_ Querier = &Tx{}
+)
+
+// DB is a database connection, which rewrites the ? placeholders in the queries
+// to the syntax of the dialect, so the stores can share the queries.
+type DB struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// Open connects to the database and applies the schema migrations.
+func Open(ctx context.Context, dialect Dialect, dsn string) (*DB, error) {
+	driverName, ok := driverNames[dialect]
+	if !ok {
+		return nil, fmt.Errorf("unknown SQL dialect %s", dialect)
+	}
+
+	sqlDB, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "while opening database")
+	}
+
+	if dialect == DialectSQLite {
+		// SQLite allows a single writer, so the queries wait for each other instead
+		// of failing with "database is locked". It keeps also ":memory:" databases,
+		// which exist only for one connection.
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	db := &DB{
+		db:      sqlDB,
+		dialect: dialect,
+	}
+
+	if err := db.migrate(ctx); err != nil {
+		sqlDB.Close()
+		return nil, errors.Wrap(err, "while migrating schema")
+	}
+
+	return db, nil
+}
+
+func (db *DB) Close() error {
+	return db.db.Close()
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.db.ExecContext(ctx, db.rebind(query), args...)
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.db.QueryContext(ctx, db.rebind(query), args...)
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.db.QueryRowContext(ctx, db.rebind(query), args...)
+}
+
+// ForUpdate returns the clause locking the selected rows until the end of the transaction.
+// SQLite has no row locks, but it runs a single transaction at a time.
+func (db *DB) ForUpdate() string {
+	if db.dialect == DialectPostgres {
+		return " FOR UPDATE"
+	}
+
+	return ""
+}
+
+// ForUpdateSkipLocked is like ForUpdate, but the rows locked by other transactions are skipped,
+// so many workers can take rows from the same table.
+func (db *DB) ForUpdateSkipLocked() string {
+	if db.dialect == DialectPostgres {
+		return " FOR UPDATE SKIP LOCKED"
+	}
+
+	return ""
+}
+
+// InTx runs the function in a transaction, which is committed, if the function returns no error.
+func (db *DB) InTx(ctx context.Context, f func(tx *Tx) error) error {
+	sqlTx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "while beginning transaction")
+	}
+
+	if err := f(&Tx{tx: sqlTx, db: db}); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return errors.Wrap(err, "while committing transaction")
+	}
+
+	return nil
+}
+
+// Tx is a transaction, which rewrites the placeholders like DB.
+type Tx struct {
+	tx *sql.Tx
+	db *DB
+}
+
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tx.tx.ExecContext(ctx, tx.db.rebind(query), args...)
+}
+
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.tx.QueryContext(ctx, tx.db.rebind(query), args...)
+}
+
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return tx.tx.QueryRowContext(ctx, tx.db.rebind(query), args...)
+}
+
+// rebind replaces the ? placeholders with $1, $2, ... for PostgreSQL.
+func (db *DB) rebind(query string) string {
+	if db.dialect != DialectPostgres {
+		return query
+	}
+
+	var (
+		b strings.Builder
+		n int
+	)
+
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+
+		n++
+		b.WriteString("$" + strconv.Itoa(n))
+	}
+
+	return b.String()
+}
+
+// Placeholders returns n placeholders separated by commas, e.g. for an IN clause.
+func Placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
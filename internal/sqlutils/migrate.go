@@ -0,0 +1,105 @@
+package sqlutils
+
+import (
+	"context"
+	"embed"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// The migrations are named "<version>_<description>.sql" and applied in the order of the versions.
+// They are written in the SQL understood by all dialects and must not be changed after a release.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+func loadMigrations() ([]*migration, error) {
+	files, err := migrations.ReadDir("migrations")
+	if err != nil {
+		return nil, errors.Wrap(err, "while reading migrations")
+	}
+
+	loaded := make([]*migration, 0, len(files))
+	for _, file := range files {
+		version, err := strconv.Atoi(strings.SplitN(file.Name(), "_", 2)[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "while parsing version of migration %s", file.Name())
+		}
+
+		query, err := migrations.ReadFile(path.Join("migrations", file.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "while reading migration %s", file.Name())
+		}
+
+		loaded = append(loaded, &migration{
+			version: version,
+			name:    file.Name(),
+			query:   string(query),
+		})
+	}
+
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].version < loaded[j].version
+	})
+
+	return loaded, nil
+}
+
+// migrate applies the migrations, which were not applied yet. Each migration runs in a transaction.
+func (db *DB) migrate(ctx context.Context) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at BIGINT NOT NULL
+	)`)
+	if err != nil {
+		return errors.Wrap(err, "while creating schema_migrations table")
+	}
+
+	loaded, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range loaded {
+		err := db.InTx(ctx, func(tx *Tx) error {
+			var count int
+			err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations WHERE version = ?", m.version).Scan(&count)
+			if err != nil {
+				return errors.Wrap(err, "while checking migration")
+			}
+
+			if count > 0 {
+				return nil
+			}
+
+			if _, err := tx.ExecContext(ctx, m.query); err != nil {
+				return errors.Wrap(err, "while executing migration")
+			}
+
+			_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)", m.version, time.Now().Unix())
+			if err != nil {
+				return errors.Wrap(err, "while recording migration")
+			}
+
+			logrus.WithField("migration", m.name).Info("applied schema migration")
+			return nil
+		})
+		if err != nil {
+			return errors.Wrapf(err, "while applying migration %s", m.name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+-- The times are stored as timestamps in epoch seconds.
+
+CREATE TABLE channel_config (
+    channel_id TEXT PRIMARY KEY,
+    timezone TEXT,
+    digest_schedule TEXT
+);
+
+CREATE TABLE reminders (
+    channel_id TEXT NOT NULL,
+    id TEXT NOT NULL,
+    title TEXT NOT NULL,
+    date BIGINT NOT NULL,
+    recurrence TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    -- JSON with the users, roles and @here
+    mentions TEXT NOT NULL,
+    -- lead times in seconds separated by commas
+    lead_times TEXT NOT NULL,
+    PRIMARY KEY (channel_id, id)
+);
+
+-- The triggers of the reminders are identified by the reminder and the lead time in seconds,
+-- which is 0 for the reminder itself.
+
+CREATE TABLE reminder_queue (
+    channel_id TEXT NOT NULL,
+    reminder_id TEXT NOT NULL,
+    lead_time BIGINT NOT NULL,
+    due_at BIGINT NOT NULL,
+    PRIMARY KEY (channel_id, reminder_id, lead_time)
+);
+
+CREATE INDEX reminder_queue_due_at ON reminder_queue (due_at);
+
+CREATE TABLE reminder_leases (
+    channel_id TEXT NOT NULL,
+    reminder_id TEXT NOT NULL,
+    lead_time BIGINT NOT NULL,
+    worker_id TEXT NOT NULL,
+    -- the time, for which the trigger was queued
+    due_at BIGINT NOT NULL,
+    expires_at BIGINT NOT NULL,
+    PRIMARY KEY (channel_id, reminder_id, lead_time)
+);
+
+CREATE TABLE reminder_attempts (
+    channel_id TEXT NOT NULL,
+    reminder_id TEXT NOT NULL,
+    lead_time BIGINT NOT NULL,
+    attempts INTEGER NOT NULL,
+    PRIMARY KEY (channel_id, reminder_id, lead_time)
+);
+
+CREATE TABLE reminder_dead_letters (
+    channel_id TEXT NOT NULL,
+    reminder_id TEXT NOT NULL,
+    lead_time BIGINT NOT NULL,
+    failed_at BIGINT NOT NULL,
+    error TEXT NOT NULL,
+    PRIMARY KEY (channel_id, reminder_id, lead_time)
+);
+
+CREATE TABLE reminder_pushed (
+    channel_id TEXT NOT NULL,
+    reminder_id TEXT NOT NULL,
+    lead_time BIGINT NOT NULL,
+    scheduled_at BIGINT NOT NULL,
+    expires_at BIGINT NOT NULL,
+    PRIMARY KEY (channel_id, reminder_id, lead_time, scheduled_at)
+);
+
+-- copies of the delivered reminders, which can be still snoozed
+CREATE TABLE reminder_delivered (
+    channel_id TEXT NOT NULL,
+    reminder_id TEXT NOT NULL,
+    title TEXT NOT NULL,
+    date BIGINT NOT NULL,
+    recurrence TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    mentions TEXT NOT NULL,
+    lead_times TEXT NOT NULL,
+    expires_at BIGINT NOT NULL,
+    PRIMARY KEY (channel_id, reminder_id)
+);
+
+CREATE TABLE todo_channels (
+    channel_id TEXT PRIMARY KEY,
+    notification_timestamp BIGINT NOT NULL DEFAULT 0,
+    notification_hash TEXT NOT NULL DEFAULT '',
+    board_message_id TEXT NOT NULL DEFAULT '',
+    -- see ExpiryPolicy.String, empty for the default policy
+    expiry_policy TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE todo_lists (
+    channel_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (channel_id, name)
+);
+
+CREATE TABLE todo_entries (
+    channel_id TEXT NOT NULL,
+    list_name TEXT NOT NULL,
+    id TEXT NOT NULL,
+    position BIGINT NOT NULL,
+    text TEXT NOT NULL,
+    due_date BIGINT,
+    priority INTEGER NOT NULL,
+    assignee_id TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    -- JSON with the checklist
+    subtasks TEXT NOT NULL,
+    auto_complete BOOLEAN NOT NULL,
+    -- JSON array with the tags
+    tags TEXT NOT NULL,
+    -- NULL, if the entry does not expire
+    expires_at BIGINT,
+    PRIMARY KEY (channel_id, list_name, id)
+);
+
+CREATE TABLE todo_entry_tags (
+    channel_id TEXT NOT NULL,
+    list_name TEXT NOT NULL,
+    entry_id TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (channel_id, list_name, tag, entry_id)
+);
+
+CREATE TABLE todo_archive (
+    channel_id TEXT NOT NULL,
+    list_name TEXT NOT NULL,
+    id TEXT NOT NULL,
+    text TEXT NOT NULL,
+    due_date BIGINT,
+    priority INTEGER NOT NULL,
+    assignee_id TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    subtasks TEXT NOT NULL,
+    auto_complete BOOLEAN NOT NULL,
+    tags TEXT NOT NULL,
+    completed_at BIGINT NOT NULL,
+    completed_by TEXT NOT NULL,
+    expires_at BIGINT,
+    PRIMARY KEY (channel_id, id)
+);
+
+CREATE INDEX todo_archive_completed_at ON todo_archive (channel_id, completed_at);
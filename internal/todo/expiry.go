@@ -0,0 +1,110 @@
+package todo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ExpiryMode string
+
+const (
+	// ExpiryNever keeps the entries and the archive forever.
+	ExpiryNever ExpiryMode = "never"
+	// ExpiryInactivity removes entries, which were not changed for the number of days.
+	ExpiryInactivity ExpiryMode = "inactivity"
+	// ExpiryCompletion removes completed entries from the archive after the number of days.
+	ExpiryCompletion ExpiryMode = "completion"
+
+	maxExpiryDays = 365
+)
+
+// DefaultExpiryPolicy is used in channels, which have no policy set.
+var DefaultExpiryPolicy = ExpiryPolicy{Mode: ExpiryInactivity, Days: 30}
+
+// ExpiryPolicy decides, when entries of a channel are removed.
+type ExpiryPolicy struct {
+	Mode ExpiryMode
+	Days int
+}
+
+// ParseExpiryPolicy parses a policy in the format returned by String,
+// e.g. "never", "inactivity:30" or "completion:7".
+func ParseExpiryPolicy(policy string) (ExpiryPolicy, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(policy)), ":")
+
+	mode := ExpiryMode(parts[0])
+
+	switch mode {
+	case ExpiryNever:
+		if len(parts) != 1 {
+			return ExpiryPolicy{}, fmt.Errorf("policy %s does not take days", mode)
+		}
+		return ExpiryPolicy{Mode: mode}, nil
+
+	case ExpiryInactivity, ExpiryCompletion:
+		if len(parts) != 2 {
+			return ExpiryPolicy{}, fmt.Errorf("policy %s requires days", mode)
+		}
+
+		days, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return ExpiryPolicy{}, fmt.Errorf("invalid days %s", parts[1])
+		}
+
+		return NewExpiryPolicy(mode, days)
+	}
+
+	return ExpiryPolicy{}, fmt.Errorf("unknown expiry mode %s", parts[0])
+}
+
+// NewExpiryPolicy validates the mode and the number of days.
+func NewExpiryPolicy(mode ExpiryMode, days int) (ExpiryPolicy, error) {
+	switch mode {
+	case ExpiryNever:
+		return ExpiryPolicy{Mode: mode}, nil
+
+	case ExpiryInactivity, ExpiryCompletion:
+		if days < 1 || days > maxExpiryDays {
+			return ExpiryPolicy{}, fmt.Errorf("days must be between 1 and %d", maxExpiryDays)
+		}
+		return ExpiryPolicy{Mode: mode, Days: days}, nil
+	}
+
+	return ExpiryPolicy{}, fmt.Errorf("unknown expiry mode %s", mode)
+}
+
+func (p ExpiryPolicy) String() string {
+	if p.Mode == ExpiryNever {
+		return string(p.Mode)
+	}
+
+	return fmt.Sprintf("%s:%d", p.Mode, p.Days)
+}
+
+// EntryTTL returns, how long an entry is kept after it was changed. Zero means forever.
+func (p ExpiryPolicy) EntryTTL() time.Duration {
+	if p.Mode != ExpiryInactivity {
+		return 0
+	}
+
+	return time.Duration(p.Days) * 24 * time.Hour
+}
+
+// ArchiveExpiration returns, when an entry completed at the time is removed from the archive.
+// It returns nil, if the entry is kept forever.
+func (p ExpiryPolicy) ArchiveExpiration(completedAt time.Time) *time.Time {
+	var expiration time.Time
+
+	switch p.Mode {
+	case ExpiryNever:
+		return nil
+	case ExpiryCompletion:
+		expiration = completedAt.AddDate(0, 0, p.Days)
+	default:
+		expiration = completedAt.Add(archiveExpirationTime)
+	}
+
+	return &expiration
+}
@@ -0,0 +1,77 @@
+package todo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpiryPolicy(t *testing.T) {
+	tt := map[string]struct {
+		input    string
+		expected todo.ExpiryPolicy
+		fails    bool
+	}{
+		"Never": {
+			input:    "never",
+			expected: todo.ExpiryPolicy{Mode: todo.ExpiryNever},
+		},
+		"Inactivity": {
+			input:    "inactivity:30",
+			expected: todo.ExpiryPolicy{Mode: todo.ExpiryInactivity, Days: 30},
+		},
+		"Completion": {
+			input:    "completion:7",
+			expected: todo.ExpiryPolicy{Mode: todo.ExpiryCompletion, Days: 7},
+		},
+		"MissingDays": {
+			input: "inactivity",
+			fails: true,
+		},
+		"NeverWithDays": {
+			input: "never:3",
+			fails: true,
+		},
+		"TooManyDays": {
+			input: "completion:1000",
+			fails: true,
+		},
+		"UnknownMode": {
+			input: "sometimes:3",
+			fails: true,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			policy, err := todo.ParseExpiryPolicy(test.input)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, policy)
+			require.Equal(t, test.input, policy.String())
+		})
+	}
+}
+
+func TestExpiryPolicy_Expiration(t *testing.T) {
+	completedAt := time.Date(2021, 11, 2, 10, 0, 0, 0, time.UTC)
+
+	never := todo.ExpiryPolicy{Mode: todo.ExpiryNever}
+	require.Zero(t, never.EntryTTL())
+	require.Nil(t, never.ArchiveExpiration(completedAt))
+
+	inactivity := todo.ExpiryPolicy{Mode: todo.ExpiryInactivity, Days: 10}
+	require.Equal(t, 10*24*time.Hour, inactivity.EntryTTL())
+
+	completion := todo.ExpiryPolicy{Mode: todo.ExpiryCompletion, Days: 7}
+	require.Zero(t, completion.EntryTTL())
+	require.Equal(t, time.Date(2021, 11, 9, 10, 0, 0, 0, time.UTC), *completion.ArchiveExpiration(completedAt))
+}
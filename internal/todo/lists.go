@@ -0,0 +1,29 @@
+package todo
+
+import (
+	"errors"
+	"regexp"
+)
+
+// DefaultList is the name of the list, which every channel has.
+// It is used, when no list is given.
+const DefaultList = "default"
+
+var (
+	ErrListNotFound  = errors.New("list not found")
+	ErrListExists    = errors.New("list already exists")
+	ErrDefaultList   = errors.New("default list cannot be changed")
+	ErrEntryNotFound = errors.New("entry not found")
+
+	listNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+)
+
+// ValidateListName checks, if the name can be used for a list.
+// Names are lower case letters, digits, "-" and "_", up to 32 characters.
+func ValidateListName(name string) error {
+	if !listNameRegexp.MatchString(name) {
+		return errors.New("list name must be up to 32 lower case letters, digits, - or _")
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package todo_test
+
+import (
+	"testing"
+
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateListName(t *testing.T) {
+	tt := map[string]struct {
+		name  string
+		fails bool
+	}{
+		"Simple": {
+			name: "backlog",
+		},
+		"WithDash": {
+			name: "this-sprint",
+		},
+		"UpperCase": {
+			name:  "Bugs",
+			fails: true,
+		},
+		"Colon": {
+			name:  "a:b",
+			fails: true,
+		},
+		"Empty": {
+			name:  "",
+			fails: true,
+		},
+		"TooLong": {
+			name:  "abcdefghijklmnopqrstuvwxyz0123456",
+			fails: true,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			err := todo.ValidateListName(test.name)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
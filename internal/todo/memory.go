@@ -0,0 +1,669 @@
+package todo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryEntry is an entry with the time, when it expires. It never expires, if expiresAt is nil.
+type memoryEntry struct {
+	entry     *Entry
+	expiresAt *time.Time
+}
+
+func (e *memoryEntry) isExpired(now time.Time) bool {
+	return e.expiresAt != nil && !e.expiresAt.After(now)
+}
+
+// memoryArchivedEntry is a completed entry with the time, when it expires.
+type memoryArchivedEntry struct {
+	archived  *ArchivedEntry
+	expiresAt *time.Time
+}
+
+type memoryChannel struct {
+	// entries by the list name and the entry ID
+	entries map[string]map[string]*memoryEntry
+	// entry IDs in their order by the list name
+	order map[string][]string
+	// names of the named lists
+	lists   map[string]bool
+	archive map[string]*memoryArchivedEntry
+
+	notificationTimestamp int64
+	notificationHash      string
+	boardMessageID        string
+	expiryPolicy          *ExpiryPolicy
+}
+
+// MemoryTodoStore keeps the todo lists in memory, with the same semantics as RedisTodoStore.
+// The expired entries are removed, when they are read. It is used for local development and tests.
+type MemoryTodoStore struct {
+	mu       sync.Mutex
+	channels map[string]*memoryChannel
+}
+
+func NewMemoryTodoStore() *MemoryTodoStore {
+	return &MemoryTodoStore{
+		channels: make(map[string]*memoryChannel),
+	}
+}
+
+// GetEntry returns the entry or ErrEntryNotFound, if it does not exist.
+func (store *MemoryTodoStore) GetEntry(ctx context.Context, channelID, listName, entryID string) (*Entry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	stored, ok := store.liveEntries(channelID, listName, time.Now())[entryID]
+	if !ok {
+		return nil, ErrEntryNotFound
+	}
+
+	return cloneEntry(stored.entry), nil
+}
+
+func (store *MemoryTodoStore) ListEntries(ctx context.Context, channelID, listName string) ([]string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var IDs []string
+	for ID := range store.liveEntries(channelID, listName, time.Now()) {
+		IDs = append(IDs, ID)
+	}
+
+	return IDs, nil
+}
+
+// GetEntries returns the entries of the list in their order.
+func (store *MemoryTodoStore) GetEntries(ctx context.Context, channelID, listName string) (*List, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.list(channelID, listName, time.Now()), nil
+}
+
+// GetEntriesByTag returns the entries of the list with the tag in their order.
+func (store *MemoryTodoStore) GetEntriesByTag(ctx context.Context, channelID, listName, tag string) (*List, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	list := store.list(channelID, listName, time.Now())
+
+	filter := &Filter{Tag: tag}
+	list.Entries = filter.Apply(list.Entries, time.Now())
+
+	return list, nil
+}
+
+func (store *MemoryTodoStore) AddEntry(ctx context.Context, channelID, listName string, entry *Entry) (string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	entry.ID = uuid.New().String()
+
+	now := time.Now()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	entry.UpdatedAt = now
+
+	list := store.list(channelID, listName, now)
+	ch := store.channel(channelID)
+
+	ch.order[listName] = insertID(entryIDs(list.Entries), InsertPosition(list.Entries, entry), entry.ID)
+	store.setEntry(channelID, listName, entry, now)
+
+	return entry.ID, nil
+}
+
+// UpdateEntry saves the changed entry. It keeps its position and restarts its expiration time.
+// Returns ErrEntryNotFound, if the entry does not exist.
+func (store *MemoryTodoStore) UpdateEntry(ctx context.Context, channelID, listName string, entry *Entry) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := store.liveEntries(channelID, listName, now)[entry.ID]; !ok {
+		return ErrEntryNotFound
+	}
+
+	entry.UpdatedAt = now
+	store.setEntry(channelID, listName, entry, now)
+
+	return nil
+}
+
+// MoveEntry moves the entry at the position from to the position to, both counted from zero.
+// Returns ErrEntryNotFound, if there is no entry at the position from.
+func (store *MemoryTodoStore) MoveEntry(ctx context.Context, channelID, listName string, from, to int) (*Entry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	list := store.list(channelID, listName, time.Now())
+
+	order, err := MoveID(entryIDs(list.Entries), from, to)
+	if err != nil {
+		return nil, ErrEntryNotFound
+	}
+
+	store.channel(channelID).order[listName] = order
+
+	return list.Entries[from], nil
+}
+
+// TransferEntry moves the entry to another list, also in another channel. The entry gets the
+// expiration time of the target channel. Returns ErrEntryNotFound, if the entry does not exist.
+func (store *MemoryTodoStore) TransferEntry(ctx context.Context, channelID, listName, entryID, toChannelID, toListName string) (*Entry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+
+	stored, ok := store.liveEntries(channelID, listName, now)[entryID]
+	if !ok {
+		return nil, ErrEntryNotFound
+	}
+
+	entry := cloneEntry(stored.entry)
+	if channelID == toChannelID && listName == toListName {
+		return entry, nil
+	}
+
+	store.deleteEntry(channelID, listName, entryID)
+
+	target := store.list(toChannelID, toListName, now)
+	store.channel(toChannelID).order[toListName] = insertID(entryIDs(target.Entries), InsertPosition(target.Entries, entry), entryID)
+
+	entry.UpdatedAt = now
+	store.setEntry(toChannelID, toListName, entry, now)
+
+	return entry, nil
+}
+
+// TouchEntry marks the entry as updated now, which restarts its expiration time.
+// Returns ErrEntryNotFound, if the entry does not exist.
+func (store *MemoryTodoStore) TouchEntry(ctx context.Context, channelID, listName, entryID string) (*Entry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+
+	stored, ok := store.liveEntries(channelID, listName, now)[entryID]
+	if !ok {
+		return nil, ErrEntryNotFound
+	}
+
+	entry := cloneEntry(stored.entry)
+	entry.UpdatedAt = now
+	store.setEntry(channelID, listName, entry, now)
+
+	return entry, nil
+}
+
+// GetExpiringEntries returns the entries in all lists of the channel, which expire
+// within the duration, the soonest first.
+func (store *MemoryTodoStore) GetExpiringEntries(ctx context.Context, channelID string, within time.Duration) ([]*ExpiringEntry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+	expiring := make([]*ExpiringEntry, 0)
+
+	for _, name := range store.listNames(channelID) {
+		entries := store.liveEntries(channelID, name, now)
+
+		for _, entry := range store.list(channelID, name, now).Entries {
+			stored := entries[entry.ID]
+			if stored.expiresAt == nil || stored.expiresAt.Sub(now) > within {
+				continue
+			}
+
+			expiring = append(expiring, &ExpiringEntry{
+				Entry:     entry,
+				ListName:  name,
+				ExpiresAt: *stored.expiresAt,
+			})
+		}
+	}
+
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].ExpiresAt.Before(expiring[j].ExpiresAt)
+	})
+
+	return expiring, nil
+}
+
+// GetExpiryPolicy returns the expiry policy of the channel or DefaultExpiryPolicy, if it is not set.
+func (store *MemoryTodoStore) GetExpiryPolicy(ctx context.Context, channelID string) (ExpiryPolicy, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.expiryPolicy(channelID), nil
+}
+
+// SetExpiryPolicy changes the expiry policy of the channel and applies it to the
+// current entries and the archive. The expiration time of the entries starts from now.
+func (store *MemoryTodoStore) SetExpiryPolicy(ctx context.Context, channelID string, policy ExpiryPolicy) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+	ch := store.channel(channelID)
+	ch.expiryPolicy = &policy
+
+	for _, name := range store.listNames(channelID) {
+		for _, stored := range store.liveEntries(channelID, name, now) {
+			stored.expiresAt = expiresAt(now, policy.EntryTTL())
+		}
+	}
+
+	for _, archived := range ch.archive {
+		archived.expiresAt = policy.ArchiveExpiration(*archived.archived.Entry.CompletedAt)
+	}
+
+	return nil
+}
+
+func (store *MemoryTodoStore) RemoveEntry(ctx context.Context, channelID, listName, entryID string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.deleteEntry(channelID, listName, entryID)
+	return nil
+}
+
+// CompleteEntry moves the entry to the archive. Returns ErrEntryNotFound,
+// if the entry does not exist, e.g. it was completed already.
+func (store *MemoryTodoStore) CompleteEntry(ctx context.Context, channelID, listName, entryID, userID string) (*Entry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+
+	stored, ok := store.liveEntries(channelID, listName, now)[entryID]
+	if !ok {
+		return nil, ErrEntryNotFound
+	}
+
+	entry := cloneEntry(stored.entry)
+	entry.CompletedAt = &now
+	entry.CompletedBy = userID
+	entry.UpdatedAt = now
+
+	store.deleteEntry(channelID, listName, entryID)
+
+	store.channel(channelID).archive[entryID] = &memoryArchivedEntry{
+		archived: &ArchivedEntry{
+			Entry:    cloneEntry(entry),
+			ListName: listName,
+		},
+		expiresAt: store.expiryPolicy(channelID).ArchiveExpiration(now),
+	}
+
+	return entry, nil
+}
+
+// ArchiveEntry stores the archived entry with its ID and completion time.
+func (store *MemoryTodoStore) ArchiveEntry(ctx context.Context, channelID string, archived *ArchivedEntry) error {
+	if archived.Entry.CompletedAt == nil {
+		return fmt.Errorf("archived entry %s has no completion time", archived.Entry.ID)
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.channel(channelID).archive[archived.Entry.ID] = &memoryArchivedEntry{
+		archived: &ArchivedEntry{
+			Entry:    cloneEntry(archived.Entry),
+			ListName: archived.ListName,
+		},
+		expiresAt: store.expiryPolicy(channelID).ArchiveExpiration(*archived.Entry.CompletedAt),
+	}
+
+	return nil
+}
+
+// RestoreEntry moves a completed entry from the archive back to its list. If the list
+// was deleted, the entry goes to the default list. Returns ErrEntryNotFound, if the entry
+// is not in the archive.
+func (store *MemoryTodoStore) RestoreEntry(ctx context.Context, channelID, entryID string) (*ArchivedEntry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+	ch := store.channel(channelID)
+
+	stored, ok := ch.archive[entryID]
+	if !ok || (stored.expiresAt != nil && !stored.expiresAt.After(now)) {
+		delete(ch.archive, entryID)
+		return nil, ErrEntryNotFound
+	}
+
+	delete(ch.archive, entryID)
+
+	archived := &ArchivedEntry{
+		Entry:    cloneEntry(stored.archived.Entry),
+		ListName: stored.archived.ListName,
+	}
+
+	if archived.ListName != DefaultList && !ch.lists[archived.ListName] {
+		archived.ListName = DefaultList
+	}
+
+	entry := archived.Entry
+	entry.CompletedAt = nil
+	entry.CompletedBy = ""
+	entry.UpdatedAt = now
+
+	list := store.list(channelID, archived.ListName, now)
+	ch.order[archived.ListName] = insertID(entryIDs(list.Entries), InsertPosition(list.Entries, entry), entryID)
+	store.setEntry(channelID, archived.ListName, entry, now)
+
+	return archived, nil
+}
+
+// GetArchivedEntries returns the entries completed in the time range, the latest first.
+func (store *MemoryTodoStore) GetArchivedEntries(ctx context.Context, channelID string, from, to time.Time) ([]*ArchivedEntry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+	ch := store.channel(channelID)
+
+	archived := make([]*ArchivedEntry, 0)
+	for ID, stored := range ch.archive {
+		if stored.expiresAt != nil && !stored.expiresAt.After(now) {
+			delete(ch.archive, ID)
+			continue
+		}
+
+		// the range is compared in seconds like the scores in Redis
+		completedAt := stored.archived.Entry.CompletedAt.Unix()
+		if completedAt < from.Unix() || completedAt > to.Unix() {
+			continue
+		}
+
+		archived = append(archived, &ArchivedEntry{
+			Entry:    cloneEntry(stored.archived.Entry),
+			ListName: stored.archived.ListName,
+		})
+	}
+
+	sort.Slice(archived, func(i, j int) bool {
+		return archived[i].Entry.CompletedAt.After(*archived[j].Entry.CompletedAt)
+	})
+
+	return archived, nil
+}
+
+// GetLists returns the names of all lists in the channel, the default list first.
+func (store *MemoryTodoStore) GetLists(ctx context.Context, channelID string) ([]string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.listNames(channelID), nil
+}
+
+// ListExists checks, if the list was created in the channel. The default list always exists.
+func (store *MemoryTodoStore) ListExists(ctx context.Context, channelID, listName string) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return listName == DefaultList || store.channel(channelID).lists[listName], nil
+}
+
+// CreateList adds a named list to the channel. Returns ErrListExists, if it already exists.
+func (store *MemoryTodoStore) CreateList(ctx context.Context, channelID, listName string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	ch := store.channel(channelID)
+	if listName == DefaultList || ch.lists[listName] {
+		return ErrListExists
+	}
+
+	ch.lists[listName] = true
+	return nil
+}
+
+// RenameList renames a named list and moves its entries.
+func (store *MemoryTodoStore) RenameList(ctx context.Context, channelID, oldName, newName string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if oldName == DefaultList || newName == DefaultList {
+		return ErrDefaultList
+	}
+
+	ch := store.channel(channelID)
+	if !ch.lists[oldName] {
+		return ErrListNotFound
+	}
+
+	if ch.lists[newName] {
+		return ErrListExists
+	}
+
+	ch.entries[newName] = ch.entries[oldName]
+	ch.order[newName] = ch.order[oldName]
+	ch.lists[newName] = true
+
+	delete(ch.entries, oldName)
+	delete(ch.order, oldName)
+	delete(ch.lists, oldName)
+
+	return nil
+}
+
+// DeleteList removes a named list with all its entries.
+func (store *MemoryTodoStore) DeleteList(ctx context.Context, channelID, listName string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if listName == DefaultList {
+		return ErrDefaultList
+	}
+
+	ch := store.channel(channelID)
+	if !ch.lists[listName] {
+		return ErrListNotFound
+	}
+
+	delete(ch.entries, listName)
+	delete(ch.order, listName)
+	delete(ch.lists, listName)
+
+	return nil
+}
+
+// GetAllChannelsWithTodo returns the channels, which have any entries.
+func (store *MemoryTodoStore) GetAllChannelsWithTodo(ctx context.Context) ([]string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+
+	channelIDs := make([]string, 0)
+	for channelID, ch := range store.channels {
+		for name := range ch.entries {
+			if len(store.liveEntries(channelID, name, now)) > 0 {
+				channelIDs = append(channelIDs, channelID)
+				break
+			}
+		}
+	}
+
+	sort.Strings(channelIDs)
+
+	return channelIDs, nil
+}
+
+func (store *MemoryTodoStore) GetLastTodoNotificationTimestamp(ctx context.Context, channelID string) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.channel(channelID).notificationTimestamp, nil
+}
+
+func (store *MemoryTodoStore) SetLastTodoNotificationTimestamp(ctx context.Context, channelID string, timestamp int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.channel(channelID).notificationTimestamp = timestamp
+	return nil
+}
+
+func (store *MemoryTodoStore) GetLastTodoNotificationHash(ctx context.Context, channelID string) (string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.channel(channelID).notificationHash, nil
+}
+
+func (store *MemoryTodoStore) SetLastTodoNotificationHash(ctx context.Context, channelID, hash string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.channel(channelID).notificationHash = hash
+	return nil
+}
+
+// GetBoardMessageID returns the ID of the board message in the channel or an empty string,
+// if there is no board.
+func (store *MemoryTodoStore) GetBoardMessageID(ctx context.Context, channelID string) (string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.channel(channelID).boardMessageID, nil
+}
+
+func (store *MemoryTodoStore) SetBoardMessageID(ctx context.Context, channelID, messageID string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.channel(channelID).boardMessageID = messageID
+	return nil
+}
+
+// channel returns the data of the channel, which is created on the first use.
+func (store *MemoryTodoStore) channel(channelID string) *memoryChannel {
+	ch, ok := store.channels[channelID]
+	if !ok {
+		ch = &memoryChannel{
+			entries: make(map[string]map[string]*memoryEntry),
+			order:   make(map[string][]string),
+			lists:   make(map[string]bool),
+			archive: make(map[string]*memoryArchivedEntry),
+		}
+		store.channels[channelID] = ch
+	}
+
+	return ch
+}
+
+func (store *MemoryTodoStore) expiryPolicy(channelID string) ExpiryPolicy {
+	if policy := store.channel(channelID).expiryPolicy; policy != nil {
+		return *policy
+	}
+
+	return DefaultExpiryPolicy
+}
+
+func (store *MemoryTodoStore) listNames(channelID string) []string {
+	ch := store.channel(channelID)
+
+	names := make([]string, 0, len(ch.lists))
+	for name := range ch.lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return append([]string{DefaultList}, names...)
+}
+
+// liveEntries returns the stored entries of the list, after the expired ones are removed.
+func (store *MemoryTodoStore) liveEntries(channelID, listName string, now time.Time) map[string]*memoryEntry {
+	entries := store.channel(channelID).entries[listName]
+
+	for ID, stored := range entries {
+		if stored.isExpired(now) {
+			delete(entries, ID)
+		}
+	}
+
+	return entries
+}
+
+// list returns copies of the entries of the list in their order.
+func (store *MemoryTodoStore) list(channelID, listName string, now time.Time) *List {
+	live := store.liveEntries(channelID, listName, now)
+
+	entries := make(map[string]*Entry, len(live))
+	for ID, stored := range live {
+		entries[ID] = cloneEntry(stored.entry)
+	}
+
+	return &List{
+		ChannelID: channelID,
+		Name:      listName,
+		Entries:   orderEntries(store.channel(channelID).order[listName], entries),
+	}
+}
+
+// setEntry stores a copy of the entry with the expiration time of the channel.
+func (store *MemoryTodoStore) setEntry(channelID, listName string, entry *Entry, now time.Time) {
+	ch := store.channel(channelID)
+
+	if ch.entries[listName] == nil {
+		ch.entries[listName] = make(map[string]*memoryEntry)
+	}
+
+	ch.entries[listName][entry.ID] = &memoryEntry{
+		entry:     cloneEntry(entry),
+		expiresAt: expiresAt(now, store.expiryPolicy(channelID).EntryTTL()),
+	}
+}
+
+func (store *MemoryTodoStore) deleteEntry(channelID, listName, entryID string) {
+	ch := store.channel(channelID)
+
+	delete(ch.entries[listName], entryID)
+
+	order := make([]string, 0, len(ch.order[listName]))
+	for _, ID := range ch.order[listName] {
+		if ID != entryID {
+			order = append(order, ID)
+		}
+	}
+	ch.order[listName] = order
+}
+
+// cloneEntry returns a deep copy of the entry, so the stored entries are not changed by the callers.
+func cloneEntry(entry *Entry) *Entry {
+	clone := *entry
+
+	if entry.DueDate != nil {
+		dueDate := *entry.DueDate
+		clone.DueDate = &dueDate
+	}
+
+	if entry.CompletedAt != nil {
+		completedAt := *entry.CompletedAt
+		clone.CompletedAt = &completedAt
+	}
+
+	clone.Tags = append([]string(nil), entry.Tags...)
+
+	clone.Subtasks = nil
+	for _, subtask := range entry.Subtasks {
+		subtaskClone := *subtask
+		clone.Subtasks = append(clone.Subtasks, &subtaskClone)
+	}
+
+	return &clone
+}
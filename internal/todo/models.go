@@ -1,12 +1,70 @@
 package todo
 
+import "time"
+
 type Entry struct {
 	ID   string
 	Text string
-	// TODO add updatedAt
+	// DueDate is optional.
+	DueDate  *time.Time
+	Priority Priority
+	// AssigneeID is the ID of the user, who should do the task. Empty, if not assigned.
+	AssigneeID string
+	CreatedBy  string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	// CompletedAt and CompletedBy are set on archived entries.
+	CompletedAt *time.Time
+	CompletedBy string
+	// Subtasks is the checklist of the entry.
+	Subtasks []*Subtask
+	// AutoComplete completes the entry, when all subtasks are done.
+	AutoComplete bool
+	// Tags are normalized with NormalizeTag. They are parsed from the #tag tokens in
+	// the text or given explicitly.
+	Tags []string
+}
+
+// IsOverdue returns true, if the entry has a due date before now.
+func (e *Entry) IsOverdue(now time.Time) bool {
+	return e.DueDate != nil && e.DueDate.Before(now)
+}
+
+// ArchivedEntry is a completed entry with the list, on which it was.
+type ArchivedEntry struct {
+	Entry    *Entry
+	ListName string
+}
+
+// ExpiringEntry is an entry, which will be removed soon because of the expiry policy.
+type ExpiringEntry struct {
+	Entry     *Entry
+	ListName  string
+	ExpiresAt time.Time
 }
 
 type List struct {
 	ChannelID string
-	Entries   []*Entry
+	// Name of the list, DefaultList for the default list of the channel.
+	Name    string
+	Entries []*Entry
+}
+
+// Digest is the summary of the todo lists pushed to a channel.
+type Digest struct {
+	Lists    []*List
+	Expiring []*ExpiringEntry
+	// Changes since the previous digest, nil for the first digest in the channel.
+	Changes *DigestChanges
+	// GroupByTag shows the entries of each list grouped by their tags.
+	GroupByTag bool
+	// Location is the timezone of the channel, in which the dates are shown.
+	Location *time.Location
+}
+
+// DigestChanges counts the entries added and completed since the previous digest.
+type DigestChanges struct {
+	New   int
+	Done  int
+	Since time.Time
 }
@@ -2,27 +2,64 @@ package todo
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/Trojan295/organizer-bot/internal/organizer"
 	log "github.com/sirupsen/logrus"
 )
 
+// expiryWarningPeriod is how long before the expiration an entry is listed in the notification
+var expiryWarningPeriod = 3 * 24 * time.Hour
+
+// PermanentError is returned by a Pusher, when pushing the same digest again will not help,
+// e.g. Discord rejected the message.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+func IsPermanentError(err error) bool {
+	var permanentErr *PermanentError
+	return errors.As(err, &permanentErr)
+}
+
 type Pusher interface {
-	PushTodoListNotification(ctx context.Context, list *List) error
+	// PushTodoListNotification pushes the digest of the lists in the channel.
+	PushTodoListNotification(ctx context.Context, channelID string, digest *Digest) error
 }
 
 type Store interface {
 	GetAllChannelsWithTodo(ctx context.Context) ([]string, error)
-	GetEntries(ctx context.Context, channelID string) (*List, error)
+	GetLists(ctx context.Context, channelID string) ([]string, error)
+	GetEntries(ctx context.Context, channelID, listName string) (*List, error)
+	GetExpiringEntries(ctx context.Context, channelID string, within time.Duration) ([]*ExpiringEntry, error)
+	GetArchivedEntries(ctx context.Context, channelID string, from, to time.Time) ([]*ArchivedEntry, error)
 	GetLastTodoNotificationTimestamp(ctx context.Context, channelID string) (int64, error)
 	SetLastTodoNotificationTimestamp(ctx context.Context, channelID string, timestamp int64) error
+	GetLastTodoNotificationHash(ctx context.Context, channelID string) (string, error)
+	SetLastTodoNotificationHash(ctx context.Context, channelID, hash string) error
 }
 
 type TimezoneStore interface {
 	GetCurrentTimezone(ctx context.Context, channelID string) (*time.Location, error)
 }
 
+type DigestScheduleStore interface {
+	// GetDigestSchedule returns the schedule of the channel or nil, if it is not set.
+	GetDigestSchedule(ctx context.Context, channelID string) (*organizer.DigestSchedule, error)
+}
+
 type Clock interface {
 	Now() time.Time
 }
@@ -31,6 +68,7 @@ type Notifier struct {
 	pusher        Pusher
 	store         Store
 	timezoneStore TimezoneStore
+	scheduleStore DigestScheduleStore
 	clock         Clock
 
 	logger *log.Entry
@@ -40,6 +78,7 @@ type NotifierConfig struct {
 	Pusher        Pusher
 	Store         Store
 	TimezoneStore TimezoneStore
+	ScheduleStore DigestScheduleStore
 	Clock         Clock
 }
 
@@ -66,6 +105,10 @@ func NewNotifier(cfg *NotifierConfig) (*Notifier, error) {
 		return nil, fmt.Errorf("TimezoneStore is not set")
 	}
 
+	if cfg.ScheduleStore == nil {
+		return nil, fmt.Errorf("ScheduleStore is not set")
+	}
+
 	if cfg.Clock == nil {
 		cfg.Clock = &RealClock{}
 	}
@@ -74,6 +117,7 @@ func NewNotifier(cfg *NotifierConfig) (*Notifier, error) {
 		pusher:        cfg.Pusher,
 		store:         cfg.Store,
 		timezoneStore: cfg.TimezoneStore,
+		scheduleStore: cfg.ScheduleStore,
 		clock:         cfg.Clock,
 		logger:        log.NewEntry(log.New()).WithField("struct", "todo.Service"),
 	}, nil
@@ -105,28 +149,176 @@ func (service *Notifier) Run(ctx context.Context) error {
 			continue
 		}
 
-		currentTime := now.In(timezone)
-		lastNotificationTime := time.Unix(lastNotification, 0).In(timezone)
+		schedule, err := service.scheduleStore.GetDigestSchedule(ctx, ID)
+		if err != nil {
+			service.logger.WithError(err).WithField("channelID", ID).Error("failed to get digest schedule")
+			continue
+		}
+
+		if schedule == nil {
+			schedule = &organizer.DefaultDigestSchedule
+		}
 
-		// send notification if after 9.00 and not send this day
-		if (lastNotification == 0 || currentTime.Day() != lastNotificationTime.Day()) && currentTime.Hour() >= 9 {
-			list, err := service.store.GetEntries(ctx, ID)
+		// the digest is sent once for the latest scheduled time today, missed days are not caught up
+		slot := schedule.LatestSlot(now.In(timezone))
+		if slot == nil {
+			continue
+		}
+
+		if lastNotification >= slot.Unix() {
+			continue
+		}
+
+		digest, err := service.getDigest(ctx, ID, lastNotification, now.In(timezone))
+		if err != nil {
+			service.logger.WithError(err).WithField("channelID", ID).Error("failed to get digest")
+			continue
+		}
+
+		// there is nothing to summarize
+		if len(digest.Lists) == 0 {
+			continue
+		}
+
+		digest.GroupByTag = schedule.GroupByTag
+
+		hash := digestHash(digest, now)
+
+		if schedule.SkipUnchanged {
+			lastHash, err := service.store.GetLastTodoNotificationHash(ctx, ID)
 			if err != nil {
-				service.logger.WithError(err).WithField("channelID", ID).Error("failed to get todo list")
+				service.logger.WithError(err).WithField("channelID", ID).Error("failed to get last notification hash")
 				continue
 			}
 
-			if err := service.pusher.PushTodoListNotification(ctx, list); err != nil {
-				service.logger.WithError(err).WithField("channelID", ID).Error("failed to push todo list")
+			if lastHash == hash {
+				if err := service.store.SetLastTodoNotificationTimestamp(ctx, ID, now.Unix()); err != nil {
+					service.logger.WithError(err).WithField("channelID", ID).Error("failed to set last notification timestamp")
+				}
 				continue
 			}
+		}
+
+		if err := service.pusher.PushTodoListNotification(ctx, ID, digest); IsPermanentError(err) {
+			// the digest is not retried until the next scheduled time
+			service.logger.WithError(err).WithField("channelID", ID).Error("todo list was rejected")
 
 			if err := service.store.SetLastTodoNotificationTimestamp(ctx, ID, now.Unix()); err != nil {
 				service.logger.WithError(err).WithField("channelID", ID).Error("failed to set last notification timestamp")
-				continue
 			}
+			continue
+		} else if err != nil {
+			service.logger.WithError(err).WithField("channelID", ID).Error("failed to push todo list")
+			continue
+		}
+
+		if err := service.store.SetLastTodoNotificationTimestamp(ctx, ID, now.Unix()); err != nil {
+			service.logger.WithError(err).WithField("channelID", ID).Error("failed to set last notification timestamp")
+			continue
+		}
+
+		if err := service.store.SetLastTodoNotificationHash(ctx, ID, hash); err != nil {
+			service.logger.WithError(err).WithField("channelID", ID).Error("failed to set last notification hash")
+			continue
 		}
 	}
 
 	return nil
 }
+
+func (service *Notifier) getNonEmptyLists(ctx context.Context, channelID string) ([]*List, error) {
+	names, err := service.store.GetLists(ctx, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("while getting list names: %w", err)
+	}
+
+	lists := make([]*List, 0, len(names))
+	for _, name := range names {
+		list, err := service.store.GetEntries(ctx, channelID, name)
+		if err != nil {
+			return nil, fmt.Errorf("while getting list %s: %w", name, err)
+		}
+
+		if len(list.Entries) > 0 {
+			lists = append(lists, list)
+		}
+	}
+
+	return lists, nil
+}
+
+// getDigest collects the lists and the changes since the last notification. The time
+// of the changes and the location of the digest are the location of now.
+func (service *Notifier) getDigest(ctx context.Context, channelID string, lastNotification int64, now time.Time) (*Digest, error) {
+	lists, err := service.getNonEmptyLists(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiring, err := service.store.GetExpiringEntries(ctx, channelID, expiryWarningPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("while getting expiring entries: %w", err)
+	}
+
+	digest := &Digest{
+		Lists:    lists,
+		Expiring: expiring,
+		Location: now.Location(),
+	}
+
+	if lastNotification == 0 {
+		return digest, nil
+	}
+
+	since := time.Unix(lastNotification, 0).In(now.Location())
+
+	archived, err := service.store.GetArchivedEntries(ctx, channelID, since, now)
+	if err != nil {
+		return nil, fmt.Errorf("while getting archived entries: %w", err)
+	}
+
+	digest.Changes = &DigestChanges{
+		Done:  len(archived),
+		Since: since,
+	}
+
+	for _, list := range lists {
+		for _, entry := range list.Entries {
+			if entry.CreatedAt.After(since) {
+				digest.Changes.New++
+			}
+		}
+	}
+
+	return digest, nil
+}
+
+// digestHash returns a hash of the digest content, which changes, when an entry
+// is added, changed, removed, becomes overdue or starts expiring, or a subtask is checked.
+func digestHash(digest *Digest, now time.Time) string {
+	hash := sha256.New()
+
+	for _, list := range digest.Lists {
+		fmt.Fprintf(hash, "list:%s\n", list.Name)
+
+		for _, entry := range list.Entries {
+			var due int64
+			if entry.DueDate != nil {
+				due = entry.DueDate.Unix()
+			}
+
+			fmt.Fprintf(hash, "entry:%s:%d:%d:%d:%s:%t:%s\n",
+				entry.ID, entry.UpdatedAt.Unix(), due, entry.Priority, entry.AssigneeID, entry.IsOverdue(now), entry.Text)
+
+			for _, subtask := range entry.Subtasks {
+				fmt.Fprintf(hash, "subtask:%s:%t:%s\n", subtask.ID, subtask.Done, subtask.Text)
+			}
+		}
+	}
+
+	for _, entry := range digest.Expiring {
+		fmt.Fprintf(hash, "expiring:%s:%s\n", entry.ListName, entry.Entry.ID)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
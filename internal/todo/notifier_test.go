@@ -2,9 +2,11 @@ package todo_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/Trojan295/organizer-bot/internal/organizer"
 	"github.com/Trojan295/organizer-bot/internal/todo"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -14,8 +16,8 @@ type Mock struct {
 	mock.Mock
 }
 
-func (p *Mock) PushTodoListNotification(ctx context.Context, list *todo.List) error {
-	args := p.Called(ctx, list)
+func (p *Mock) PushTodoListNotification(ctx context.Context, channelID string, digest *todo.Digest) error {
+	args := p.Called(ctx, channelID, digest)
 	return args.Error(0)
 }
 
@@ -24,11 +26,36 @@ func (p *Mock) GetAllChannelsWithTodo(ctx context.Context) ([]string, error) {
 	return args.Get(0).([]string), args.Error(1)
 }
 
-func (p *Mock) GetEntries(ctx context.Context, channelID string) (*todo.List, error) {
+func (p *Mock) GetLists(ctx context.Context, channelID string) ([]string, error) {
 	args := p.Called(ctx, channelID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (p *Mock) GetEntries(ctx context.Context, channelID, listName string) (*todo.List, error) {
+	args := p.Called(ctx, channelID, listName)
 	return args.Get(0).(*todo.List), args.Error(1)
 }
 
+func (p *Mock) GetExpiringEntries(ctx context.Context, channelID string, within time.Duration) ([]*todo.ExpiringEntry, error) {
+	args := p.Called(ctx, channelID, within)
+	return args.Get(0).([]*todo.ExpiringEntry), args.Error(1)
+}
+
+func (p *Mock) GetArchivedEntries(ctx context.Context, channelID string, from, to time.Time) ([]*todo.ArchivedEntry, error) {
+	args := p.Called(ctx, channelID, from, to)
+	return args.Get(0).([]*todo.ArchivedEntry), args.Error(1)
+}
+
+func (p *Mock) GetLastTodoNotificationHash(ctx context.Context, channelID string) (string, error) {
+	args := p.Called(ctx, channelID)
+	return args.String(0), args.Error(1)
+}
+
+func (p *Mock) SetLastTodoNotificationHash(ctx context.Context, channelID, hash string) error {
+	args := p.Called(ctx, channelID, hash)
+	return args.Error(0)
+}
+
 func (p *Mock) GetLastTodoNotificationTimestamp(ctx context.Context, channelID string) (int64, error) {
 	args := p.Called(ctx, channelID)
 	return args.Get(0).(int64), args.Error(1)
@@ -44,6 +71,11 @@ func (p *Mock) GetCurrentTimezone(ctx context.Context, channelID string) (*time.
 	return args.Get(0).(*time.Location), args.Error(1)
 }
 
+func (p *Mock) GetDigestSchedule(ctx context.Context, channelID string) (*organizer.DigestSchedule, error) {
+	args := p.Called(ctx, channelID)
+	return args.Get(0).(*organizer.DigestSchedule), args.Error(1)
+}
+
 type MockClock struct {
 	FixedTime time.Time
 }
@@ -125,6 +157,7 @@ func TestNotifier_Run(t *testing.T) {
 	for name, test := range tt {
 		list := &todo.List{
 			ChannelID: "channelID",
+			Name:      todo.DefaultList,
 			Entries: []*todo.Entry{
 				{
 					ID:   "1",
@@ -134,23 +167,31 @@ func TestNotifier_Run(t *testing.T) {
 		}
 		ctx := context.Background()
 
-		mock := &Mock{}
+		m := &Mock{}
 
-		mock.On("GetCurrentTimezone", ctx, list.ChannelID).Return(test.timeZone, nil)
-		mock.On("GetAllChannelsWithTodo", ctx).Return([]string{list.ChannelID}, nil)
-		mock.On("GetLastTodoNotificationTimestamp", ctx, list.ChannelID).Return(test.lastNotificationTimestamp, nil)
+		m.On("GetCurrentTimezone", ctx, list.ChannelID).Return(test.timeZone, nil)
+		m.On("GetAllChannelsWithTodo", ctx).Return([]string{list.ChannelID}, nil)
+		m.On("GetDigestSchedule", ctx, list.ChannelID).Return((*organizer.DigestSchedule)(nil), nil)
+		m.On("GetLastTodoNotificationTimestamp", ctx, list.ChannelID).Return(test.lastNotificationTimestamp, nil)
 
 		if test.notified {
-			mock.On("GetEntries", ctx, list.ChannelID).Return(list, nil)
-			mock.On("SetLastTodoNotificationTimestamp", ctx, list.ChannelID, test.currentTimestamp.Unix()).Return(nil)
-			mock.On("PushTodoListNotification", ctx, list).Return(nil)
+			m.On("GetLists", ctx, list.ChannelID).Return([]string{todo.DefaultList}, nil)
+			m.On("GetEntries", ctx, list.ChannelID, todo.DefaultList).Return(list, nil)
+			m.On("SetLastTodoNotificationTimestamp", ctx, list.ChannelID, test.currentTimestamp.Unix()).Return(nil)
+			m.On("GetExpiringEntries", ctx, list.ChannelID, 72*time.Hour).Return([]*todo.ExpiringEntry{}, nil)
+			m.On("GetArchivedEntries", ctx, list.ChannelID, mock.Anything, mock.Anything).Return([]*todo.ArchivedEntry{}, nil).Maybe()
+			m.On("PushTodoListNotification", ctx, list.ChannelID, mock.MatchedBy(func(d *todo.Digest) bool {
+				return len(d.Lists) == 1 && d.Lists[0] == list
+			})).Return(nil)
+			m.On("SetLastTodoNotificationHash", ctx, list.ChannelID, mock.Anything).Return(nil)
 		}
 
 		t.Run(name, func(t *testing.T) {
 			notifier, err := todo.NewNotifier(&todo.NotifierConfig{
-				Pusher:        mock,
-				Store:         mock,
-				TimezoneStore: mock,
+				Pusher:        m,
+				Store:         m,
+				TimezoneStore: m,
+				ScheduleStore: m,
 				Clock:         &MockClock{FixedTime: test.currentTimestamp},
 			})
 			require.NoError(t, err)
@@ -158,7 +199,424 @@ func TestNotifier_Run(t *testing.T) {
 			err = notifier.Run(context.Background())
 			require.NoError(t, err)
 
-			mock.AssertExpectations(t)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNotifier_RunSummarizesAllLists(t *testing.T) {
+	ctx := context.Background()
+	channelID := "channelID"
+	now := time.Date(2021, 11, 2, 9, 37, 0, 0, time.UTC)
+
+	defaultList := &todo.List{
+		ChannelID: channelID,
+		Name:      todo.DefaultList,
+		Entries:   []*todo.Entry{{ID: "1", Text: "Hello!"}},
+	}
+	backlog := &todo.List{
+		ChannelID: channelID,
+		Name:      "backlog",
+		Entries:   []*todo.Entry{{ID: "2", Text: "Later"}},
+	}
+	bugs := &todo.List{
+		ChannelID: channelID,
+		Name:      "bugs",
+		Entries:   []*todo.Entry{},
+	}
+
+	m := &Mock{}
+
+	m.On("GetCurrentTimezone", ctx, channelID).Return(time.UTC, nil)
+	m.On("GetAllChannelsWithTodo", ctx).Return([]string{channelID}, nil)
+	m.On("GetDigestSchedule", ctx, channelID).Return((*organizer.DigestSchedule)(nil), nil)
+	m.On("GetLastTodoNotificationTimestamp", ctx, channelID).Return(int64(0), nil)
+	m.On("GetLists", ctx, channelID).Return([]string{todo.DefaultList, "backlog", "bugs"}, nil)
+	m.On("GetEntries", ctx, channelID, todo.DefaultList).Return(defaultList, nil)
+	m.On("GetEntries", ctx, channelID, "backlog").Return(backlog, nil)
+	m.On("GetEntries", ctx, channelID, "bugs").Return(bugs, nil)
+	m.On("GetExpiringEntries", ctx, channelID, 72*time.Hour).Return([]*todo.ExpiringEntry{}, nil)
+	m.On("PushTodoListNotification", ctx, channelID, &todo.Digest{
+		Lists:    []*todo.List{defaultList, backlog},
+		Expiring: []*todo.ExpiringEntry{},
+		Location: time.UTC,
+	}).Return(nil)
+	m.On("SetLastTodoNotificationHash", ctx, channelID, mock.Anything).Return(nil)
+	m.On("SetLastTodoNotificationTimestamp", ctx, channelID, now.Unix()).Return(nil)
+
+	notifier, err := todo.NewNotifier(&todo.NotifierConfig{
+		Pusher:        m,
+		Store:         m,
+		TimezoneStore: m,
+		ScheduleStore: m,
+		Clock:         &MockClock{FixedTime: now},
+	})
+	require.NoError(t, err)
+
+	err = notifier.Run(ctx)
+	require.NoError(t, err)
+
+	m.AssertExpectations(t)
+}
+
+func TestNotifier_RunWarnsAboutExpiringEntries(t *testing.T) {
+	ctx := context.Background()
+	channelID := "channelID"
+	now := time.Date(2021, 11, 2, 9, 37, 0, 0, time.UTC)
+
+	entry := &todo.Entry{ID: "1", Text: "Hello!"}
+	list := &todo.List{
+		ChannelID: channelID,
+		Name:      todo.DefaultList,
+		Entries:   []*todo.Entry{entry},
+	}
+	expiring := []*todo.ExpiringEntry{
+		{Entry: entry, ListName: todo.DefaultList, ExpiresAt: now.Add(24 * time.Hour)},
+	}
+
+	m := &Mock{}
+
+	m.On("GetCurrentTimezone", ctx, channelID).Return(time.UTC, nil)
+	m.On("GetAllChannelsWithTodo", ctx).Return([]string{channelID}, nil)
+	m.On("GetDigestSchedule", ctx, channelID).Return((*organizer.DigestSchedule)(nil), nil)
+	m.On("GetLastTodoNotificationTimestamp", ctx, channelID).Return(int64(0), nil)
+	m.On("GetLists", ctx, channelID).Return([]string{todo.DefaultList}, nil)
+	m.On("GetEntries", ctx, channelID, todo.DefaultList).Return(list, nil)
+	m.On("GetExpiringEntries", ctx, channelID, 72*time.Hour).Return(expiring, nil)
+	m.On("PushTodoListNotification", ctx, channelID, &todo.Digest{
+		Lists:    []*todo.List{list},
+		Expiring: expiring,
+		Location: time.UTC,
+	}).Return(nil)
+	m.On("SetLastTodoNotificationHash", ctx, channelID, mock.Anything).Return(nil)
+	m.On("SetLastTodoNotificationTimestamp", ctx, channelID, now.Unix()).Return(nil)
+
+	notifier, err := todo.NewNotifier(&todo.NotifierConfig{
+		Pusher:        m,
+		Store:         m,
+		TimezoneStore: m,
+		ScheduleStore: m,
+		Clock:         &MockClock{FixedTime: now},
+	})
+	require.NoError(t, err)
+
+	err = notifier.Run(ctx)
+	require.NoError(t, err)
+
+	m.AssertExpectations(t)
+}
+
+func TestNotifier_RunFollowsDigestSchedule(t *testing.T) {
+	// Tuesday
+	tuesday := time.Date(2021, 11, 2, 0, 0, 0, 0, time.UTC)
+
+	tt := map[string]struct {
+		schedule                  string
+		currentTimestamp          time.Time
+		lastNotificationTimestamp time.Time
+		notified                  bool
+	}{
+		"Off": {
+			schedule:         "off",
+			currentTimestamp: tuesday.Add(12 * time.Hour),
+			notified:         false,
+		},
+		"DifferentHour": {
+			schedule:                  "daily 14:30",
+			currentTimestamp:          tuesday.Add(14*time.Hour + 31*time.Minute),
+			lastNotificationTimestamp: tuesday.Add(-10 * time.Hour),
+			notified:                  true,
+		},
+		"DifferentHour_BeforeTime": {
+			schedule:                  "daily 14:30",
+			currentTimestamp:          tuesday.Add(14 * time.Hour),
+			lastNotificationTimestamp: tuesday.Add(-10 * time.Hour),
+			notified:                  false,
+		},
+		"TwiceDaily_SecondDigest": {
+			schedule:                  "daily 9,17",
+			currentTimestamp:          tuesday.Add(17*time.Hour + 5*time.Minute),
+			lastNotificationTimestamp: tuesday.Add(9*time.Hour + 5*time.Minute),
+			notified:                  true,
+		},
+		"TwiceDaily_FirstDigestSent": {
+			schedule:                  "daily 9,17",
+			currentTimestamp:          tuesday.Add(12 * time.Hour),
+			lastNotificationTimestamp: tuesday.Add(9*time.Hour + 5*time.Minute),
+			notified:                  false,
+		},
+		"WeeklyMonday_NotOnTuesday": {
+			schedule:                  "mon 9",
+			currentTimestamp:          tuesday.Add(12 * time.Hour),
+			lastNotificationTimestamp: tuesday.AddDate(0, 0, -8),
+			notified:                  false,
+		},
+		"WeeklyMonday_OnMonday": {
+			schedule:                  "mon 9",
+			currentTimestamp:          tuesday.AddDate(0, 0, 6).Add(12 * time.Hour),
+			lastNotificationTimestamp: tuesday.AddDate(0, 0, -1).Add(9 * time.Hour),
+			notified:                  true,
+		},
+		"Weekdays_NotOnSaturday": {
+			schedule:                  "weekdays 9",
+			currentTimestamp:          tuesday.AddDate(0, 0, 4).Add(12 * time.Hour),
+			lastNotificationTimestamp: tuesday.AddDate(0, 0, 3).Add(9 * time.Hour),
+			notified:                  false,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			channelID := "channelID"
+			list := &todo.List{
+				ChannelID: channelID,
+				Name:      todo.DefaultList,
+				Entries:   []*todo.Entry{{ID: "1", Text: "Hello!"}},
+			}
+
+			schedule, err := organizer.ParseDigestSchedule(test.schedule)
+			require.NoError(t, err)
+
+			m := &Mock{}
+
+			m.On("GetCurrentTimezone", ctx, channelID).Return(time.UTC, nil)
+			m.On("GetAllChannelsWithTodo", ctx).Return([]string{channelID}, nil)
+			m.On("GetDigestSchedule", ctx, channelID).Return(schedule, nil)
+			m.On("GetLastTodoNotificationTimestamp", ctx, channelID).Return(test.lastNotificationTimestamp.Unix(), nil)
+
+			if test.notified {
+				m.On("GetLists", ctx, channelID).Return([]string{todo.DefaultList}, nil)
+				m.On("GetEntries", ctx, channelID, todo.DefaultList).Return(list, nil)
+				m.On("GetExpiringEntries", ctx, channelID, 72*time.Hour).Return([]*todo.ExpiringEntry{}, nil)
+				m.On("GetArchivedEntries", ctx, channelID, mock.Anything, mock.Anything).Return([]*todo.ArchivedEntry{}, nil)
+				m.On("PushTodoListNotification", ctx, channelID, mock.Anything).Return(nil)
+				m.On("SetLastTodoNotificationHash", ctx, channelID, mock.Anything).Return(nil)
+				m.On("SetLastTodoNotificationTimestamp", ctx, channelID, test.currentTimestamp.Unix()).Return(nil)
+			}
+
+			notifier, err := todo.NewNotifier(&todo.NotifierConfig{
+				Pusher:        m,
+				Store:         m,
+				TimezoneStore: m,
+				ScheduleStore: m,
+				Clock:         &MockClock{FixedTime: test.currentTimestamp},
+			})
+			require.NoError(t, err)
+
+			err = notifier.Run(ctx)
+			require.NoError(t, err)
+
+			m.AssertExpectations(t)
 		})
 	}
 }
+
+func TestNotifier_RunCountsChangesSinceLastDigest(t *testing.T) {
+	ctx := context.Background()
+	channelID := "channelID"
+	now := time.Date(2021, 11, 2, 9, 37, 0, 0, time.UTC)
+	lastNotification := time.Date(2021, 11, 1, 9, 5, 0, 0, time.UTC)
+
+	list := &todo.List{
+		ChannelID: channelID,
+		Name:      todo.DefaultList,
+		Entries: []*todo.Entry{
+			{ID: "1", Text: "Old", CreatedAt: lastNotification.Add(-time.Hour)},
+			{ID: "2", Text: "New", CreatedAt: lastNotification.Add(time.Hour)},
+		},
+	}
+	archived := []*todo.ArchivedEntry{
+		{Entry: &todo.Entry{ID: "3"}, ListName: todo.DefaultList},
+		{Entry: &todo.Entry{ID: "4"}, ListName: todo.DefaultList},
+	}
+
+	m := &Mock{}
+
+	m.On("GetCurrentTimezone", ctx, channelID).Return(time.UTC, nil)
+	m.On("GetAllChannelsWithTodo", ctx).Return([]string{channelID}, nil)
+	m.On("GetDigestSchedule", ctx, channelID).Return((*organizer.DigestSchedule)(nil), nil)
+	m.On("GetLastTodoNotificationTimestamp", ctx, channelID).Return(lastNotification.Unix(), nil)
+	m.On("GetLists", ctx, channelID).Return([]string{todo.DefaultList}, nil)
+	m.On("GetEntries", ctx, channelID, todo.DefaultList).Return(list, nil)
+	m.On("GetExpiringEntries", ctx, channelID, 72*time.Hour).Return([]*todo.ExpiringEntry{}, nil)
+	m.On("GetArchivedEntries", ctx, channelID, lastNotification, now).Return(archived, nil)
+	m.On("PushTodoListNotification", ctx, channelID, &todo.Digest{
+		Lists:    []*todo.List{list},
+		Expiring: []*todo.ExpiringEntry{},
+		Changes:  &todo.DigestChanges{New: 1, Done: 2, Since: lastNotification},
+		Location: time.UTC,
+	}).Return(nil)
+	m.On("SetLastTodoNotificationTimestamp", ctx, channelID, now.Unix()).Return(nil)
+	m.On("SetLastTodoNotificationHash", ctx, channelID, mock.Anything).Return(nil)
+
+	notifier, err := todo.NewNotifier(&todo.NotifierConfig{
+		Pusher:        m,
+		Store:         m,
+		TimezoneStore: m,
+		ScheduleStore: m,
+		Clock:         &MockClock{FixedTime: now},
+	})
+	require.NoError(t, err)
+
+	err = notifier.Run(ctx)
+	require.NoError(t, err)
+
+	m.AssertExpectations(t)
+}
+
+func TestNotifier_RunSkipsUnchangedDigest(t *testing.T) {
+	ctx := context.Background()
+	channelID := "channelID"
+	firstDay := time.Date(2021, 11, 2, 9, 37, 0, 0, time.UTC)
+	secondDay := firstDay.AddDate(0, 0, 1)
+
+	list := &todo.List{
+		ChannelID: channelID,
+		Name:      todo.DefaultList,
+		Entries:   []*todo.Entry{{ID: "1", Text: "Hello!"}},
+	}
+
+	schedule, err := organizer.ParseDigestSchedule("daily 9:00 skip-unchanged")
+	require.NoError(t, err)
+
+	setupMock := func(lastNotification int64, lastHash string) *Mock {
+		m := &Mock{}
+
+		m.On("GetCurrentTimezone", ctx, channelID).Return(time.UTC, nil)
+		m.On("GetAllChannelsWithTodo", ctx).Return([]string{channelID}, nil)
+		m.On("GetDigestSchedule", ctx, channelID).Return(schedule, nil)
+		m.On("GetLastTodoNotificationTimestamp", ctx, channelID).Return(lastNotification, nil)
+		m.On("GetLastTodoNotificationHash", ctx, channelID).Return(lastHash, nil)
+		m.On("GetLists", ctx, channelID).Return([]string{todo.DefaultList}, nil)
+		m.On("GetEntries", ctx, channelID, todo.DefaultList).Return(list, nil)
+		m.On("GetExpiringEntries", ctx, channelID, 72*time.Hour).Return([]*todo.ExpiringEntry{}, nil)
+		m.On("GetArchivedEntries", ctx, channelID, mock.Anything, mock.Anything).Return([]*todo.ArchivedEntry{}, nil).Maybe()
+
+		return m
+	}
+
+	run := func(m *Mock, now time.Time) {
+		notifier, err := todo.NewNotifier(&todo.NotifierConfig{
+			Pusher:        m,
+			Store:         m,
+			TimezoneStore: m,
+			ScheduleStore: m,
+			Clock:         &MockClock{FixedTime: now},
+		})
+		require.NoError(t, err)
+
+		err = notifier.Run(ctx)
+		require.NoError(t, err)
+
+		m.AssertExpectations(t)
+	}
+
+	// the first digest is pushed and its hash saved
+	var hash string
+
+	first := setupMock(0, "")
+	first.On("PushTodoListNotification", ctx, channelID, mock.Anything).Return(nil)
+	first.On("SetLastTodoNotificationTimestamp", ctx, channelID, firstDay.Unix()).Return(nil)
+	first.On("SetLastTodoNotificationHash", ctx, channelID, mock.Anything).
+		Run(func(args mock.Arguments) { hash = args.String(2) }).
+		Return(nil)
+
+	run(first, firstDay)
+	require.NotEmpty(t, hash)
+
+	// the next day nothing changed, so only the timestamp is updated
+	second := setupMock(firstDay.Unix(), hash)
+	second.On("SetLastTodoNotificationTimestamp", ctx, channelID, secondDay.Unix()).Return(nil)
+
+	run(second, secondDay)
+	second.AssertNotCalled(t, "PushTodoListNotification", ctx, channelID, mock.Anything)
+}
+
+func TestNotifier_RunGroupsByTag(t *testing.T) {
+	ctx := context.Background()
+	channelID := "channelID"
+	now := time.Date(2021, 11, 2, 9, 37, 0, 0, time.UTC)
+
+	list := &todo.List{
+		ChannelID: channelID,
+		Name:      todo.DefaultList,
+		Entries:   []*todo.Entry{{ID: "1", Text: "Deploy #ops", Tags: []string{"ops"}}},
+	}
+
+	schedule, err := organizer.ParseDigestSchedule("daily 9:00 group-by-tag")
+	require.NoError(t, err)
+
+	m := &Mock{}
+	m.On("GetCurrentTimezone", ctx, channelID).Return(time.UTC, nil)
+	m.On("GetAllChannelsWithTodo", ctx).Return([]string{channelID}, nil)
+	m.On("GetDigestSchedule", ctx, channelID).Return(schedule, nil)
+	m.On("GetLastTodoNotificationTimestamp", ctx, channelID).Return(int64(0), nil)
+	m.On("GetLists", ctx, channelID).Return([]string{todo.DefaultList}, nil)
+	m.On("GetEntries", ctx, channelID, todo.DefaultList).Return(list, nil)
+	m.On("GetExpiringEntries", ctx, channelID, 72*time.Hour).Return([]*todo.ExpiringEntry{}, nil)
+	m.On("PushTodoListNotification", ctx, channelID, &todo.Digest{
+		Lists:      []*todo.List{list},
+		Expiring:   []*todo.ExpiringEntry{},
+		GroupByTag: true,
+		Location:   time.UTC,
+	}).Return(nil)
+	m.On("SetLastTodoNotificationTimestamp", ctx, channelID, now.Unix()).Return(nil)
+	m.On("SetLastTodoNotificationHash", ctx, channelID, mock.Anything).Return(nil)
+
+	notifier, err := todo.NewNotifier(&todo.NotifierConfig{
+		Pusher:        m,
+		Store:         m,
+		TimezoneStore: m,
+		ScheduleStore: m,
+		Clock:         &MockClock{FixedTime: now},
+	})
+	require.NoError(t, err)
+
+	err = notifier.Run(ctx)
+	require.NoError(t, err)
+
+	m.AssertExpectations(t)
+}
+
+func TestNotifier_RunDoesNotRetryRejectedDigest(t *testing.T) {
+	ctx := context.Background()
+	channelID := "channelID"
+	now := time.Date(2021, 11, 2, 9, 37, 0, 0, time.UTC)
+
+	list := &todo.List{
+		ChannelID: channelID,
+		Name:      todo.DefaultList,
+		Entries:   []*todo.Entry{{ID: "1", Text: "Hello!"}},
+	}
+
+	m := &Mock{}
+	m.On("GetCurrentTimezone", ctx, channelID).Return(time.UTC, nil)
+	m.On("GetAllChannelsWithTodo", ctx).Return([]string{channelID}, nil)
+	m.On("GetDigestSchedule", ctx, channelID).Return((*organizer.DigestSchedule)(nil), nil)
+	m.On("GetLastTodoNotificationTimestamp", ctx, channelID).Return(int64(0), nil)
+	m.On("GetLists", ctx, channelID).Return([]string{todo.DefaultList}, nil)
+	m.On("GetEntries", ctx, channelID, todo.DefaultList).Return(list, nil)
+	m.On("GetExpiringEntries", ctx, channelID, 72*time.Hour).Return([]*todo.ExpiringEntry{}, nil)
+	m.On("PushTodoListNotification", ctx, channelID, mock.Anything).
+		Return(&todo.PermanentError{Err: errors.New("message too long")})
+	// the timestamp is saved, so the digest waits for the next scheduled time
+	m.On("SetLastTodoNotificationTimestamp", ctx, channelID, now.Unix()).Return(nil)
+
+	notifier, err := todo.NewNotifier(&todo.NotifierConfig{
+		Pusher:        m,
+		Store:         m,
+		TimezoneStore: m,
+		ScheduleStore: m,
+		Clock:         &MockClock{FixedTime: now},
+	})
+	require.NoError(t, err)
+
+	err = notifier.Run(ctx)
+	require.NoError(t, err)
+
+	m.AssertExpectations(t)
+	m.AssertNotCalled(t, "SetLastTodoNotificationHash", ctx, channelID, mock.Anything)
+}
@@ -0,0 +1,78 @@
+package todo
+
+import "fmt"
+
+// InsertPosition returns the index, at which the entry is added to the ordered entries.
+// It goes before the first entry, which SortEntries would put after it, so new entries
+// follow the priorities, even if the list was reordered by hand.
+func InsertPosition(entries []*Entry, entry *Entry) int {
+	for idx, other := range entries {
+		if entryLess(entry, other) {
+			return idx
+		}
+	}
+
+	return len(entries)
+}
+
+// MoveID returns the IDs with the one at index from moved to index to.
+// The index to is limited to the length of the IDs.
+func MoveID(IDs []string, from, to int) ([]string, error) {
+	if from < 0 || from >= len(IDs) {
+		return nil, fmt.Errorf("position %d out of range", from+1)
+	}
+
+	if to < 0 {
+		to = 0
+	}
+	if to >= len(IDs) {
+		to = len(IDs) - 1
+	}
+
+	moved := IDs[from]
+
+	result := make([]string, 0, len(IDs))
+	result = append(result, IDs[:from]...)
+	result = append(result, IDs[from+1:]...)
+
+	result = append(result[:to], append([]string{moved}, result[to:]...)...)
+
+	return result, nil
+}
+
+// insertID returns the IDs with the ID added at the index.
+func insertID(IDs []string, idx int, ID string) []string {
+	result := make([]string, 0, len(IDs)+1)
+	result = append(result, IDs[:idx]...)
+	result = append(result, ID)
+	result = append(result, IDs[idx:]...)
+
+	return result
+}
+
+// orderEntries returns the entries in the order of the IDs. The entries, which are not
+// in the order, e.g. added before it was stored, follow sorted with SortEntries.
+func orderEntries(order []string, entries map[string]*Entry) []*Entry {
+	remaining := make(map[string]*Entry, len(entries))
+	for ID, entry := range entries {
+		remaining[ID] = entry
+	}
+
+	ordered := make([]*Entry, 0, len(entries))
+
+	// the order can have IDs of the expired entries
+	for _, ID := range order {
+		if entry, ok := remaining[ID]; ok {
+			ordered = append(ordered, entry)
+			delete(remaining, ID)
+		}
+	}
+
+	unordered := make([]*Entry, 0, len(remaining))
+	for _, entry := range remaining {
+		unordered = append(unordered, entry)
+	}
+	SortEntries(unordered)
+
+	return append(ordered, unordered...)
+}
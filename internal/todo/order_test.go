@@ -0,0 +1,101 @@
+package todo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertPosition(t *testing.T) {
+	now := time.Date(2021, 11, 2, 10, 0, 0, 0, time.UTC)
+
+	// reordered by hand, the low priority entry is first
+	entries := []*todo.Entry{
+		{ID: "low", Priority: todo.PriorityLow, CreatedAt: now},
+		{ID: "high", Priority: todo.PriorityHigh, CreatedAt: now},
+		{ID: "normal", Priority: todo.PriorityNormal, CreatedAt: now},
+	}
+
+	tt := map[string]struct {
+		entry    *todo.Entry
+		expected int
+	}{
+		"Urgent": {
+			entry:    &todo.Entry{ID: "new", Priority: todo.PriorityUrgent, CreatedAt: now.Add(time.Hour)},
+			expected: 0,
+		},
+		"NormalGoesBeforeFirstLower": {
+			entry:    &todo.Entry{ID: "new", Priority: todo.PriorityNormal, CreatedAt: now.Add(time.Hour)},
+			expected: 0,
+		},
+		"Last": {
+			entry:    &todo.Entry{ID: "new", Priority: todo.PriorityLow, CreatedAt: now.Add(time.Hour)},
+			expected: 3,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expected, todo.InsertPosition(entries, test.entry))
+		})
+	}
+
+	require.Equal(t, 0, todo.InsertPosition(nil, &todo.Entry{ID: "new"}))
+}
+
+func TestMoveID(t *testing.T) {
+	IDs := []string{"a", "b", "c", "d"}
+
+	tt := map[string]struct {
+		from     int
+		to       int
+		expected []string
+		fails    bool
+	}{
+		"Down": {
+			from:     0,
+			to:       2,
+			expected: []string{"b", "c", "a", "d"},
+		},
+		"Up": {
+			from:     3,
+			to:       1,
+			expected: []string{"a", "d", "b", "c"},
+		},
+		"Same": {
+			from:     1,
+			to:       1,
+			expected: []string{"a", "b", "c", "d"},
+		},
+		"ToPastEnd": {
+			from:     0,
+			to:       10,
+			expected: []string{"b", "c", "d", "a"},
+		},
+		"FromOutOfRange": {
+			from:  4,
+			to:    0,
+			fails: true,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			moved, err := todo.MoveID(IDs, test.from, test.to)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, moved)
+			require.Equal(t, []string{"a", "b", "c", "d"}, IDs)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Priority of an entry. The zero value is normal, so entries stored
+// before priorities were added keep the normal priority.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+	PriorityUrgent Priority = 2
+)
+
+var priorityNames = map[Priority]string{
+	PriorityLow:    "low",
+	PriorityNormal: "normal",
+	PriorityHigh:   "high",
+	PriorityUrgent: "urgent",
+}
+
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Priority(%d)", int(p))
+}
+
+// ParsePriority parses the priority name, e.g. "high".
+func ParsePriority(name string) (Priority, error) {
+	for priority, priorityName := range priorityNames {
+		if strings.EqualFold(name, priorityName) {
+			return priority, nil
+		}
+	}
+
+	return PriorityNormal, fmt.Errorf("unknown priority %s", name)
+}
+
+// SortEntries sorts the entries by priority, the most important first, and then
+// by due date, the earliest first. Entries without a due date go after the ones with it.
+func SortEntries(entries []*Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entryLess(entries[i], entries[j])
+	})
+}
+
+func entryLess(a, b *Entry) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+
+	if (a.DueDate == nil) != (b.DueDate == nil) {
+		return a.DueDate != nil
+	}
+
+	if a.DueDate != nil && !a.DueDate.Equal(*b.DueDate) {
+		return a.DueDate.Before(*b.DueDate)
+	}
+
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+
+	return a.ID < b.ID
+}
@@ -0,0 +1,65 @@
+package todo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePriority(t *testing.T) {
+	tt := map[string]struct {
+		input    string
+		expected todo.Priority
+		fails    bool
+	}{
+		"Low": {
+			input:    "low",
+			expected: todo.PriorityLow,
+		},
+		"UrgentUpperCase": {
+			input:    "URGENT",
+			expected: todo.PriorityUrgent,
+		},
+		"Unknown": {
+			input: "asap",
+			fails: true,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			priority, err := todo.ParsePriority(test.input)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, priority)
+		})
+	}
+}
+
+func TestSortEntries(t *testing.T) {
+	entries := []*todo.Entry{
+		{ID: "normal-no-due"},
+		{ID: "normal-due-late", DueDate: DatePtr(time.Date(2021, 11, 5, 9, 0, 0, 0, time.UTC))},
+		{ID: "low", Priority: todo.PriorityLow},
+		{ID: "normal-due-early", DueDate: DatePtr(time.Date(2021, 11, 3, 9, 0, 0, 0, time.UTC))},
+		{ID: "urgent", Priority: todo.PriorityUrgent},
+		{ID: "high", Priority: todo.PriorityHigh},
+	}
+
+	todo.SortEntries(entries)
+
+	IDs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		IDs = append(IDs, entry.ID)
+	}
+
+	require.Equal(t, []string{"urgent", "high", "normal-due-early", "normal-due-late", "normal-no-due", "low"}, IDs)
+}
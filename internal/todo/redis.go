@@ -1,10 +1,9 @@
 package todo
 
 import (
-	"bytes"
 	"context"
-	"encoding/gob"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,8 +13,71 @@ import (
 	"github.com/pkg/errors"
 )
 
-var redisExpirationTime = 30 * 24 * time.Hour
+var archiveExpirationTime = 90 * 24 * time.Hour
 
+const channelsKey = "todo:channels"
+
+// entrySchemaVersion is the version of the JSON, in which the entries are stored.
+const entrySchemaVersion = 1
+
+// STRING for storing entries of the default list as JSON with the schema version,
+// see redisutils.MarshalJSON
+// key: "todo:<channelID>:entries:<entryID>"
+//
+// STRING for storing entries of a named list
+// key: "todo:<channelID>:lists:<listName>:entries:<entryID>"
+//
+// SET with the IDs of the entries in the default list
+// key: "todo:<channelID>:index"
+//
+// SET with the IDs of the entries in a named list
+// key: "todo:<channelID>:lists:<listName>:index"
+//
+// SET with the IDs of the channels, which have entries
+// key: "todo:channels"
+//
+// ZSET with the order of the entries in the default list
+// key: "todo:<channelID>:order"
+// member: entry ID
+// score: position of the entry
+//
+// ZSET with the order of the entries in a named list
+// key: "todo:<channelID>:lists:<listName>:order"
+//
+// SET with the IDs of the entries with a tag in the default list
+// key: "todo:<channelID>:tags:<tag>"
+//
+// SET with the IDs of the entries with a tag in a named list
+// key: "todo:<channelID>:lists:<listName>:tags:<tag>"
+//
+// SET with the tags, which have a tag index in the default list
+// key: "todo:<channelID>:tags"
+//
+// SET with the tags, which have a tag index in a named list
+// key: "todo:<channelID>:lists:<listName>:tags"
+//
+// SET with the names of the named lists in the channel
+// key: "todo:<channelID>:lists"
+//
+// ZSET with the completed entries
+// key: "todo:<channelID>:archive"
+// member: entry ID
+// score: timestamp in epoch, when the entry was completed
+//
+// STRING for storing completed entries with their list
+// key: "todo:<channelID>:archive:<entryID>"
+//
+// STRING with the timestamp of the last daily notification
+// key: "todo:<channelID>:notificationTimestamp"
+//
+// STRING with the hash of the last daily notification content
+// key: "todo:<channelID>:notificationHash"
+//
+// STRING with the ID of the pinned board message
+// key: "todo:<channelID>:board"
+//
+// STRING with the expiry policy of the channel, see ExpiryPolicy.String
+// key: "todo:<channelID>:expiryPolicy"
 type RedisTodoStore struct {
 	redisClient *redis.Client
 }
@@ -26,11 +88,14 @@ func NewRedisTodoStore(client *redis.Client) *RedisTodoStore {
 	}
 }
 
-func (store *RedisTodoStore) GetEntry(ctx context.Context, channelID, entryID string) (*Entry, error) {
-	key := fmt.Sprintf("todo:%s:entries:%s", channelID, entryID)
+// GetEntry returns the entry or ErrEntryNotFound, if it does not exist.
+func (store *RedisTodoStore) GetEntry(ctx context.Context, channelID, listName, entryID string) (*Entry, error) {
+	key := entryKey(channelID, listName, entryID)
 
 	data, err := store.redisClient.Get(ctx, key).Bytes()
-	if err != nil {
+	if err == redis.Nil {
+		return nil, ErrEntryNotFound
+	} else if err != nil {
 		return nil, errors.Wrapf(err, "while getting key %s", key)
 	}
 
@@ -42,135 +107,1535 @@ func (store *RedisTodoStore) GetEntry(ctx context.Context, channelID, entryID st
 	return entry, nil
 }
 
-func (store *RedisTodoStore) ListEntries(ctx context.Context, channelID string) ([]string, error) {
-	keyPattern := fmt.Sprintf("todo:%s:entries:*", channelID)
+func (store *RedisTodoStore) ListEntries(ctx context.Context, channelID, listName string) ([]string, error) {
+	entries, err := store.indexedEntries(ctx, channelID, listName)
+	if err != nil {
+		return nil, err
+	}
+
+	var IDs []string
+	for ID := range entries {
+		IDs = append(IDs, ID)
+	}
+
+	return IDs, nil
+}
 
-	allKeys, err := redisutils.ScanKeys(ctx, store.redisClient, keyPattern)
+// GetEntries returns the entries of the list in their order. Entries, which are
+// not in the order, e.g. added before it was stored, follow sorted with SortEntries.
+func (store *RedisTodoStore) GetEntries(ctx context.Context, channelID, listName string) (*List, error) {
+	data, err := store.indexedEntries(ctx, channelID, listName)
 	if err != nil {
-		return nil, errors.Wrap(err, "while scanning all keys")
+		return nil, err
 	}
 
-	var allIDs []string
+	entries := make(map[string]*Entry, len(data))
+	for entryID, entryData := range data {
+		entry, err := store.unmarshalEntry(entryData)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while unmarshaling entry %s", entryID)
+		}
 
-	for _, key := range allKeys {
-		parts := strings.Split(key, ":")
-		ID := parts[len(parts)-1]
-		allIDs = append(allIDs, ID)
+		entries[entryID] = entry
 	}
 
-	return allIDs, nil
+	return store.orderedList(ctx, channelID, listName, entries)
 }
 
-func (store *RedisTodoStore) GetEntries(ctx context.Context, channelID string) (*List, error) {
-	IDs, err := store.ListEntries(ctx, channelID)
+// indexedEntries reads the entries in the index of the list with a single MGET and returns
+// their data by the entry ID. The expired entries are removed from the index.
+func (store *RedisTodoStore) indexedEntries(ctx context.Context, channelID, listName string) (map[string][]byte, error) {
+	key := entryIndexKey(channelID, listName)
+
+	IDs, err := store.redisClient.SMembers(ctx, key).Result()
 	if err != nil {
-		return nil, errors.Wrap(err, "while listing entry IDs")
+		return nil, errors.Wrapf(err, "while SMEMBERS key %s", key)
 	}
 
-	list := &List{
-		ChannelID: channelID,
-		Entries:   make([]*Entry, 0),
+	entries := make(map[string][]byte, len(IDs))
+	if len(IDs) == 0 {
+		return entries, nil
+	}
+
+	keys := make([]string, 0, len(IDs))
+	for _, ID := range IDs {
+		keys = append(keys, entryKey(channelID, listName, ID))
+	}
+
+	values, err := store.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "while MGET entries of %s", key)
+	}
+
+	var expired []interface{}
+	for idx, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			expired = append(expired, IDs[idx])
+			continue
+		}
+
+		entries[IDs[idx]] = []byte(data)
+	}
+
+	if len(expired) > 0 {
+		if err := store.redisClient.SRem(ctx, key, expired...).Err(); err != nil {
+			return nil, errors.Wrapf(err, "while SREM on key %s", key)
+		}
+	}
+
+	return entries, nil
+}
+
+// GetEntriesByTag returns the entries of the list with the tag in their order. Only the
+// entries in the tag index are read.
+func (store *RedisTodoStore) GetEntriesByTag(ctx context.Context, channelID, listName, tag string) (*List, error) {
+	key := tagKey(channelID, listName, NormalizeTag(tag))
+
+	IDs, err := store.redisClient.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "while SMEMBERS key %s", key)
 	}
 
+	entries := make(map[string]*Entry, len(IDs))
 	for _, entryID := range IDs {
-		entry, err := store.GetEntry(ctx, channelID, entryID)
-		if err != nil {
+		entry, err := store.GetEntry(ctx, channelID, listName, entryID)
+		if err == ErrEntryNotFound {
+			// the entry expired, so it is removed from the index
+			if err := store.redisClient.SRem(ctx, key, entryID).Err(); err != nil {
+				return nil, errors.Wrapf(err, "while SREM on key %s", key)
+			}
+			continue
+		} else if err != nil {
 			return nil, errors.Wrapf(err, "while getting entry %s", entryID)
 		}
 
-		list.Entries = append(list.Entries, entry)
+		entries[entryID] = entry
+	}
+
+	return store.orderedList(ctx, channelID, listName, entries)
+}
+
+// orderedList returns the list with the entries sorted by the order of the list.
+func (store *RedisTodoStore) orderedList(ctx context.Context, channelID, listName string, entries map[string]*Entry) (*List, error) {
+	key := orderKey(channelID, listName)
+
+	order, err := store.redisClient.ZRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "while ZRANGE on key %s", key)
 	}
 
-	return list, nil
+	return &List{
+		ChannelID: channelID,
+		Name:      listName,
+		Entries:   orderEntries(order, entries),
+	}, nil
 }
 
-func (store *RedisTodoStore) AddEntry(ctx context.Context, channelID string, entry *Entry) (string, error) {
+func (store *RedisTodoStore) AddEntry(ctx context.Context, channelID, listName string, entry *Entry) (string, error) {
 	UUID := uuid.New()
 	entry.ID = UUID.String()
 
-	key := fmt.Sprintf("todo:%s:entries:%s", channelID, entry.ID)
+	now := time.Now()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	entry.UpdatedAt = now
+
+	policy, err := store.GetExpiryPolicy(ctx, channelID)
+	if err != nil {
+		return "", err
+	}
+
+	key := entryKey(channelID, listName, entry.ID)
 	data, err := store.marshalEntry(entry)
 	if err != nil {
 		return "", errors.Wrap(err, "while marshaling entry")
 	}
 
-	if err := store.redisClient.Set(ctx, key, data, redisExpirationTime).Err(); err != nil {
-		return "", errors.Wrapf(err, "while SET on key %s", key)
+	// WATCH makes sure the order does not lose entries added, moved or removed concurrently
+	err = redisutils.WatchRetry(ctx, store.redisClient, func(tx *redis.Tx) error {
+		list, err := store.GetEntries(ctx, channelID, listName)
+		if err != nil {
+			return errors.Wrap(err, "while getting list")
+		}
+
+		order := insertID(entryIDs(list.Entries), InsertPosition(list.Entries, entry), entry.ID)
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			if err := p.Set(ctx, key, data, policy.EntryTTL()).Err(); err != nil {
+				return errors.Wrapf(err, "while SET on key %s", key)
+			}
+
+			if err := indexEntry(ctx, p, channelID, listName, entry.ID); err != nil {
+				return err
+			}
+
+			if err := indexTags(ctx, p, channelID, listName, entry.ID, nil, entry.Tags); err != nil {
+				return err
+			}
+
+			return writeOrder(ctx, p, orderKey(channelID, listName), order)
+		})
+
+		return err
+	}, entryIndexKey(channelID, listName), orderKey(channelID, listName))
+	if err != nil {
+		return "", errors.Wrap(err, "while executing TX pipeline")
 	}
 
 	return entry.ID, nil
 }
 
-func (store *RedisTodoStore) RemoveEntry(ctx context.Context, channelID, entryID string) error {
-	key := fmt.Sprintf("todo:%s:entries:%s", channelID, entryID)
+// UpdateEntry saves the changed entry. It keeps its position and restarts its expiration time.
+// Returns ErrEntryNotFound, if the entry does not exist.
+func (store *RedisTodoStore) UpdateEntry(ctx context.Context, channelID, listName string, entry *Entry) error {
+	policy, err := store.GetExpiryPolicy(ctx, channelID)
+	if err != nil {
+		return err
+	}
+
+	key := entryKey(channelID, listName, entry.ID)
 
-	if err := store.redisClient.Del(ctx, key).Err(); err != nil {
-		return errors.Wrapf(err, "while DEL key %s", key)
+	entry.UpdatedAt = time.Now()
+
+	data, err := store.marshalEntry(entry)
+	if err != nil {
+		return errors.Wrap(err, "while marshaling entry")
+	}
+
+	err = store.redisClient.Watch(ctx, func(tx *redis.Tx) error {
+		previousData, err := tx.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			return ErrEntryNotFound
+		} else if err != nil {
+			return errors.Wrapf(err, "while getting key %s", key)
+		}
+
+		previous, err := store.unmarshalEntry(previousData)
+		if err != nil {
+			return errors.Wrap(err, "while unmarshaling entry")
+		}
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			if err := p.Set(ctx, key, data, policy.EntryTTL()).Err(); err != nil {
+				return errors.Wrapf(err, "while SET on key %s", key)
+			}
+
+			return indexTags(ctx, p, channelID, listName, entry.ID, previous.Tags, entry.Tags)
+		})
+
+		return err
+	}, key)
+	if err == ErrEntryNotFound {
+		return err
+	} else if err != nil {
+		return errors.Wrap(err, "while executing TX pipeline")
 	}
 
 	return nil
 }
 
-func (store *RedisTodoStore) GetAllChannelsWithTodo(ctx context.Context) ([]string, error) {
-	keys, err := redisutils.ScanKeys(ctx, store.redisClient, "todo:*:entries:*")
+// MoveEntry moves the entry at the position from to the position to, both counted from zero.
+// Returns ErrEntryNotFound, if there is no entry at the position from.
+func (store *RedisTodoStore) MoveEntry(ctx context.Context, channelID, listName string, from, to int) (*Entry, error) {
+	var moved *Entry
+
+	err := redisutils.WatchRetry(ctx, store.redisClient, func(tx *redis.Tx) error {
+		list, err := store.GetEntries(ctx, channelID, listName)
+		if err != nil {
+			return errors.Wrap(err, "while getting list")
+		}
+
+		order, err := MoveID(entryIDs(list.Entries), from, to)
+		if err != nil {
+			return ErrEntryNotFound
+		}
+		moved = list.Entries[from]
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			return writeOrder(ctx, p, orderKey(channelID, listName), order)
+		})
+
+		return err
+	}, entryIndexKey(channelID, listName), orderKey(channelID, listName))
+	if err == ErrEntryNotFound {
+		return nil, err
+	} else if err != nil {
+		return nil, errors.Wrap(err, "while executing TX pipeline")
+	}
+
+	return moved, nil
+}
+
+// TransferEntry moves the entry to another list, also in another channel. The entry gets the
+// expiration time of the target channel. Returns ErrEntryNotFound, if the entry does not exist.
+func (store *RedisTodoStore) TransferEntry(ctx context.Context, channelID, listName, entryID, toChannelID, toListName string) (*Entry, error) {
+	if channelID == toChannelID && listName == toListName {
+		return store.GetEntry(ctx, channelID, listName, entryID)
+	}
+
+	key := entryKey(channelID, listName, entryID)
+	toKey := entryKey(toChannelID, toListName, entryID)
+
+	policy, err := store.GetExpiryPolicy(ctx, toChannelID)
 	if err != nil {
-		return nil, fmt.Errorf("while scanning keys: %w", err)
+		return nil, err
 	}
 
-	channelIDs := make([]string, 0)
-	for _, key := range keys {
-		els := strings.Split(key, ":")
-		if len(els) < 2 {
-			continue
+	var entry *Entry
+
+	// WATCH makes sure an entry completed or removed concurrently is not brought back
+	// and the orders of both lists do not lose concurrent changes
+	err = redisutils.WatchRetry(ctx, store.redisClient, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			return ErrEntryNotFound
+		} else if err != nil {
+			return errors.Wrapf(err, "while getting key %s", key)
+		}
+
+		entry, err = store.unmarshalEntry(data)
+		if err != nil {
+			return errors.Wrap(err, "while unmarshaling entry")
+		}
+
+		source, err := store.GetEntries(ctx, channelID, listName)
+		if err != nil {
+			return errors.Wrap(err, "while getting source list")
+		}
+
+		target, err := store.GetEntries(ctx, toChannelID, toListName)
+		if err != nil {
+			return errors.Wrap(err, "while getting target list")
+		}
+
+		sourceOrder := make([]string, 0, len(source.Entries))
+		for _, ID := range entryIDs(source.Entries) {
+			if ID != entryID {
+				sourceOrder = append(sourceOrder, ID)
+			}
+		}
+
+		targetOrder := insertID(entryIDs(target.Entries), InsertPosition(target.Entries, entry), entryID)
+
+		entry.UpdatedAt = time.Now()
+
+		data, err = store.marshalEntry(entry)
+		if err != nil {
+			return errors.Wrap(err, "while marshaling entry")
 		}
 
-		channelID := els[1]
-		channelIDs = append(channelIDs, channelID)
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			if err := p.Set(ctx, toKey, data, policy.EntryTTL()).Err(); err != nil {
+				return errors.Wrapf(err, "while SET on key %s", toKey)
+			}
+
+			if err := p.Del(ctx, key).Err(); err != nil {
+				return errors.Wrapf(err, "while DEL key %s", key)
+			}
+
+			if err := unindexEntry(ctx, p, channelID, listName, entryID); err != nil {
+				return err
+			}
+
+			if err := indexEntry(ctx, p, toChannelID, toListName, entryID); err != nil {
+				return err
+			}
+
+			if err := indexTags(ctx, p, channelID, listName, entryID, entry.Tags, nil); err != nil {
+				return err
+			}
+
+			if err := indexTags(ctx, p, toChannelID, toListName, entryID, nil, entry.Tags); err != nil {
+				return err
+			}
+
+			if err := writeOrder(ctx, p, orderKey(channelID, listName), sourceOrder); err != nil {
+				return err
+			}
+
+			return writeOrder(ctx, p, orderKey(toChannelID, toListName), targetOrder)
+		})
+
+		return err
+	}, key, entryIndexKey(channelID, listName), orderKey(channelID, listName),
+		entryIndexKey(toChannelID, toListName), orderKey(toChannelID, toListName))
+	if err == ErrEntryNotFound {
+		return nil, err
+	} else if err != nil {
+		return nil, errors.Wrap(err, "while executing TX pipeline")
 	}
 
-	return channelIDs, nil
+	return entry, nil
 }
 
-func (store *RedisTodoStore) GetLastTodoNotificationTimestamp(ctx context.Context, channelID string) (int64, error) {
-	key := fmt.Sprintf("todo:%s:notificationTimestamp", channelID)
-	timestamp, err := store.redisClient.Get(ctx, key).Int64()
+// TouchEntry marks the entry as updated now, which restarts its expiration time.
+// Returns ErrEntryNotFound, if the entry does not exist.
+func (store *RedisTodoStore) TouchEntry(ctx context.Context, channelID, listName, entryID string) (*Entry, error) {
+	policy, err := store.GetExpiryPolicy(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := entryKey(channelID, listName, entryID)
+
+	var entry *Entry
+
+	err = store.redisClient.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			return ErrEntryNotFound
+		} else if err != nil {
+			return errors.Wrapf(err, "while getting key %s", key)
+		}
+
+		entry, err = store.unmarshalEntry(data)
+		if err != nil {
+			return errors.Wrap(err, "while unmarshaling entry")
+		}
+
+		entry.UpdatedAt = time.Now()
+
+		data, err = store.marshalEntry(entry)
+		if err != nil {
+			return errors.Wrap(err, "while marshaling entry")
+		}
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			if err := p.Set(ctx, key, data, policy.EntryTTL()).Err(); err != nil {
+				return errors.Wrapf(err, "while SET on key %s", key)
+			}
+
+			return nil
+		})
+
+		return err
+	}, key)
+	if err == ErrEntryNotFound {
+		return nil, err
+	} else if err != nil {
+		return nil, errors.Wrap(err, "while executing TX pipeline")
+	}
+
+	return entry, nil
+}
+
+// GetExpiringEntries returns the entries in all lists of the channel, which expire
+// within the duration, the soonest first.
+func (store *RedisTodoStore) GetExpiringEntries(ctx context.Context, channelID string, within time.Duration) ([]*ExpiringEntry, error) {
+	policy, err := store.GetExpiryPolicy(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if policy.EntryTTL() == 0 {
+		return []*ExpiringEntry{}, nil
+	}
+
+	names, err := store.GetLists(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiring := make([]*ExpiringEntry, 0)
+
+	for _, name := range names {
+		list, err := store.GetEntries(ctx, channelID, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while getting list %s", name)
+		}
+
+		cmds := make([]*redis.DurationCmd, len(list.Entries))
+		_, err = store.redisClient.Pipelined(ctx, func(p redis.Pipeliner) error {
+			for idx, entry := range list.Entries {
+				cmds[idx] = p.PTTL(ctx, entryKey(channelID, name, entry.ID))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "while executing PTTL pipeline")
+		}
+
+		for idx, entry := range list.Entries {
+			// negative values mean, the key has no expiration or is already gone
+			ttl := cmds[idx].Val()
+			if ttl < 0 || ttl > within {
+				continue
+			}
+
+			expiring = append(expiring, &ExpiringEntry{
+				Entry:     entry,
+				ListName:  name,
+				ExpiresAt: now.Add(ttl),
+			})
+		}
+	}
+
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].ExpiresAt.Before(expiring[j].ExpiresAt)
+	})
+
+	return expiring, nil
+}
+
+// GetExpiryPolicy returns the expiry policy of the channel or DefaultExpiryPolicy, if it is not set.
+func (store *RedisTodoStore) GetExpiryPolicy(ctx context.Context, channelID string) (ExpiryPolicy, error) {
+	key := fmt.Sprintf("todo:%s:expiryPolicy", channelID)
+
+	value, err := store.redisClient.Get(ctx, key).Result()
 	if err == redis.Nil {
-		return 0, nil
+		return DefaultExpiryPolicy, nil
+	} else if err != nil {
+		return ExpiryPolicy{}, errors.Wrapf(err, "while getting key %s", key)
 	}
+
+	policy, err := ParseExpiryPolicy(value)
 	if err != nil {
-		return 0, err
+		return ExpiryPolicy{}, errors.Wrapf(err, "while parsing policy %s", value)
 	}
 
-	return timestamp, nil
+	return policy, nil
 }
 
-func (store *RedisTodoStore) SetLastTodoNotificationTimestamp(ctx context.Context, channelID string, timestamp int64) error {
-	key := fmt.Sprintf("todo:%s:notificationTimestamp", channelID)
-	if _, err := store.redisClient.Set(ctx, key, timestamp, 0).Result(); err != nil {
+// SetExpiryPolicy changes the expiry policy of the channel and applies it to the
+// current entries and the archive. The expiration time of the entries starts from now.
+func (store *RedisTodoStore) SetExpiryPolicy(ctx context.Context, channelID string, policy ExpiryPolicy) error {
+	key := fmt.Sprintf("todo:%s:expiryPolicy", channelID)
+	zsetKey := fmt.Sprintf("todo:%s:archive", channelID)
+
+	names, err := store.GetLists(ctx, channelID)
+	if err != nil {
+		return err
+	}
+
+	entryKeys := make([]string, 0)
+	for _, name := range names {
+		IDs, err := store.ListEntries(ctx, channelID, name)
+		if err != nil {
+			return errors.Wrap(err, "while listing entry IDs")
+		}
+
+		for _, ID := range IDs {
+			entryKeys = append(entryKeys, entryKey(channelID, name, ID))
+		}
+	}
+
+	archived, err := store.redisClient.ZRangeWithScores(ctx, zsetKey, 0, -1).Result()
+	if err != nil {
+		return errors.Wrapf(err, "while ZRANGE on key %s", zsetKey)
+	}
+
+	_, err = store.redisClient.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		if err := p.Set(ctx, key, policy.String(), 0).Err(); err != nil {
+			return errors.Wrapf(err, "while SET on key %s", key)
+		}
+
+		for _, entryKey := range entryKeys {
+			if err := applyTTL(ctx, p, entryKey, policy.EntryTTL()); err != nil {
+				return err
+			}
+		}
+
+		for _, z := range archived {
+			archiveKey := fmt.Sprintf("todo:%s:archive:%s", channelID, z.Member)
+			completedAt := time.Unix(int64(z.Score), 0)
+
+			if err := applyExpireAt(ctx, p, archiveKey, policy.ArchiveExpiration(completedAt)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "while executing TX pipeline")
+	}
+
+	return nil
+}
+
+func (store *RedisTodoStore) RemoveEntry(ctx context.Context, channelID, listName, entryID string) error {
+	key := entryKey(channelID, listName, entryID)
+	order := orderKey(channelID, listName)
+
+	var tags []string
+
+	entry, err := store.GetEntry(ctx, channelID, listName, entryID)
+	if err == nil {
+		tags = entry.Tags
+	} else if err != ErrEntryNotFound {
 		return err
 	}
 
+	_, err = store.redisClient.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		if err := p.Del(ctx, key).Err(); err != nil {
+			return errors.Wrapf(err, "while DEL key %s", key)
+		}
+
+		if err := unindexEntry(ctx, p, channelID, listName, entryID); err != nil {
+			return err
+		}
+
+		if err := indexTags(ctx, p, channelID, listName, entryID, tags, nil); err != nil {
+			return err
+		}
+
+		if err := p.ZRem(ctx, order, entryID).Err(); err != nil {
+			return errors.Wrapf(err, "while ZREM key %s", order)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "while executing TX pipeline")
+	}
+
 	return nil
 }
 
-func (store *RedisTodoStore) unmarshalEntry(data []byte) (*Entry, error) {
-	var (
-		entry = &Entry{}
-		buf   = bytes.NewBuffer(data)
-	)
+// CompleteEntry moves the entry to the archive. Returns ErrEntryNotFound,
+// if the entry does not exist, e.g. it was completed already.
+func (store *RedisTodoStore) CompleteEntry(ctx context.Context, channelID, listName, entryID, userID string) (*Entry, error) {
+	key := entryKey(channelID, listName, entryID)
+	archiveKey := fmt.Sprintf("todo:%s:archive:%s", channelID, entryID)
+	zsetKey := fmt.Sprintf("todo:%s:archive", channelID)
+
+	policy, err := store.GetExpiryPolicy(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *Entry
+
+	// WATCH makes sure the entry is archived only once, when completed concurrently
+	err = store.redisClient.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			return ErrEntryNotFound
+		} else if err != nil {
+			return errors.Wrapf(err, "while getting key %s", key)
+		}
+
+		entry, err = store.unmarshalEntry(data)
+		if err != nil {
+			return errors.Wrap(err, "while unmarshaling entry")
+		}
+
+		now := time.Now()
+		entry.CompletedAt = &now
+		entry.CompletedBy = userID
+		entry.UpdatedAt = now
 
-	if err := gob.NewDecoder(buf).Decode(entry); err != nil {
+		archiveData, err := store.marshalArchivedEntry(&ArchivedEntry{
+			Entry:    entry,
+			ListName: listName,
+		})
+		if err != nil {
+			return errors.Wrap(err, "while marshaling archived entry")
+		}
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			if err := p.Set(ctx, archiveKey, archiveData, 0).Err(); err != nil {
+				return errors.Wrapf(err, "while SET on key %s", archiveKey)
+			}
+
+			if err := applyExpireAt(ctx, p, archiveKey, policy.ArchiveExpiration(now)); err != nil {
+				return err
+			}
+
+			if err := p.ZAdd(ctx, zsetKey, &redis.Z{Member: entryID, Score: float64(now.Unix())}).Err(); err != nil {
+				return errors.Wrapf(err, "while ZADD on key %s", zsetKey)
+			}
+
+			if err := p.Del(ctx, key).Err(); err != nil {
+				return errors.Wrapf(err, "while DEL key %s", key)
+			}
+
+			if err := p.ZRem(ctx, orderKey(channelID, listName), entryID).Err(); err != nil {
+				return errors.Wrapf(err, "while ZREM entry %s", entryID)
+			}
+
+			if err := unindexEntry(ctx, p, channelID, listName, entryID); err != nil {
+				return err
+			}
+
+			return indexTags(ctx, p, channelID, listName, entryID, entry.Tags, nil)
+		})
+
+		return err
+	}, key)
+	if err == ErrEntryNotFound {
 		return nil, err
+	} else if err != nil {
+		return nil, errors.Wrap(err, "while executing TX pipeline")
 	}
 
 	return entry, nil
 }
 
-func (store *RedisTodoStore) marshalEntry(entry *Entry) ([]byte, error) {
-	buf := &bytes.Buffer{}
-	if err := gob.NewEncoder(buf).Encode(entry); err != nil {
+// ArchiveEntry stores the archived entry with its ID and completion time. It expires
+// according to the expiry policy of the channel, as if it was completed here.
+func (store *RedisTodoStore) ArchiveEntry(ctx context.Context, channelID string, archived *ArchivedEntry) error {
+	entryID := archived.Entry.ID
+	archiveKey := fmt.Sprintf("todo:%s:archive:%s", channelID, entryID)
+	zsetKey := fmt.Sprintf("todo:%s:archive", channelID)
+
+	if archived.Entry.CompletedAt == nil {
+		return errors.Errorf("archived entry %s has no completion time", entryID)
+	}
+	completedAt := *archived.Entry.CompletedAt
+
+	policy, err := store.GetExpiryPolicy(ctx, channelID)
+	if err != nil {
+		return err
+	}
+
+	data, err := store.marshalArchivedEntry(archived)
+	if err != nil {
+		return errors.Wrap(err, "while marshaling archived entry")
+	}
+
+	_, err = store.redisClient.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		if err := p.Set(ctx, archiveKey, data, 0).Err(); err != nil {
+			return errors.Wrapf(err, "while SET on key %s", archiveKey)
+		}
+
+		if err := applyExpireAt(ctx, p, archiveKey, policy.ArchiveExpiration(completedAt)); err != nil {
+			return err
+		}
+
+		if err := p.ZAdd(ctx, zsetKey, &redis.Z{Member: entryID, Score: float64(completedAt.Unix())}).Err(); err != nil {
+			return errors.Wrapf(err, "while ZADD on key %s", zsetKey)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "while executing TX pipeline")
+	}
+
+	return nil
+}
+
+// RestoreEntry moves a completed entry from the archive back to its list. If the list
+// was deleted, the entry goes to the default list. Returns ErrEntryNotFound, if the entry
+// is not in the archive.
+func (store *RedisTodoStore) RestoreEntry(ctx context.Context, channelID, entryID string) (*ArchivedEntry, error) {
+	archiveKey := fmt.Sprintf("todo:%s:archive:%s", channelID, entryID)
+	zsetKey := fmt.Sprintf("todo:%s:archive", channelID)
+
+	policy, err := store.GetExpiryPolicy(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	var archived *ArchivedEntry
+
+	// WATCH makes sure the entry is restored once and the order does not lose entries
+	// added, moved or removed concurrently
+	err = redisutils.WatchRetry(ctx, store.redisClient, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, archiveKey).Bytes()
+		if err == redis.Nil {
+			return ErrEntryNotFound
+		} else if err != nil {
+			return errors.Wrapf(err, "while getting key %s", archiveKey)
+		}
+
+		archived, err = store.unmarshalArchivedEntry(data)
+		if err != nil {
+			return errors.Wrap(err, "while unmarshaling archived entry")
+		}
+
+		exists, err := store.ListExists(ctx, channelID, archived.ListName)
+		if err != nil {
+			return err
+		} else if !exists {
+			archived.ListName = DefaultList
+		}
+
+		// the list is known only from the archived entry
+		if err := tx.Watch(ctx, entryIndexKey(channelID, archived.ListName), orderKey(channelID, archived.ListName)).Err(); err != nil {
+			return errors.Wrap(err, "while WATCH list keys")
+		}
+
+		entry := archived.Entry
+		entry.CompletedAt = nil
+		entry.CompletedBy = ""
+		entry.UpdatedAt = time.Now()
+
+		entryData, err := store.marshalEntry(entry)
+		if err != nil {
+			return errors.Wrap(err, "while marshaling entry")
+		}
+
+		list, err := store.GetEntries(ctx, channelID, archived.ListName)
+		if err != nil {
+			return errors.Wrap(err, "while getting list")
+		}
+
+		order := insertID(entryIDs(list.Entries), InsertPosition(list.Entries, entry), entryID)
+
+		key := entryKey(channelID, archived.ListName, entryID)
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			if err := p.Set(ctx, key, entryData, policy.EntryTTL()).Err(); err != nil {
+				return errors.Wrapf(err, "while SET on key %s", key)
+			}
+
+			if err := p.Del(ctx, archiveKey).Err(); err != nil {
+				return errors.Wrapf(err, "while DEL key %s", archiveKey)
+			}
+
+			if err := p.ZRem(ctx, zsetKey, entryID).Err(); err != nil {
+				return errors.Wrapf(err, "while ZREM key %s", zsetKey)
+			}
+
+			if err := indexEntry(ctx, p, channelID, archived.ListName, entryID); err != nil {
+				return err
+			}
+
+			if err := indexTags(ctx, p, channelID, archived.ListName, entryID, nil, entry.Tags); err != nil {
+				return err
+			}
+
+			return writeOrder(ctx, p, orderKey(channelID, archived.ListName), order)
+		})
+
+		return err
+	}, archiveKey)
+	if err == ErrEntryNotFound {
+		return nil, err
+	} else if err != nil {
+		return nil, errors.Wrap(err, "while executing TX pipeline")
+	}
+
+	return archived, nil
+}
+
+// GetArchivedEntries returns the entries completed in the time range, the latest first.
+func (store *RedisTodoStore) GetArchivedEntries(ctx context.Context, channelID string, from, to time.Time) ([]*ArchivedEntry, error) {
+	zsetKey := fmt.Sprintf("todo:%s:archive", channelID)
+
+	IDs, err := store.redisClient.ZRevRangeByScore(ctx, zsetKey, &redis.ZRangeBy{
+		Min: fmt.Sprintf("%d", from.Unix()),
+		Max: fmt.Sprintf("%d", to.Unix()),
+	}).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "while ZREVRANGEBYSCORE on key %s", zsetKey)
+	}
+
+	archived := make([]*ArchivedEntry, 0, len(IDs))
+	for _, ID := range IDs {
+		archiveKey := fmt.Sprintf("todo:%s:archive:%s", channelID, ID)
+
+		data, err := store.redisClient.Get(ctx, archiveKey).Bytes()
+		if err == redis.Nil {
+			// the entry expired from the archive, so it is removed from the index
+			if err := store.redisClient.ZRem(ctx, zsetKey, ID).Err(); err != nil {
+				return nil, errors.Wrapf(err, "while ZREM on key %s", zsetKey)
+			}
+			continue
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "while getting key %s", archiveKey)
+		}
+
+		entry, err := store.unmarshalArchivedEntry(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "while unmarshaling archived entry")
+		}
+
+		archived = append(archived, entry)
+	}
+
+	return archived, nil
+}
+
+// GetLists returns the names of all lists in the channel, the default list first.
+func (store *RedisTodoStore) GetLists(ctx context.Context, channelID string) ([]string, error) {
+	key := fmt.Sprintf("todo:%s:lists", channelID)
+
+	names, err := store.redisClient.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "while SMEMBERS key %s", key)
+	}
+
+	sort.Strings(names)
+
+	return append([]string{DefaultList}, names...), nil
+}
+
+// ListExists checks, if the list was created in the channel. The default list always exists.
+func (store *RedisTodoStore) ListExists(ctx context.Context, channelID, listName string) (bool, error) {
+	if listName == DefaultList {
+		return true, nil
+	}
+
+	key := fmt.Sprintf("todo:%s:lists", channelID)
+
+	exists, err := store.redisClient.SIsMember(ctx, key, listName).Result()
+	if err != nil {
+		return false, errors.Wrapf(err, "while SISMEMBER key %s", key)
+	}
+
+	return exists, nil
+}
+
+// CreateList adds a named list to the channel. Returns ErrListExists, if it already exists.
+func (store *RedisTodoStore) CreateList(ctx context.Context, channelID, listName string) error {
+	if listName == DefaultList {
+		return ErrListExists
+	}
+
+	key := fmt.Sprintf("todo:%s:lists", channelID)
+
+	added, err := store.redisClient.SAdd(ctx, key, listName).Result()
+	if err != nil {
+		return errors.Wrapf(err, "while SADD key %s", key)
+	}
+
+	if added == 0 {
+		return ErrListExists
+	}
+
+	return nil
+}
+
+// RenameList renames a named list and moves its entries.
+func (store *RedisTodoStore) RenameList(ctx context.Context, channelID, oldName, newName string) error {
+	if oldName == DefaultList || newName == DefaultList {
+		return ErrDefaultList
+	}
+
+	key := fmt.Sprintf("todo:%s:lists", channelID)
+
+	// the keys of the list are watched and renamed only when they still exist, e.g. an entry
+	// can expire before EXEC or a tag index be empty, and RENAME fails on missing keys
+	err := redisutils.WatchRetry(ctx, store.redisClient, func(tx *redis.Tx) error {
+		exists, err := store.ListExists(ctx, channelID, oldName)
+		if err != nil {
+			return err
+		} else if !exists {
+			return ErrListNotFound
+		}
+
+		exists, err = store.ListExists(ctx, channelID, newName)
+		if err != nil {
+			return err
+		} else if exists {
+			return ErrListExists
+		}
+
+		IDs, err := store.ListEntries(ctx, channelID, oldName)
+		if err != nil {
+			return errors.Wrap(err, "while listing entry IDs")
+		}
+
+		if err := tx.Watch(ctx, tagNamesKey(channelID, oldName)).Err(); err != nil {
+			return errors.Wrap(err, "while WATCH tag names")
+		}
+
+		tags, err := tx.SMembers(ctx, tagNamesKey(channelID, oldName)).Result()
+		if err != nil {
+			return errors.Wrap(err, "while getting tags")
+		}
+
+		candidates := [][2]string{
+			{orderKey(channelID, oldName), orderKey(channelID, newName)},
+			{entryIndexKey(channelID, oldName), entryIndexKey(channelID, newName)},
+			{tagNamesKey(channelID, oldName), tagNamesKey(channelID, newName)},
+		}
+
+		// RENAME keeps the expiration time of the entries
+		for _, ID := range IDs {
+			candidates = append(candidates, [2]string{entryKey(channelID, oldName, ID), entryKey(channelID, newName, ID)})
+		}
+
+		for _, tag := range tags {
+			candidates = append(candidates, [2]string{tagKey(channelID, oldName, tag), tagKey(channelID, newName, tag)})
+		}
+
+		oldKeys := make([]string, 0, len(candidates))
+		for _, keys := range candidates {
+			oldKeys = append(oldKeys, keys[0])
+		}
+
+		if err := tx.Watch(ctx, oldKeys...).Err(); err != nil {
+			return errors.Wrap(err, "while WATCH list keys")
+		}
+
+		var renamedKeys [][2]string
+		for _, keys := range candidates {
+			exists, err := tx.Exists(ctx, keys[0]).Result()
+			if err != nil {
+				return errors.Wrapf(err, "while EXISTS key %s", keys[0])
+			}
+
+			if exists > 0 {
+				renamedKeys = append(renamedKeys, keys)
+			}
+		}
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			for _, keys := range renamedKeys {
+				if err := p.Rename(ctx, keys[0], keys[1]).Err(); err != nil {
+					return errors.Wrapf(err, "while RENAME key %s", keys[0])
+				}
+			}
+
+			if err := p.SRem(ctx, key, oldName).Err(); err != nil {
+				return errors.Wrapf(err, "while SREM key %s", key)
+			}
+
+			if err := p.SAdd(ctx, key, newName).Err(); err != nil {
+				return errors.Wrapf(err, "while SADD key %s", key)
+			}
+
+			return nil
+		})
+
+		return err
+	}, key)
+	if err == ErrListNotFound || err == ErrListExists {
+		return err
+	} else if err != nil {
+		return errors.Wrap(err, "while executing TX pipeline")
+	}
+
+	return nil
+}
+
+// DeleteList removes a named list with all its entries.
+func (store *RedisTodoStore) DeleteList(ctx context.Context, channelID, listName string) error {
+	if listName == DefaultList {
+		return ErrDefaultList
+	}
+
+	key := fmt.Sprintf("todo:%s:lists", channelID)
+
+	exists, err := store.ListExists(ctx, channelID, listName)
+	if err != nil {
+		return err
+	} else if !exists {
+		return ErrListNotFound
+	}
+
+	IDs, err := store.ListEntries(ctx, channelID, listName)
+	if err != nil {
+		return errors.Wrap(err, "while listing entry IDs")
+	}
+
+	tags, err := store.redisClient.SMembers(ctx, tagNamesKey(channelID, listName)).Result()
+	if err != nil {
+		return errors.Wrap(err, "while getting tags")
+	}
+
+	_, err = store.redisClient.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		for _, ID := range IDs {
+			if err := p.Del(ctx, entryKey(channelID, listName, ID)).Err(); err != nil {
+				return errors.Wrapf(err, "while DEL entry %s", ID)
+			}
+		}
+
+		for _, tag := range tags {
+			key := tagKey(channelID, listName, tag)
+			if err := p.Del(ctx, key).Err(); err != nil {
+				return errors.Wrapf(err, "while DEL key %s", key)
+			}
+		}
+
+		if err := p.Del(ctx, orderKey(channelID, listName), entryIndexKey(channelID, listName), tagNamesKey(channelID, listName)).Err(); err != nil {
+			return errors.Wrapf(err, "while DEL order and indexes of list %s", listName)
+		}
+
+		if err := p.SRem(ctx, key, listName).Err(); err != nil {
+			return errors.Wrapf(err, "while SREM key %s", key)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "while executing TX pipeline")
+	}
+
+	return nil
+}
+
+// GetAllChannelsWithTodo returns the channels, which have any entries. The channels, whose
+// entries all expired, are removed from the index.
+func (store *RedisTodoStore) GetAllChannelsWithTodo(ctx context.Context) ([]string, error) {
+	channelIDs, err := store.redisClient.SMembers(ctx, channelsKey).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "while SMEMBERS key %s", channelsKey)
+	}
+
+	sort.Strings(channelIDs)
+
+	withTodo := make([]string, 0, len(channelIDs))
+	for _, channelID := range channelIDs {
+		hasEntries, err := store.hasEntries(ctx, channelID)
+		if err != nil {
+			return nil, err
+		}
+
+		if hasEntries {
+			withTodo = append(withTodo, channelID)
+			continue
+		}
+
+		if err := store.unindexChannel(ctx, channelID); err != nil {
+			return nil, err
+		}
+	}
+
+	return withTodo, nil
+}
+
+func (store *RedisTodoStore) hasEntries(ctx context.Context, channelID string) (bool, error) {
+	names, err := store.GetLists(ctx, channelID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, name := range names {
+		entries, err := store.indexedEntries(ctx, channelID, name)
+		if err != nil {
+			return false, err
+		}
+
+		if len(entries) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// unindexChannel removes the channel without entries from the channels index. The lists
+// of the channel and their indexes are watched between the check and the removal, so the
+// channel is kept, if an entry or a list is added in the meantime.
+func (store *RedisTodoStore) unindexChannel(ctx context.Context, channelID string) error {
+	listsKey := fmt.Sprintf("todo:%s:lists", channelID)
+
+	err := store.redisClient.Watch(ctx, func(tx *redis.Tx) error {
+		names, err := tx.SMembers(ctx, listsKey).Result()
+		if err != nil {
+			return errors.Wrapf(err, "while SMEMBERS key %s", listsKey)
+		}
+
+		indexKeys := []string{entryIndexKey(channelID, DefaultList)}
+		for _, name := range names {
+			indexKeys = append(indexKeys, entryIndexKey(channelID, name))
+		}
+
+		if err := tx.Watch(ctx, indexKeys...).Err(); err != nil {
+			return errors.Wrap(err, "while WATCH list indexes")
+		}
+
+		for _, key := range indexKeys {
+			count, err := tx.SCard(ctx, key).Result()
+			if err != nil {
+				return errors.Wrapf(err, "while SCARD key %s", key)
+			} else if count > 0 {
+				return nil
+			}
+		}
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			return p.SRem(ctx, channelsKey, channelID).Err()
+		})
+		return err
+	}, listsKey)
+	if err == redis.TxFailedErr {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "while removing channel %s from index", channelID)
+	}
+
+	return nil
+}
+
+// Reindex adds the entries stored before the indexes were kept to the index of their list
+// and their channels to the channels index. The tags of the tag indexes are added to the tags
+// of their list. It scans the whole keyspace, so it is meant to be run once. It returns
+// the number of entries.
+func (store *RedisTodoStore) Reindex(ctx context.Context) (int, error) {
+	keys, err := redisutils.ScanKeys(ctx, store.redisClient, "todo:*:entries:*")
+	if err != nil {
+		return 0, err
+	}
+
+	tagKeys, err := redisutils.ScanKeys(ctx, store.redisClient, "todo:*:tags:*")
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	_, err = store.redisClient.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, key := range keys {
+			channelID, listName, entryID, ok := parseEntryKey(key)
+			if !ok {
+				continue
+			}
+
+			if err := indexEntry(ctx, p, channelID, listName, entryID); err != nil {
+				return err
+			}
+			count++
+		}
+
+		for _, key := range tagKeys {
+			channelID, listName, tag, ok := parseTagKey(key)
+			if !ok {
+				continue
+			}
+
+			namesKey := tagNamesKey(channelID, listName)
+			if err := p.SAdd(ctx, namesKey, tag).Err(); err != nil {
+				return errors.Wrapf(err, "while SADD key %s", namesKey)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "while executing pipeline")
+	}
+
+	return count, nil
+}
+
+func (store *RedisTodoStore) GetLastTodoNotificationTimestamp(ctx context.Context, channelID string) (int64, error) {
+	key := fmt.Sprintf("todo:%s:notificationTimestamp", channelID)
+	timestamp, err := store.redisClient.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return timestamp, nil
+}
+
+func (store *RedisTodoStore) SetLastTodoNotificationTimestamp(ctx context.Context, channelID string, timestamp int64) error {
+	key := fmt.Sprintf("todo:%s:notificationTimestamp", channelID)
+	if _, err := store.redisClient.Set(ctx, key, timestamp, 0).Result(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (store *RedisTodoStore) GetLastTodoNotificationHash(ctx context.Context, channelID string) (string, error) {
+	key := fmt.Sprintf("todo:%s:notificationHash", channelID)
+	hash, err := store.redisClient.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return hash, nil
+}
+
+func (store *RedisTodoStore) SetLastTodoNotificationHash(ctx context.Context, channelID, hash string) error {
+	key := fmt.Sprintf("todo:%s:notificationHash", channelID)
+	if _, err := store.redisClient.Set(ctx, key, hash, 0).Result(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetBoardMessageID returns the ID of the board message in the channel or an empty string,
+// if there is no board.
+func (store *RedisTodoStore) GetBoardMessageID(ctx context.Context, channelID string) (string, error) {
+	key := fmt.Sprintf("todo:%s:board", channelID)
+
+	messageID, err := store.redisClient.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	} else if err != nil {
+		return "", errors.Wrapf(err, "while getting key %s", key)
+	}
+
+	return messageID, nil
+}
+
+func (store *RedisTodoStore) SetBoardMessageID(ctx context.Context, channelID, messageID string) error {
+	key := fmt.Sprintf("todo:%s:board", channelID)
+
+	if err := store.redisClient.Set(ctx, key, messageID, 0).Err(); err != nil {
+		return errors.Wrapf(err, "while SET on key %s", key)
+	}
+
+	return nil
+}
+
+// applyTTL sets the expiration time of the key or removes it, if the TTL is zero.
+func applyTTL(ctx context.Context, p redis.Pipeliner, key string, ttl time.Duration) error {
+	if ttl == 0 {
+		if err := p.Persist(ctx, key).Err(); err != nil {
+			return errors.Wrapf(err, "while PERSIST key %s", key)
+		}
+		return nil
+	}
+
+	if err := p.Expire(ctx, key, ttl).Err(); err != nil {
+		return errors.Wrapf(err, "while EXPIRE key %s", key)
+	}
+
+	return nil
+}
+
+// applyExpireAt sets the expiration time of the key or removes it, if it is nil.
+func applyExpireAt(ctx context.Context, p redis.Pipeliner, key string, expiration *time.Time) error {
+	if expiration == nil {
+		if err := p.Persist(ctx, key).Err(); err != nil {
+			return errors.Wrapf(err, "while PERSIST key %s", key)
+		}
+		return nil
+	}
+
+	if err := p.ExpireAt(ctx, key, *expiration).Err(); err != nil {
+		return errors.Wrapf(err, "while EXPIREAT key %s", key)
+	}
+
+	return nil
+}
+
+// entryKey returns the key of an entry. The default list keeps the keys
+// used before named lists were added.
+func entryKey(channelID, listName, entryID string) string {
+	if listName == DefaultList {
+		return fmt.Sprintf("todo:%s:entries:%s", channelID, entryID)
+	}
+
+	return fmt.Sprintf("todo:%s:lists:%s:entries:%s", channelID, listName, entryID)
+}
+
+// parseEntryKey returns the channel, the list and the entry ID from the key of an entry.
+// It returns false for other keys, e.g. "todo:<channelID>:lists:entries:order" of a list named "entries".
+func parseEntryKey(key string) (channelID, listName, entryID string, ok bool) {
+	parts := strings.Split(key, ":")
+
+	switch {
+	case len(parts) == 4 && parts[0] == "todo" && parts[2] == "entries":
+		return parts[1], DefaultList, parts[3], true
+	case len(parts) == 6 && parts[0] == "todo" && parts[2] == "lists" && parts[4] == "entries":
+		return parts[1], parts[3], parts[5], true
+	}
+
+	return "", "", "", false
+}
+
+// entryIndexKey returns the key of the SET with the IDs of the entries in the list.
+func entryIndexKey(channelID, listName string) string {
+	if listName == DefaultList {
+		return fmt.Sprintf("todo:%s:index", channelID)
+	}
+
+	return fmt.Sprintf("todo:%s:lists:%s:index", channelID, listName)
+}
+
+// indexEntry adds the entry to the index of the list and the channel to the channels index.
+func indexEntry(ctx context.Context, p redis.Pipeliner, channelID, listName, entryID string) error {
+	key := entryIndexKey(channelID, listName)
+
+	if err := p.SAdd(ctx, key, entryID).Err(); err != nil {
+		return errors.Wrapf(err, "while SADD on key %s", key)
+	}
+
+	if err := p.SAdd(ctx, channelsKey, channelID).Err(); err != nil {
+		return errors.Wrapf(err, "while SADD on key %s", channelsKey)
+	}
+
+	return nil
+}
+
+// unindexEntry removes the entry from the index of the list. The channel stays in the
+// channels index until GetAllChannelsWithTodo finds it empty.
+func unindexEntry(ctx context.Context, p redis.Pipeliner, channelID, listName, entryID string) error {
+	key := entryIndexKey(channelID, listName)
+
+	if err := p.SRem(ctx, key, entryID).Err(); err != nil {
+		return errors.Wrapf(err, "while SREM on key %s", key)
+	}
+
+	return nil
+}
+
+// orderKey returns the key of the order of the list.
+func orderKey(channelID, listName string) string {
+	if listName == DefaultList {
+		return fmt.Sprintf("todo:%s:order", channelID)
+	}
+
+	return fmt.Sprintf("todo:%s:lists:%s:order", channelID, listName)
+}
+
+// tagKey returns the key of the index of the entries with the tag in the list.
+func tagKey(channelID, listName, tag string) string {
+	if listName == DefaultList {
+		return fmt.Sprintf("todo:%s:tags:%s", channelID, tag)
+	}
+
+	return fmt.Sprintf("todo:%s:lists:%s:tags:%s", channelID, listName, tag)
+}
+
+// tagNamesKey returns the key of the SET with the tags of the tag indexes in the list.
+// The tags stay in it after their index is empty, the index key is removed by Redis then.
+func tagNamesKey(channelID, listName string) string {
+	if listName == DefaultList {
+		return fmt.Sprintf("todo:%s:tags", channelID)
+	}
+
+	return fmt.Sprintf("todo:%s:lists:%s:tags", channelID, listName)
+}
+
+// parseTagKey returns the channel, the list and the tag from the key of a tag index.
+func parseTagKey(key string) (channelID, listName, tag string, ok bool) {
+	parts := strings.Split(key, ":")
+
+	switch {
+	case len(parts) == 4 && parts[0] == "todo" && parts[2] == "tags":
+		return parts[1], DefaultList, parts[3], true
+	case len(parts) == 6 && parts[0] == "todo" && parts[2] == "lists" && parts[4] == "tags":
+		return parts[1], parts[3], parts[5], true
+	}
+
+	return "", "", "", false
+}
+
+// indexTags updates the tag index of the list after the tags of the entry changed.
+func indexTags(ctx context.Context, p redis.Pipeliner, channelID, listName, entryID string, previous, current []string) error {
+	for _, tag := range previous {
+		key := tagKey(channelID, listName, tag)
+		if err := p.SRem(ctx, key, entryID).Err(); err != nil {
+			return errors.Wrapf(err, "while SREM key %s", key)
+		}
+	}
+
+	for _, tag := range current {
+		key := tagKey(channelID, listName, tag)
+		if err := p.SAdd(ctx, key, entryID).Err(); err != nil {
+			return errors.Wrapf(err, "while SADD key %s", key)
+		}
+	}
+
+	if len(current) > 0 {
+		key := tagNamesKey(channelID, listName)
+
+		tags := make([]interface{}, 0, len(current))
+		for _, tag := range current {
+			tags = append(tags, tag)
+		}
+
+		if err := p.SAdd(ctx, key, tags...).Err(); err != nil {
+			return errors.Wrapf(err, "while SADD key %s", key)
+		}
+	}
+
+	return nil
+}
+
+// writeOrder replaces the order of a list with the IDs.
+func writeOrder(ctx context.Context, p redis.Pipeliner, key string, IDs []string) error {
+	if err := p.Del(ctx, key).Err(); err != nil {
+		return errors.Wrapf(err, "while DEL key %s", key)
+	}
+
+	if len(IDs) == 0 {
+		return nil
+	}
+
+	members := make([]*redis.Z, 0, len(IDs))
+	for idx, ID := range IDs {
+		members = append(members, &redis.Z{Member: ID, Score: float64(idx)})
+	}
+
+	if err := p.ZAdd(ctx, key, members...).Err(); err != nil {
+		return errors.Wrapf(err, "while ZADD on key %s", key)
+	}
+
+	return nil
+}
+
+func entryIDs(entries []*Entry) []string {
+	IDs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		IDs = append(IDs, entry.ID)
+	}
+
+	return IDs
+}
+
+func (store *RedisTodoStore) unmarshalArchivedEntry(data []byte) (*ArchivedEntry, error) {
+	entry := &ArchivedEntry{}
+	if _, err := redisutils.Unmarshal(data, entrySchemaVersion, entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (store *RedisTodoStore) marshalArchivedEntry(entry *ArchivedEntry) ([]byte, error) {
+	return redisutils.MarshalJSON(entrySchemaVersion, entry)
+}
+
+func (store *RedisTodoStore) unmarshalEntry(data []byte) (*Entry, error) {
+	entry := &Entry{}
+	if _, err := redisutils.Unmarshal(data, entrySchemaVersion, entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (store *RedisTodoStore) marshalEntry(entry *Entry) ([]byte, error) {
+	return redisutils.MarshalJSON(entrySchemaVersion, entry)
+}
+
+// MigrateToJSON rewrites the entries and the archived entries stored with gob as JSON. It returns
+// the number of rewritten keys and of keys skipped, because they kept changing during the rewrite.
+func (store *RedisTodoStore) MigrateToJSON(ctx context.Context) (int, int, error) {
+	count, skippedCount := 0, 0
+
+	for _, pattern := range []string{"todo:*:entries:*", "todo:*:archive:*"} {
+		rewritten, skipped, err := redisutils.RewriteKeys(ctx, store.redisClient, pattern, store.rewriteLegacyEntry)
+		count += rewritten
+		skippedCount += skipped
+		if err != nil {
+			return count, skippedCount, err
+		}
+	}
+
+	return count, skippedCount, nil
+}
+
+// rewriteLegacyEntry rewrites the keys of the entries and the archived entries. The patterns
+// match also other keys, e.g. of a list named "archive", so the key format is checked.
+func (store *RedisTodoStore) rewriteLegacyEntry(key string, data []byte) ([]byte, error) {
+	var (
+		parts = strings.Split(key, ":")
+		value interface{}
+	)
+
+	if len(parts) == 4 && parts[2] == "archive" {
+		value = &ArchivedEntry{}
+	} else if _, _, _, ok := parseEntryKey(key); ok {
+		value = &Entry{}
+	} else {
+		return nil, nil
+	}
+
+	legacy, err := redisutils.Unmarshal(data, entrySchemaVersion, value)
+	if err != nil || !legacy {
 		return nil, err
 	}
 
-	return buf.Bytes(), nil
+	return redisutils.MarshalJSON(entrySchemaVersion, value)
 }
@@ -0,0 +1,1025 @@
+package todo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/sqlutils"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	entryColumns = "id, text, due_date, priority, assignee_id, created_by, created_at, updated_at, subtasks, auto_complete, tags"
+
+	// liveEntry is the condition for the entries, which did not expire yet
+	liveEntry = "(expires_at IS NULL OR expires_at > ?)"
+)
+
+// SQLTodoStore keeps the todo lists in the tables:
+//
+// "todo_entries" with the entries of all lists, ordered by their position in the list
+//
+// "todo_entry_tags" with the tags of the entries
+//
+// "todo_lists" with the names of the named lists
+//
+// "todo_archive" with the completed entries and their list
+//
+// "todo_channels" with the digest state, the board message and the expiry policy of the channels
+//
+// The expired entries are skipped, when they are read, and removed by GetAllChannelsWithTodo,
+// which the Notifier calls periodically.
+type SQLTodoStore struct {
+	db *sqlutils.DB
+}
+
+func NewSQLTodoStore(db *sqlutils.DB) *SQLTodoStore {
+	return &SQLTodoStore{
+		db: db,
+	}
+}
+
+// GetEntry returns the entry or ErrEntryNotFound, if it does not exist.
+func (store *SQLTodoStore) GetEntry(ctx context.Context, channelID, listName, entryID string) (*Entry, error) {
+	return getEntrySQL(ctx, store.db, channelID, listName, entryID, time.Now())
+}
+
+func (store *SQLTodoStore) ListEntries(ctx context.Context, channelID, listName string) ([]string, error) {
+	rows, err := store.db.QueryContext(ctx, "SELECT id FROM todo_entries WHERE channel_id = ? AND list_name = ? AND "+liveEntry,
+		channelID, listName, time.Now().Unix())
+	if err != nil {
+		return nil, errors.Wrap(err, "while selecting entries")
+	}
+	defer rows.Close()
+
+	var IDs []string
+	for rows.Next() {
+		var ID string
+		if err := rows.Scan(&ID); err != nil {
+			return nil, errors.Wrap(err, "while scanning entry ID")
+		}
+		IDs = append(IDs, ID)
+	}
+
+	return IDs, rows.Err()
+}
+
+// GetEntries returns the entries of the list in their order.
+func (store *SQLTodoStore) GetEntries(ctx context.Context, channelID, listName string) (*List, error) {
+	return getListSQL(ctx, store.db, channelID, listName, time.Now())
+}
+
+// GetEntriesByTag returns the entries of the list with the tag in their order.
+func (store *SQLTodoStore) GetEntriesByTag(ctx context.Context, channelID, listName, tag string) (*List, error) {
+	rows, err := store.db.QueryContext(ctx, `SELECT e.id, e.text, e.due_date, e.priority, e.assignee_id, e.created_by, e.created_at, e.updated_at,
+		e.subtasks, e.auto_complete, e.tags
+		FROM todo_entries e JOIN todo_entry_tags t ON t.channel_id = e.channel_id AND t.list_name = e.list_name AND t.entry_id = e.id
+		WHERE e.channel_id = ? AND e.list_name = ? AND t.tag = ? AND (e.expires_at IS NULL OR e.expires_at > ?)
+		ORDER BY e.position`, channelID, listName, NormalizeTag(tag), time.Now().Unix())
+	if err != nil {
+		return nil, errors.Wrap(err, "while selecting entries by tag")
+	}
+	defer rows.Close()
+
+	entries, err := scanEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &List{
+		ChannelID: channelID,
+		Name:      listName,
+		Entries:   entries,
+	}, nil
+}
+
+func (store *SQLTodoStore) AddEntry(ctx context.Context, channelID, listName string, entry *Entry) (string, error) {
+	entry.ID = uuid.New().String()
+
+	now := time.Now()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	entry.UpdatedAt = now
+
+	err := store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		if err := lockChannelSQL(ctx, tx, store.db, channelID); err != nil {
+			return err
+		}
+
+		return insertEntrySQL(ctx, tx, channelID, listName, entry, now)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return entry.ID, nil
+}
+
+// UpdateEntry saves the changed entry. It keeps its position and restarts its expiration time.
+// Returns ErrEntryNotFound, if the entry does not exist.
+func (store *SQLTodoStore) UpdateEntry(ctx context.Context, channelID, listName string, entry *Entry) error {
+	now := time.Now()
+
+	return store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		if err := lockChannelSQL(ctx, tx, store.db, channelID); err != nil {
+			return err
+		}
+
+		if _, err := getEntrySQL(ctx, tx, channelID, listName, entry.ID, now); err != nil {
+			return err
+		}
+
+		entry.UpdatedAt = now
+		return updateEntrySQL(ctx, tx, channelID, listName, entry, now)
+	})
+}
+
+// MoveEntry moves the entry at the position from to the position to, both counted from zero.
+// Returns ErrEntryNotFound, if there is no entry at the position from.
+func (store *SQLTodoStore) MoveEntry(ctx context.Context, channelID, listName string, from, to int) (*Entry, error) {
+	var moved *Entry
+
+	err := store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		if err := lockChannelSQL(ctx, tx, store.db, channelID); err != nil {
+			return err
+		}
+
+		list, err := getListSQL(ctx, tx, channelID, listName, time.Now())
+		if err != nil {
+			return err
+		}
+
+		order, err := MoveID(entryIDs(list.Entries), from, to)
+		if err != nil {
+			return ErrEntryNotFound
+		}
+
+		moved = list.Entries[from]
+		return writeOrderSQL(ctx, tx, channelID, listName, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return moved, nil
+}
+
+// TransferEntry moves the entry to another list, also in another channel. The entry gets the
+// expiration time of the target channel. Returns ErrEntryNotFound, if the entry does not exist.
+func (store *SQLTodoStore) TransferEntry(ctx context.Context, channelID, listName, entryID, toChannelID, toListName string) (*Entry, error) {
+	var entry *Entry
+
+	err := store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		// the channels are locked in the same order by all transactions
+		channelIDs := []string{channelID, toChannelID}
+		sort.Strings(channelIDs)
+
+		for _, ID := range channelIDs {
+			if err := lockChannelSQL(ctx, tx, store.db, ID); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+
+		var err error
+		entry, err = getEntrySQL(ctx, tx, channelID, listName, entryID, now)
+		if err != nil {
+			return err
+		}
+
+		if channelID == toChannelID && listName == toListName {
+			return nil
+		}
+
+		if err := deleteEntrySQL(ctx, tx, channelID, listName, entryID); err != nil {
+			return err
+		}
+
+		entry.UpdatedAt = now
+		return insertEntrySQL(ctx, tx, toChannelID, toListName, entry, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// TouchEntry marks the entry as updated now, which restarts its expiration time.
+// Returns ErrEntryNotFound, if the entry does not exist.
+func (store *SQLTodoStore) TouchEntry(ctx context.Context, channelID, listName, entryID string) (*Entry, error) {
+	var entry *Entry
+
+	err := store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		now := time.Now()
+
+		var err error
+		entry, err = getEntrySQL(ctx, tx, channelID, listName, entryID, now)
+		if err != nil {
+			return err
+		}
+
+		policy, err := getExpiryPolicySQL(ctx, tx, channelID)
+		if err != nil {
+			return err
+		}
+
+		entry.UpdatedAt = now
+
+		_, err = tx.ExecContext(ctx, "UPDATE todo_entries SET updated_at = ?, expires_at = ? WHERE channel_id = ? AND list_name = ? AND id = ?",
+			now.Unix(), unixOrNil(expiresAt(now, policy.EntryTTL())), channelID, listName, entryID)
+		if err != nil {
+			return errors.Wrap(err, "while updating entry")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// GetExpiringEntries returns the entries in all lists of the channel, which expire
+// within the duration, the soonest first.
+func (store *SQLTodoStore) GetExpiringEntries(ctx context.Context, channelID string, within time.Duration) ([]*ExpiringEntry, error) {
+	now := time.Now()
+
+	rows, err := store.db.QueryContext(ctx, "SELECT "+entryColumns+`, list_name, expires_at FROM todo_entries
+		WHERE channel_id = ? AND expires_at > ? AND expires_at <= ? ORDER BY expires_at, position`,
+		channelID, now.Unix(), now.Add(within).Unix())
+	if err != nil {
+		return nil, errors.Wrap(err, "while selecting expiring entries")
+	}
+	defer rows.Close()
+
+	expiring := make([]*ExpiringEntry, 0)
+	for rows.Next() {
+		var (
+			listName  string
+			expiresAt int64
+		)
+
+		entry, err := scanEntry(rows, &listName, &expiresAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "while scanning entry")
+		}
+
+		expiring = append(expiring, &ExpiringEntry{
+			Entry:     entry,
+			ListName:  listName,
+			ExpiresAt: time.Unix(expiresAt, 0),
+		})
+	}
+
+	return expiring, rows.Err()
+}
+
+// GetExpiryPolicy returns the expiry policy of the channel or DefaultExpiryPolicy, if it is not set.
+func (store *SQLTodoStore) GetExpiryPolicy(ctx context.Context, channelID string) (ExpiryPolicy, error) {
+	return getExpiryPolicySQL(ctx, store.db, channelID)
+}
+
+// SetExpiryPolicy changes the expiry policy of the channel and applies it to the
+// current entries and the archive. The expiration time of the entries starts from now.
+func (store *SQLTodoStore) SetExpiryPolicy(ctx context.Context, channelID string, policy ExpiryPolicy) error {
+	now := time.Now()
+
+	return store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		if err := lockChannelSQL(ctx, tx, store.db, channelID); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, "UPDATE todo_channels SET expiry_policy = ? WHERE channel_id = ?", policy.String(), channelID)
+		if err != nil {
+			return errors.Wrap(err, "while updating expiry policy")
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE todo_entries SET expires_at = ? WHERE channel_id = ? AND "+liveEntry,
+			unixOrNil(expiresAt(now, policy.EntryTTL())), channelID, now.Unix())
+		if err != nil {
+			return errors.Wrap(err, "while updating expiration of entries")
+		}
+
+		rows, err := tx.QueryContext(ctx, "SELECT id, completed_at FROM todo_archive WHERE channel_id = ?", channelID)
+		if err != nil {
+			return errors.Wrap(err, "while selecting archive")
+		}
+
+		completedAt := make(map[string]int64)
+		for rows.Next() {
+			var (
+				ID        string
+				timestamp int64
+			)
+			if err := rows.Scan(&ID, &timestamp); err != nil {
+				rows.Close()
+				return errors.Wrap(err, "while scanning archived entry")
+			}
+			completedAt[ID] = timestamp
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return errors.Wrap(err, "while selecting archive")
+		}
+
+		for ID, timestamp := range completedAt {
+			_, err := tx.ExecContext(ctx, "UPDATE todo_archive SET expires_at = ? WHERE channel_id = ? AND id = ?",
+				unixOrNil(policy.ArchiveExpiration(time.Unix(timestamp, 0))), channelID, ID)
+			if err != nil {
+				return errors.Wrap(err, "while updating expiration of archived entry")
+			}
+		}
+
+		return nil
+	})
+}
+
+func (store *SQLTodoStore) RemoveEntry(ctx context.Context, channelID, listName, entryID string) error {
+	return store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		return deleteEntrySQL(ctx, tx, channelID, listName, entryID)
+	})
+}
+
+// CompleteEntry moves the entry to the archive. Returns ErrEntryNotFound,
+// if the entry does not exist, e.g. it was completed already.
+func (store *SQLTodoStore) CompleteEntry(ctx context.Context, channelID, listName, entryID, userID string) (*Entry, error) {
+	var entry *Entry
+
+	err := store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		if err := lockChannelSQL(ctx, tx, store.db, channelID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		var err error
+		entry, err = getEntrySQL(ctx, tx, channelID, listName, entryID, now)
+		if err != nil {
+			return err
+		}
+
+		policy, err := getExpiryPolicySQL(ctx, tx, channelID)
+		if err != nil {
+			return err
+		}
+
+		entry.CompletedAt = &now
+		entry.CompletedBy = userID
+		entry.UpdatedAt = now
+
+		if err := deleteEntrySQL(ctx, tx, channelID, listName, entryID); err != nil {
+			return err
+		}
+
+		// an expired copy could be left from the previous completion
+		if _, err := tx.ExecContext(ctx, "DELETE FROM todo_archive WHERE channel_id = ? AND id = ?", channelID, entryID); err != nil {
+			return errors.Wrap(err, "while deleting archived entry")
+		}
+
+		values, err := entryValues(entry)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO todo_archive (channel_id, list_name, "+entryColumns+`, completed_at, completed_by, expires_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			append(append([]interface{}{channelID, listName}, values...), now.Unix(), userID, unixOrNil(policy.ArchiveExpiration(now)))...)
+		if err != nil {
+			return errors.Wrap(err, "while inserting archived entry")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// ArchiveEntry stores the archived entry with its ID and completion time.
+func (store *SQLTodoStore) ArchiveEntry(ctx context.Context, channelID string, archived *ArchivedEntry) error {
+	entry := archived.Entry
+	if entry.CompletedAt == nil {
+		return errors.Errorf("archived entry %s has no completion time", entry.ID)
+	}
+
+	return store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		policy, err := getExpiryPolicySQL(ctx, tx, channelID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM todo_archive WHERE channel_id = ? AND id = ?", channelID, entry.ID); err != nil {
+			return errors.Wrap(err, "while deleting archived entry")
+		}
+
+		values, err := entryValues(entry)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO todo_archive (channel_id, list_name, "+entryColumns+`, completed_at, completed_by, expires_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			append(append([]interface{}{channelID, archived.ListName}, values...),
+				entry.CompletedAt.Unix(), entry.CompletedBy, unixOrNil(policy.ArchiveExpiration(*entry.CompletedAt)))...)
+		if err != nil {
+			return errors.Wrap(err, "while inserting archived entry")
+		}
+
+		return nil
+	})
+}
+
+// RestoreEntry moves a completed entry from the archive back to its list. If the list
+// was deleted, the entry goes to the default list. Returns ErrEntryNotFound, if the entry
+// is not in the archive.
+func (store *SQLTodoStore) RestoreEntry(ctx context.Context, channelID, entryID string) (*ArchivedEntry, error) {
+	var archived *ArchivedEntry
+
+	err := store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		if err := lockChannelSQL(ctx, tx, store.db, channelID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		row := tx.QueryRowContext(ctx, "SELECT "+entryColumns+", list_name FROM todo_archive WHERE channel_id = ? AND id = ? AND "+liveEntry,
+			channelID, entryID, now.Unix())
+
+		archived = &ArchivedEntry{}
+
+		var err error
+		archived.Entry, err = scanEntry(row, &archived.ListName)
+		if err == sql.ErrNoRows {
+			return ErrEntryNotFound
+		} else if err != nil {
+			return errors.Wrap(err, "while selecting archived entry")
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM todo_archive WHERE channel_id = ? AND id = ?", channelID, entryID); err != nil {
+			return errors.Wrap(err, "while deleting archived entry")
+		}
+
+		exists, err := listExistsSQL(ctx, tx, channelID, archived.ListName)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			archived.ListName = DefaultList
+		}
+
+		archived.Entry.UpdatedAt = now
+		return insertEntrySQL(ctx, tx, channelID, archived.ListName, archived.Entry, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return archived, nil
+}
+
+// GetArchivedEntries returns the entries completed in the time range, the latest first.
+func (store *SQLTodoStore) GetArchivedEntries(ctx context.Context, channelID string, from, to time.Time) ([]*ArchivedEntry, error) {
+	rows, err := store.db.QueryContext(ctx, "SELECT "+entryColumns+", list_name, completed_at, completed_by FROM todo_archive "+
+		"WHERE channel_id = ? AND completed_at >= ? AND completed_at <= ? AND "+liveEntry+" ORDER BY completed_at DESC",
+		channelID, from.Unix(), to.Unix(), time.Now().Unix())
+	if err != nil {
+		return nil, errors.Wrap(err, "while selecting archive")
+	}
+	defer rows.Close()
+
+	archived := make([]*ArchivedEntry, 0)
+	for rows.Next() {
+		var (
+			archivedEntry = &ArchivedEntry{}
+			completedAt   int64
+			completedBy   string
+		)
+
+		archivedEntry.Entry, err = scanEntry(rows, &archivedEntry.ListName, &completedAt, &completedBy)
+		if err != nil {
+			return nil, errors.Wrap(err, "while scanning archived entry")
+		}
+
+		completed := time.Unix(completedAt, 0)
+		archivedEntry.Entry.CompletedAt = &completed
+		archivedEntry.Entry.CompletedBy = completedBy
+
+		archived = append(archived, archivedEntry)
+	}
+
+	return archived, rows.Err()
+}
+
+// GetLists returns the names of all lists in the channel, the default list first.
+func (store *SQLTodoStore) GetLists(ctx context.Context, channelID string) ([]string, error) {
+	rows, err := store.db.QueryContext(ctx, "SELECT name FROM todo_lists WHERE channel_id = ? ORDER BY name", channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while selecting lists")
+	}
+	defer rows.Close()
+
+	names := []string{DefaultList}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, errors.Wrap(err, "while scanning list name")
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+// ListExists checks, if the list was created in the channel. The default list always exists.
+func (store *SQLTodoStore) ListExists(ctx context.Context, channelID, listName string) (bool, error) {
+	return listExistsSQL(ctx, store.db, channelID, listName)
+}
+
+// CreateList adds a named list to the channel. Returns ErrListExists, if it already exists.
+func (store *SQLTodoStore) CreateList(ctx context.Context, channelID, listName string) error {
+	if listName == DefaultList {
+		return ErrListExists
+	}
+
+	result, err := store.db.ExecContext(ctx, "INSERT INTO todo_lists (channel_id, name) VALUES (?, ?) ON CONFLICT (channel_id, name) DO NOTHING",
+		channelID, listName)
+	if err != nil {
+		return errors.Wrap(err, "while inserting list")
+	}
+
+	if created, err := result.RowsAffected(); err != nil {
+		return errors.Wrap(err, "while getting inserted rows")
+	} else if created == 0 {
+		return ErrListExists
+	}
+
+	return nil
+}
+
+// RenameList renames a named list and moves its entries.
+func (store *SQLTodoStore) RenameList(ctx context.Context, channelID, oldName, newName string) error {
+	if oldName == DefaultList || newName == DefaultList {
+		return ErrDefaultList
+	}
+
+	return store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		if err := lockChannelSQL(ctx, tx, store.db, channelID); err != nil {
+			return err
+		}
+
+		if exists, err := listExistsSQL(ctx, tx, channelID, oldName); err != nil {
+			return err
+		} else if !exists {
+			return ErrListNotFound
+		}
+
+		if exists, err := listExistsSQL(ctx, tx, channelID, newName); err != nil {
+			return err
+		} else if exists {
+			return ErrListExists
+		}
+
+		for _, query := range []string{
+			"UPDATE todo_lists SET name = ? WHERE channel_id = ? AND name = ?",
+			"UPDATE todo_entries SET list_name = ? WHERE channel_id = ? AND list_name = ?",
+			"UPDATE todo_entry_tags SET list_name = ? WHERE channel_id = ? AND list_name = ?",
+		} {
+			if _, err := tx.ExecContext(ctx, query, newName, channelID, oldName); err != nil {
+				return errors.Wrap(err, "while renaming list")
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeleteList removes a named list with all its entries.
+func (store *SQLTodoStore) DeleteList(ctx context.Context, channelID, listName string) error {
+	if listName == DefaultList {
+		return ErrDefaultList
+	}
+
+	return store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		result, err := tx.ExecContext(ctx, "DELETE FROM todo_lists WHERE channel_id = ? AND name = ?", channelID, listName)
+		if err != nil {
+			return errors.Wrap(err, "while deleting list")
+		}
+
+		if deleted, err := result.RowsAffected(); err != nil {
+			return errors.Wrap(err, "while getting deleted rows")
+		} else if deleted == 0 {
+			return ErrListNotFound
+		}
+
+		for _, table := range []string{"todo_entries", "todo_entry_tags"} {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE channel_id = ? AND list_name = ?", channelID, listName); err != nil {
+				return errors.Wrapf(err, "while deleting from %s", table)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetAllChannelsWithTodo returns the channels, which have any entries. The expired entries
+// are removed before.
+func (store *SQLTodoStore) GetAllChannelsWithTodo(ctx context.Context) ([]string, error) {
+	if err := store.removeExpired(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := store.db.QueryContext(ctx, "SELECT DISTINCT channel_id FROM todo_entries ORDER BY channel_id")
+	if err != nil {
+		return nil, errors.Wrap(err, "while selecting channels")
+	}
+	defer rows.Close()
+
+	channelIDs := make([]string, 0)
+	for rows.Next() {
+		var channelID string
+		if err := rows.Scan(&channelID); err != nil {
+			return nil, errors.Wrap(err, "while scanning channel ID")
+		}
+		channelIDs = append(channelIDs, channelID)
+	}
+
+	return channelIDs, rows.Err()
+}
+
+func (store *SQLTodoStore) GetLastTodoNotificationTimestamp(ctx context.Context, channelID string) (int64, error) {
+	var timestamp int64
+	if err := store.getChannelValue(ctx, channelID, "notification_timestamp", &timestamp); err != nil {
+		return 0, err
+	}
+
+	return timestamp, nil
+}
+
+func (store *SQLTodoStore) SetLastTodoNotificationTimestamp(ctx context.Context, channelID string, timestamp int64) error {
+	return store.setChannelValue(ctx, channelID, "notification_timestamp", timestamp)
+}
+
+func (store *SQLTodoStore) GetLastTodoNotificationHash(ctx context.Context, channelID string) (string, error) {
+	var hash string
+	if err := store.getChannelValue(ctx, channelID, "notification_hash", &hash); err != nil {
+		return "", err
+	}
+
+	return hash, nil
+}
+
+func (store *SQLTodoStore) SetLastTodoNotificationHash(ctx context.Context, channelID, hash string) error {
+	return store.setChannelValue(ctx, channelID, "notification_hash", hash)
+}
+
+// GetBoardMessageID returns the ID of the board message in the channel or an empty string,
+// if there is no board.
+func (store *SQLTodoStore) GetBoardMessageID(ctx context.Context, channelID string) (string, error) {
+	var messageID string
+	if err := store.getChannelValue(ctx, channelID, "board_message_id", &messageID); err != nil {
+		return "", err
+	}
+
+	return messageID, nil
+}
+
+func (store *SQLTodoStore) SetBoardMessageID(ctx context.Context, channelID, messageID string) error {
+	return store.setChannelValue(ctx, channelID, "board_message_id", messageID)
+}
+
+// getChannelValue scans the column of todo_channels. The value is not changed, if the channel has no row.
+func (store *SQLTodoStore) getChannelValue(ctx context.Context, channelID, column string, value interface{}) error {
+	err := store.db.QueryRowContext(ctx, "SELECT "+column+" FROM todo_channels WHERE channel_id = ?", channelID).Scan(value)
+	if err != nil && err != sql.ErrNoRows {
+		return errors.Wrapf(err, "while selecting %s", column)
+	}
+
+	return nil
+}
+
+func (store *SQLTodoStore) setChannelValue(ctx context.Context, channelID, column string, value interface{}) error {
+	_, err := store.db.ExecContext(ctx, "INSERT INTO todo_channels (channel_id, "+column+") VALUES (?, ?) "+
+		"ON CONFLICT (channel_id) DO UPDATE SET "+column+" = excluded."+column, channelID, value)
+	if err != nil {
+		return errors.Wrapf(err, "while setting %s", column)
+	}
+
+	return nil
+}
+
+func (store *SQLTodoStore) removeExpired(ctx context.Context) error {
+	now := time.Now().Unix()
+
+	return store.db.InTx(ctx, func(tx *sqlutils.Tx) error {
+		for _, query := range []string{
+			"DELETE FROM todo_entries WHERE expires_at <= ?",
+			"DELETE FROM todo_archive WHERE expires_at <= ?",
+		} {
+			if _, err := tx.ExecContext(ctx, query, now); err != nil {
+				return errors.Wrap(err, "while deleting expired entries")
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, `DELETE FROM todo_entry_tags WHERE NOT EXISTS (SELECT 1 FROM todo_entries e
+			WHERE e.channel_id = todo_entry_tags.channel_id AND e.list_name = todo_entry_tags.list_name AND e.id = todo_entry_tags.entry_id)`)
+		if err != nil {
+			return errors.Wrap(err, "while deleting tags of expired entries")
+		}
+
+		return nil
+	})
+}
+
+// lockChannelSQL makes the transaction the only one changing the lists of the channel until it ends.
+func lockChannelSQL(ctx context.Context, tx *sqlutils.Tx, db *sqlutils.DB, channelID string) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO todo_channels (channel_id) VALUES (?) ON CONFLICT (channel_id) DO NOTHING", channelID)
+	if err != nil {
+		return errors.Wrap(err, "while inserting channel")
+	}
+
+	var ID string
+	if err := tx.QueryRowContext(ctx, "SELECT channel_id FROM todo_channels WHERE channel_id = ?"+db.ForUpdate(), channelID).Scan(&ID); err != nil {
+		return errors.Wrap(err, "while locking channel")
+	}
+
+	return nil
+}
+
+func getExpiryPolicySQL(ctx context.Context, q sqlutils.Querier, channelID string) (ExpiryPolicy, error) {
+	var value string
+
+	err := q.QueryRowContext(ctx, "SELECT expiry_policy FROM todo_channels WHERE channel_id = ?", channelID).Scan(&value)
+	if err == sql.ErrNoRows || (err == nil && value == "") {
+		return DefaultExpiryPolicy, nil
+	} else if err != nil {
+		return ExpiryPolicy{}, errors.Wrap(err, "while selecting expiry policy")
+	}
+
+	policy, err := ParseExpiryPolicy(value)
+	if err != nil {
+		return ExpiryPolicy{}, errors.Wrapf(err, "while parsing expiry policy %s", value)
+	}
+
+	return policy, nil
+}
+
+func listExistsSQL(ctx context.Context, q sqlutils.Querier, channelID, listName string) (bool, error) {
+	if listName == DefaultList {
+		return true, nil
+	}
+
+	var count int
+	err := q.QueryRowContext(ctx, "SELECT COUNT(*) FROM todo_lists WHERE channel_id = ? AND name = ?", channelID, listName).Scan(&count)
+	if err != nil {
+		return false, errors.Wrap(err, "while selecting list")
+	}
+
+	return count > 0, nil
+}
+
+func getEntrySQL(ctx context.Context, q sqlutils.Querier, channelID, listName, entryID string, now time.Time) (*Entry, error) {
+	row := q.QueryRowContext(ctx, "SELECT "+entryColumns+" FROM todo_entries WHERE channel_id = ? AND list_name = ? AND id = ? AND "+liveEntry,
+		channelID, listName, entryID, now.Unix())
+
+	entry, err := scanEntry(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrEntryNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "while selecting entry")
+	}
+
+	return entry, nil
+}
+
+func getListSQL(ctx context.Context, q sqlutils.Querier, channelID, listName string, now time.Time) (*List, error) {
+	rows, err := q.QueryContext(ctx, "SELECT "+entryColumns+" FROM todo_entries WHERE channel_id = ? AND list_name = ? AND "+liveEntry+" ORDER BY position",
+		channelID, listName, now.Unix())
+	if err != nil {
+		return nil, errors.Wrap(err, "while selecting entries")
+	}
+	defer rows.Close()
+
+	entries, err := scanEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &List{
+		ChannelID: channelID,
+		Name:      listName,
+		Entries:   entries,
+	}, nil
+}
+
+// insertEntrySQL adds the entry to the list at InsertPosition, with the expiration time of the channel.
+func insertEntrySQL(ctx context.Context, tx *sqlutils.Tx, channelID, listName string, entry *Entry, now time.Time) error {
+	policy, err := getExpiryPolicySQL(ctx, tx, channelID)
+	if err != nil {
+		return err
+	}
+
+	list, err := getListSQL(ctx, tx, channelID, listName, now)
+	if err != nil {
+		return err
+	}
+
+	values, err := entryValues(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO todo_entries (channel_id, list_name, position, expires_at, "+entryColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		append([]interface{}{channelID, listName, len(list.Entries), unixOrNil(expiresAt(now, policy.EntryTTL()))}, values...)...)
+	if err != nil {
+		return errors.Wrap(err, "while inserting entry")
+	}
+
+	if err := writeTagsSQL(ctx, tx, channelID, listName, entry); err != nil {
+		return err
+	}
+
+	order := insertID(entryIDs(list.Entries), InsertPosition(list.Entries, entry), entry.ID)
+	return writeOrderSQL(ctx, tx, channelID, listName, order)
+}
+
+func updateEntrySQL(ctx context.Context, tx *sqlutils.Tx, channelID, listName string, entry *Entry, now time.Time) error {
+	policy, err := getExpiryPolicySQL(ctx, tx, channelID)
+	if err != nil {
+		return err
+	}
+
+	values, err := entryValues(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE todo_entries SET text = ?, due_date = ?, priority = ?, assignee_id = ?, created_by = ?,
+		created_at = ?, updated_at = ?, subtasks = ?, auto_complete = ?, tags = ?, expires_at = ?
+		WHERE channel_id = ? AND list_name = ? AND id = ?`,
+		append(values[1:], unixOrNil(expiresAt(now, policy.EntryTTL())), channelID, listName, entry.ID)...)
+	if err != nil {
+		return errors.Wrap(err, "while updating entry")
+	}
+
+	return writeTagsSQL(ctx, tx, channelID, listName, entry)
+}
+
+func deleteEntrySQL(ctx context.Context, tx *sqlutils.Tx, channelID, listName, entryID string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM todo_entries WHERE channel_id = ? AND list_name = ? AND id = ?", channelID, listName, entryID)
+	if err != nil {
+		return errors.Wrap(err, "while deleting entry")
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM todo_entry_tags WHERE channel_id = ? AND list_name = ? AND entry_id = ?", channelID, listName, entryID)
+	if err != nil {
+		return errors.Wrap(err, "while deleting tags")
+	}
+
+	return nil
+}
+
+// writeTagsSQL replaces the tags of the entry in the tag index.
+func writeTagsSQL(ctx context.Context, tx *sqlutils.Tx, channelID, listName string, entry *Entry) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM todo_entry_tags WHERE channel_id = ? AND list_name = ? AND entry_id = ?", channelID, listName, entry.ID)
+	if err != nil {
+		return errors.Wrap(err, "while deleting tags")
+	}
+
+	for _, tag := range MergeTags(entry.Tags, nil) {
+		_, err := tx.ExecContext(ctx, "INSERT INTO todo_entry_tags (channel_id, list_name, entry_id, tag) VALUES (?, ?, ?, ?)",
+			channelID, listName, entry.ID, tag)
+		if err != nil {
+			return errors.Wrapf(err, "while inserting tag %s", tag)
+		}
+	}
+
+	return nil
+}
+
+// writeOrderSQL sets the positions of the entries to their index in the IDs.
+func writeOrderSQL(ctx context.Context, tx *sqlutils.Tx, channelID, listName string, IDs []string) error {
+	for idx, ID := range IDs {
+		_, err := tx.ExecContext(ctx, "UPDATE todo_entries SET position = ? WHERE channel_id = ? AND list_name = ? AND id = ?",
+			idx, channelID, listName, ID)
+		if err != nil {
+			return errors.Wrap(err, "while updating position")
+		}
+	}
+
+	return nil
+}
+
+// entryValues returns the values of the entry in the order of entryColumns.
+func entryValues(entry *Entry) ([]interface{}, error) {
+	subtasks, err := json.Marshal(entry.Subtasks)
+	if err != nil {
+		return nil, errors.Wrap(err, "while marshaling subtasks")
+	}
+
+	tags, err := json.Marshal(entry.Tags)
+	if err != nil {
+		return nil, errors.Wrap(err, "while marshaling tags")
+	}
+
+	return []interface{}{
+		entry.ID,
+		entry.Text,
+		unixOrNil(entry.DueDate),
+		int(entry.Priority),
+		entry.AssigneeID,
+		entry.CreatedBy,
+		entry.CreatedAt.Unix(),
+		entry.UpdatedAt.Unix(),
+		string(subtasks),
+		entry.AutoComplete,
+		string(tags),
+	}, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEntry scans the entryColumns and then the extra columns into dest.
+func scanEntry(row scanner, dest ...interface{}) (*Entry, error) {
+	var (
+		entry     = &Entry{}
+		dueDate   sql.NullInt64
+		priority  int
+		createdAt int64
+		updatedAt int64
+		subtasks  string
+		tags      string
+	)
+
+	err := row.Scan(append([]interface{}{
+		&entry.ID,
+		&entry.Text,
+		&dueDate,
+		&priority,
+		&entry.AssigneeID,
+		&entry.CreatedBy,
+		&createdAt,
+		&updatedAt,
+		&subtasks,
+		&entry.AutoComplete,
+		&tags,
+	}, dest...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if dueDate.Valid {
+		date := time.Unix(dueDate.Int64, 0)
+		entry.DueDate = &date
+	}
+
+	entry.Priority = Priority(priority)
+	entry.CreatedAt = time.Unix(createdAt, 0)
+	entry.UpdatedAt = time.Unix(updatedAt, 0)
+
+	if err := json.Unmarshal([]byte(subtasks), &entry.Subtasks); err != nil {
+		return nil, errors.Wrap(err, "while unmarshaling subtasks")
+	}
+
+	if err := json.Unmarshal([]byte(tags), &entry.Tags); err != nil {
+		return nil, errors.Wrap(err, "while unmarshaling tags")
+	}
+
+	return entry, nil
+}
+
+func scanEntries(rows *sql.Rows) ([]*Entry, error) {
+	entries := make([]*Entry, 0)
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "while scanning entry")
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// unixOrNil returns the timestamp in epoch or nil, which is stored as NULL.
+func unixOrNil(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return t.Unix()
+}
@@ -0,0 +1,53 @@
+package todo
+
+import (
+	"context"
+	"time"
+)
+
+// EntryStore keeps the todo lists of the channels. It is implemented by RedisTodoStore,
+// SQLTodoStore and MemoryTodoStore. It contains the Store used by the Notifier.
+type EntryStore interface {
+	Store
+
+	// GetEntry returns ErrEntryNotFound, if the entry does not exist.
+	GetEntry(ctx context.Context, channelID, listName, entryID string) (*Entry, error)
+	ListEntries(ctx context.Context, channelID, listName string) ([]string, error)
+	GetEntriesByTag(ctx context.Context, channelID, listName, tag string) (*List, error)
+	AddEntry(ctx context.Context, channelID, listName string, entry *Entry) (string, error)
+	UpdateEntry(ctx context.Context, channelID, listName string, entry *Entry) error
+	MoveEntry(ctx context.Context, channelID, listName string, from, to int) (*Entry, error)
+	TransferEntry(ctx context.Context, channelID, listName, entryID, toChannelID, toListName string) (*Entry, error)
+	TouchEntry(ctx context.Context, channelID, listName, entryID string) (*Entry, error)
+	RemoveEntry(ctx context.Context, channelID, listName, entryID string) error
+	CompleteEntry(ctx context.Context, channelID, listName, entryID, userID string) (*Entry, error)
+	RestoreEntry(ctx context.Context, channelID, entryID string) (*ArchivedEntry, error)
+	// ArchiveEntry stores an entry completed elsewhere, e.g. in a backup, in the archive as it is.
+	ArchiveEntry(ctx context.Context, channelID string, archived *ArchivedEntry) error
+
+	ListExists(ctx context.Context, channelID, listName string) (bool, error)
+	CreateList(ctx context.Context, channelID, listName string) error
+	RenameList(ctx context.Context, channelID, oldName, newName string) error
+	DeleteList(ctx context.Context, channelID, listName string) error
+
+	GetExpiryPolicy(ctx context.Context, channelID string) (ExpiryPolicy, error)
+	SetExpiryPolicy(ctx context.Context, channelID string, policy ExpiryPolicy) error
+	GetBoardMessageID(ctx context.Context, channelID string) (string, error)
+	SetBoardMessageID(ctx context.Context, channelID, messageID string) error
+}
+
+var (
+	_ EntryStore = &RedisTodoStore{}
+	_ EntryStore = &SQLTodoStore{}
+	_ EntryStore = &MemoryTodoStore{}
+)
+
+// expiresAt returns the time, when a key with the TTL set now expires, or nil for no TTL.
+func expiresAt(now time.Time, ttl time.Duration) *time.Time {
+	if ttl == 0 {
+		return nil
+	}
+
+	t := now.Add(ttl)
+	return &t
+}
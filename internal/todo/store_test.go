@@ -0,0 +1,230 @@
+package todo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/storetest"
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func entryTexts(list *todo.List) []string {
+	texts := make([]string, 0, len(list.Entries))
+	for _, entry := range list.Entries {
+		texts = append(texts, entry.Text)
+	}
+	return texts
+}
+
+func TestStore_Order(t *testing.T) {
+	storetest.RunTodoStores(t, func(t *testing.T, store todo.EntryStore) {
+		ctx := context.Background()
+
+		for _, entry := range []*todo.Entry{
+			{Text: "normal"},
+			{Text: "urgent", Priority: todo.PriorityUrgent},
+			{Text: "low", Priority: todo.PriorityLow},
+		} {
+			_, err := store.AddEntry(ctx, "ch", todo.DefaultList, entry)
+			require.NoError(t, err)
+		}
+
+		list, err := store.GetEntries(ctx, "ch", todo.DefaultList)
+		require.NoError(t, err)
+		require.Equal(t, []string{"urgent", "normal", "low"}, entryTexts(list))
+
+		moved, err := store.MoveEntry(ctx, "ch", todo.DefaultList, 2, 0)
+		require.NoError(t, err)
+		require.Equal(t, "low", moved.Text)
+
+		list, err = store.GetEntries(ctx, "ch", todo.DefaultList)
+		require.NoError(t, err)
+		require.Equal(t, []string{"low", "urgent", "normal"}, entryTexts(list))
+
+		_, err = store.MoveEntry(ctx, "ch", todo.DefaultList, 3, 0)
+		require.Equal(t, todo.ErrEntryNotFound, err)
+	})
+}
+
+func TestStore_CompleteAndRestore(t *testing.T) {
+	storetest.RunTodoStores(t, func(t *testing.T, store todo.EntryStore) {
+		ctx := context.Background()
+
+		require.NoError(t, store.CreateList(ctx, "ch", "backlog"))
+
+		ID, err := store.AddEntry(ctx, "ch", "backlog", &todo.Entry{Text: "deploy"})
+		require.NoError(t, err)
+
+		completed, err := store.CompleteEntry(ctx, "ch", "backlog", ID, "user")
+		require.NoError(t, err)
+		require.Equal(t, "user", completed.CompletedBy)
+
+		_, err = store.CompleteEntry(ctx, "ch", "backlog", ID, "user")
+		require.Equal(t, todo.ErrEntryNotFound, err)
+
+		archived, err := store.GetArchivedEntries(ctx, "ch", time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, archived, 1)
+		require.Equal(t, "backlog", archived[0].ListName)
+
+		require.NoError(t, store.DeleteList(ctx, "ch", "backlog"))
+
+		restored, err := store.RestoreEntry(ctx, "ch", ID)
+		require.NoError(t, err)
+		require.Equal(t, todo.DefaultList, restored.ListName)
+		require.Nil(t, restored.Entry.CompletedAt)
+
+		entry, err := store.GetEntry(ctx, "ch", todo.DefaultList, ID)
+		require.NoError(t, err)
+		require.Equal(t, "deploy", entry.Text)
+
+		_, err = store.RestoreEntry(ctx, "ch", ID)
+		require.Equal(t, todo.ErrEntryNotFound, err)
+	})
+}
+
+func TestStore_ArchiveEntry(t *testing.T) {
+	storetest.RunTodoStores(t, func(t *testing.T, store todo.EntryStore) {
+		ctx := context.Background()
+		completedAt := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+
+		require.NoError(t, store.ArchiveEntry(ctx, "ch", &todo.ArchivedEntry{
+			Entry: &todo.Entry{
+				ID:          "entryID",
+				Text:        "deploy",
+				CompletedAt: &completedAt,
+				CompletedBy: "user",
+			},
+			ListName: "backlog",
+		}))
+
+		archived, err := store.GetArchivedEntries(ctx, "ch", completedAt.Add(-time.Hour), time.Now())
+		require.NoError(t, err)
+		require.Len(t, archived, 1)
+		require.Equal(t, "entryID", archived[0].Entry.ID)
+		require.Equal(t, "backlog", archived[0].ListName)
+		require.True(t, completedAt.Equal(*archived[0].Entry.CompletedAt))
+
+		restored, err := store.RestoreEntry(ctx, "ch", "entryID")
+		require.NoError(t, err)
+		require.Equal(t, "deploy", restored.Entry.Text)
+	})
+}
+
+func TestStore_Lists(t *testing.T) {
+	storetest.RunTodoStores(t, func(t *testing.T, store todo.EntryStore) {
+		ctx := context.Background()
+
+		require.NoError(t, store.CreateList(ctx, "ch", "backlog"))
+		require.Equal(t, todo.ErrListExists, store.CreateList(ctx, "ch", "backlog"))
+		require.Equal(t, todo.ErrListExists, store.CreateList(ctx, "ch", todo.DefaultList))
+
+		_, err := store.AddEntry(ctx, "ch", "backlog", &todo.Entry{Text: "release #ops", Tags: []string{"ops"}})
+		require.NoError(t, err)
+
+		require.NoError(t, store.RenameList(ctx, "ch", "backlog", "sprint"))
+		require.Equal(t, todo.ErrListNotFound, store.RenameList(ctx, "ch", "backlog", "next"))
+		require.Equal(t, todo.ErrDefaultList, store.DeleteList(ctx, "ch", todo.DefaultList))
+
+		lists, err := store.GetLists(ctx, "ch")
+		require.NoError(t, err)
+		require.Equal(t, []string{todo.DefaultList, "sprint"}, lists)
+
+		tagged, err := store.GetEntriesByTag(ctx, "ch", "sprint", "ops")
+		require.NoError(t, err)
+		require.Equal(t, []string{"release #ops"}, entryTexts(tagged))
+
+		channels, err := store.GetAllChannelsWithTodo(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"ch"}, channels)
+
+		require.NoError(t, store.RenameList(ctx, "ch", "sprint", "next"))
+
+		tagged, err = store.GetEntriesByTag(ctx, "ch", "next", "ops")
+		require.NoError(t, err)
+		require.Equal(t, []string{"release #ops"}, entryTexts(tagged))
+
+		require.NoError(t, store.DeleteList(ctx, "ch", "next"))
+		require.NoError(t, store.CreateList(ctx, "ch", "next"))
+
+		tagged, err = store.GetEntriesByTag(ctx, "ch", "next", "ops")
+		require.NoError(t, err)
+		require.Empty(t, entryTexts(tagged))
+	})
+}
+
+// The SQL stores read the dates in the local timezone, so they are shown
+// in the channel timezone with In.
+func TestStore_DueDateInChannelLocation(t *testing.T) {
+	storetest.RunTodoStores(t, func(t *testing.T, store todo.EntryStore) {
+		ctx := context.Background()
+		location := time.FixedZone("UTC+05:30", 5*60*60+30*60)
+		dueDate := time.Date(2030, time.March, 1, 9, 15, 0, 0, location)
+
+		ID, err := store.AddEntry(ctx, "ch", todo.DefaultList, &todo.Entry{Text: "deploy", DueDate: &dueDate})
+		require.NoError(t, err)
+
+		entry, err := store.GetEntry(ctx, "ch", todo.DefaultList, ID)
+		require.NoError(t, err)
+		require.True(t, dueDate.Equal(*entry.DueDate))
+		require.Equal(t, "01.03.2030 09:15", entry.DueDate.In(location).Format("02.01.2006 15:04"))
+	})
+}
+
+func TestStore_ReturnsCopies(t *testing.T) {
+	storetest.RunTodoStores(t, func(t *testing.T, store todo.EntryStore) {
+		ctx := context.Background()
+
+		ID, err := store.AddEntry(ctx, "ch", todo.DefaultList, &todo.Entry{Text: "deploy"})
+		require.NoError(t, err)
+
+		entry, err := store.GetEntry(ctx, "ch", todo.DefaultList, ID)
+		require.NoError(t, err)
+		entry.Text = "changed"
+
+		entry, err = store.GetEntry(ctx, "ch", todo.DefaultList, ID)
+		require.NoError(t, err)
+		require.Equal(t, "deploy", entry.Text)
+	})
+}
+
+func TestStore_TransferAndRoundTrip(t *testing.T) {
+	storetest.RunTodoStores(t, func(t *testing.T, store todo.EntryStore) {
+		ctx := context.Background()
+		dueDate := time.Date(2022, 3, 4, 15, 0, 0, 0, time.UTC)
+
+		ID, err := store.AddEntry(ctx, "ch", todo.DefaultList, &todo.Entry{
+			Text:         "release #ops",
+			DueDate:      &dueDate,
+			Priority:     todo.PriorityHigh,
+			AssigneeID:   "user",
+			Subtasks:     todo.ParseSubtasks("tag, changelog"),
+			AutoComplete: true,
+			Tags:         []string{"ops", "backend"},
+		})
+		require.NoError(t, err)
+
+		entry, err := store.TransferEntry(ctx, "ch", todo.DefaultList, ID, "other", todo.DefaultList)
+		require.NoError(t, err)
+		require.Equal(t, ID, entry.ID)
+
+		_, err = store.GetEntry(ctx, "ch", todo.DefaultList, ID)
+		require.Equal(t, todo.ErrEntryNotFound, err)
+
+		entry, err = store.GetEntry(ctx, "other", todo.DefaultList, ID)
+		require.NoError(t, err)
+		require.True(t, dueDate.Equal(*entry.DueDate))
+		require.Equal(t, todo.PriorityHigh, entry.Priority)
+		require.Equal(t, "user", entry.AssigneeID)
+		require.Equal(t, 2, len(entry.Subtasks))
+		require.Equal(t, "changelog", entry.Subtasks[1].Text)
+		require.True(t, entry.AutoComplete)
+		require.Equal(t, []string{"ops", "backend"}, entry.Tags)
+
+		tagged, err := store.GetEntriesByTag(ctx, "other", todo.DefaultList, "#Backend")
+		require.NoError(t, err)
+		require.Equal(t, []string{"release #ops"}, entryTexts(tagged))
+	})
+}
@@ -0,0 +1,78 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// MaxSubtasks is the maximum number of subtasks in an entry. It is the number of options
+// in a Discord select menu, which is used to check them.
+const MaxSubtasks = 25
+
+// Subtask is an item of the checklist in an entry.
+type Subtask struct {
+	ID   string
+	Text string
+	Done bool
+}
+
+// ParseSubtasks parses a comma separated list of subtasks, e.g. "tag, changelog, helm bump".
+func ParseSubtasks(text string) []*Subtask {
+	subtasks := make([]*Subtask, 0)
+
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		subtasks = append(subtasks, &Subtask{
+			ID:   uuid.New().String(),
+			Text: item,
+		})
+	}
+
+	return subtasks
+}
+
+// AddSubtasks appends the subtasks to the checklist of the entry.
+func (e *Entry) AddSubtasks(subtasks []*Subtask) error {
+	if len(e.Subtasks)+len(subtasks) > MaxSubtasks {
+		return fmt.Errorf("entry can have at most %d subtasks", MaxSubtasks)
+	}
+
+	e.Subtasks = append(e.Subtasks, subtasks...)
+	return nil
+}
+
+// SubtaskProgress returns the number of done subtasks and all subtasks.
+func (e *Entry) SubtaskProgress() (int, int) {
+	done := 0
+	for _, subtask := range e.Subtasks {
+		if subtask.Done {
+			done++
+		}
+	}
+
+	return done, len(e.Subtasks)
+}
+
+// SetDoneSubtasks marks the subtasks with the IDs as done and all others as not done.
+func (e *Entry) SetDoneSubtasks(IDs []string) {
+	done := make(map[string]bool, len(IDs))
+	for _, ID := range IDs {
+		done[ID] = true
+	}
+
+	for _, subtask := range e.Subtasks {
+		subtask.Done = done[subtask.ID]
+	}
+}
+
+// IsChecklistDone returns true, if the entry has subtasks and all of them are done.
+func (e *Entry) IsChecklistDone() bool {
+	done, total := e.SubtaskProgress()
+	return total > 0 && done == total
+}
@@ -0,0 +1,107 @@
+package todo_test
+
+import (
+	"testing"
+
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSubtasks(t *testing.T) {
+	tt := map[string]struct {
+		input    string
+		expected []string
+	}{
+		"List": {
+			input:    "tag, changelog,helm bump",
+			expected: []string{"tag", "changelog", "helm bump"},
+		},
+		"EmptyItems": {
+			input:    " , tag,, ",
+			expected: []string{"tag"},
+		},
+		"Empty": {
+			input:    "",
+			expected: []string{},
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			subtasks := todo.ParseSubtasks(test.input)
+
+			texts := make([]string, 0, len(subtasks))
+			for _, subtask := range subtasks {
+				require.NotEmpty(t, subtask.ID)
+				require.False(t, subtask.Done)
+				texts = append(texts, subtask.Text)
+			}
+
+			require.Equal(t, test.expected, texts)
+		})
+	}
+}
+
+func TestEntry_AddSubtasks(t *testing.T) {
+	entry := &todo.Entry{}
+
+	require.NoError(t, entry.AddSubtasks(todo.ParseSubtasks("tag, changelog")))
+	require.Len(t, entry.Subtasks, 2)
+
+	tooMany := make([]*todo.Subtask, todo.MaxSubtasks-1)
+	require.Error(t, entry.AddSubtasks(tooMany))
+	require.Len(t, entry.Subtasks, 2)
+}
+
+func TestEntry_SetDoneSubtasks(t *testing.T) {
+	tt := map[string]struct {
+		done             []string
+		expectedDone     int
+		expectedComplete bool
+	}{
+		"Some": {
+			done:         []string{"1", "3"},
+			expectedDone: 2,
+		},
+		"All": {
+			done:             []string{"1", "2", "3"},
+			expectedDone:     3,
+			expectedComplete: true,
+		},
+		"None": {
+			done:         []string{},
+			expectedDone: 0,
+		},
+		"UnknownID": {
+			done:         []string{"4"},
+			expectedDone: 0,
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			entry := &todo.Entry{
+				Subtasks: []*todo.Subtask{
+					{ID: "1", Text: "tag", Done: true},
+					{ID: "2", Text: "changelog", Done: true},
+					{ID: "3", Text: "helm bump"},
+				},
+			}
+
+			entry.SetDoneSubtasks(test.done)
+
+			done, total := entry.SubtaskProgress()
+			require.Equal(t, test.expectedDone, done)
+			require.Equal(t, 3, total)
+			require.Equal(t, test.expectedComplete, entry.IsChecklistDone())
+		})
+	}
+}
+
+func TestEntry_IsChecklistDoneWithoutSubtasks(t *testing.T) {
+	require.False(t, (&todo.Entry{}).IsChecklistDone())
+}
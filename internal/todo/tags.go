@@ -0,0 +1,179 @@
+package todo
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var tagRegexp = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_-]+)`)
+
+// ParseTags returns the #tag tokens in the text, normalized and without duplicates.
+func ParseTags(text string) []string {
+	tags := make([]string, 0)
+
+	for _, match := range tagRegexp.FindAllStringSubmatch(text, -1) {
+		tags = MergeTags(tags, []string{match[1]})
+	}
+
+	return tags
+}
+
+// NormalizeTag returns the tag in lower case and without the leading #.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// ValidateTag checks, if the tag can be used, the same as a #tag in the text.
+// Tags are letters, digits, "-" and "_", with an optional leading #.
+func ValidateTag(tag string) error {
+	tag = NormalizeTag(tag)
+
+	match := tagRegexp.FindStringSubmatch("#" + tag)
+	if match == nil || match[1] != tag {
+		return errors.New("tag must be letters, digits, - or _")
+	}
+
+	return nil
+}
+
+// MergeTags returns the tags from both slices normalized and without duplicates, in the order of occurrence.
+func MergeTags(tags, other []string) []string {
+	seen := make(map[string]bool, len(tags)+len(other))
+	merged := make([]string, 0, len(tags)+len(other))
+
+	for _, tag := range append(append([]string{}, tags...), other...) {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		merged = append(merged, tag)
+	}
+
+	return merged
+}
+
+// HasTag checks, if the entry has the tag.
+func (e *Entry) HasTag(tag string) bool {
+	tag = NormalizeTag(tag)
+
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetText changes the text of the entry and the tags parsed from it. The tags,
+// which were not given in the previous text, are kept.
+func (e *Entry) SetText(text string) {
+	previous := make(map[string]bool)
+	for _, tag := range ParseTags(e.Text) {
+		previous[tag] = true
+	}
+
+	kept := make([]string, 0, len(e.Tags))
+	for _, tag := range e.Tags {
+		if !previous[tag] {
+			kept = append(kept, tag)
+		}
+	}
+
+	e.Text = text
+	e.Tags = MergeTags(ParseTags(text), kept)
+}
+
+// Filter selects entries. The zero value matches all entries.
+type Filter struct {
+	Tag string
+	// AssigneeID is the ID of the user, to whom the entries are assigned.
+	AssigneeID string
+	// Overdue selects only the overdue entries.
+	Overdue bool
+}
+
+// IsEmpty returns true, if the filter matches all entries.
+func (f *Filter) IsEmpty() bool {
+	return f.Tag == "" && f.AssigneeID == "" && !f.Overdue
+}
+
+// Matches checks, if the entry is selected by the filter.
+func (f *Filter) Matches(entry *Entry, now time.Time) bool {
+	if f.Tag != "" && !entry.HasTag(f.Tag) {
+		return false
+	}
+
+	if f.AssigneeID != "" && entry.AssigneeID != f.AssigneeID {
+		return false
+	}
+
+	if f.Overdue && !entry.IsOverdue(now) {
+		return false
+	}
+
+	return true
+}
+
+// Apply returns the entries selected by the filter, keeping their order.
+func (f *Filter) Apply(entries []*Entry, now time.Time) []*Entry {
+	filtered := make([]*Entry, 0, len(entries))
+	for _, entry := range entries {
+		if f.Matches(entry, now) {
+			filtered = append(filtered, entry)
+		}
+	}
+
+	return filtered
+}
+
+// TagGroup is a group of entries with the same tag.
+type TagGroup struct {
+	// Tag is empty for the entries without tags.
+	Tag     string
+	Entries []*Entry
+}
+
+// GroupByTag groups the entries by their tags, sorted by the tag. An entry with many tags
+// is in many groups. The entries without tags are in the last group. The entries keep their order.
+func GroupByTag(entries []*Entry) []*TagGroup {
+	groups := make(map[string]*TagGroup)
+	untagged := &TagGroup{}
+
+	for _, entry := range entries {
+		if len(entry.Tags) == 0 {
+			untagged.Entries = append(untagged.Entries, entry)
+			continue
+		}
+
+		for _, tag := range entry.Tags {
+			group, ok := groups[tag]
+			if !ok {
+				group = &TagGroup{Tag: tag}
+				groups[tag] = group
+			}
+
+			group.Entries = append(group.Entries, entry)
+		}
+	}
+
+	result := make([]*TagGroup, 0, len(groups)+1)
+	for _, group := range groups {
+		result = append(result, group)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Tag < result[j].Tag
+	})
+
+	if len(untagged.Entries) > 0 {
+		result = append(result, untagged)
+	}
+
+	return result
+}
@@ -0,0 +1,155 @@
+package todo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Trojan295/organizer-bot/internal/todo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTags(t *testing.T) {
+	tt := map[string]struct {
+		input    string
+		expected []string
+	}{
+		"Tags": {
+			input:    "release 1.4 #Backend #ops-team",
+			expected: []string{"backend", "ops-team"},
+		},
+		"Duplicates": {
+			input:    "#ops fix #OPS",
+			expected: []string{"ops"},
+		},
+		"NotATag": {
+			input:    "fix issue#12 and C# code #",
+			expected: []string{},
+		},
+		"Unicode": {
+			input:    "#zakupy mleko",
+			expected: []string{"zakupy"},
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expected, todo.ParseTags(test.input))
+		})
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	tt := map[string]struct {
+		input string
+		valid bool
+	}{
+		"Tag":          {input: "ops-team", valid: true},
+		"WithHash":     {input: "#Backend", valid: true},
+		"Unicode":      {input: "zakupy_ąę", valid: true},
+		"Empty":        {input: "#", valid: false},
+		"Pattern":      {input: "ops*", valid: false},
+		"KeySeparator": {input: "ops:team", valid: false},
+		"Brackets":     {input: "[ops]", valid: false},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			err := todo.ValidateTag(test.input)
+			if test.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestEntry_SetText(t *testing.T) {
+	entry := &todo.Entry{
+		Text: "deploy #ops",
+		Tags: []string{"ops", "release"},
+	}
+
+	entry.SetText("deploy #backend")
+
+	require.Equal(t, "deploy #backend", entry.Text)
+	require.Equal(t, []string{"backend", "release"}, entry.Tags)
+}
+
+func TestFilter_Apply(t *testing.T) {
+	now := time.Date(2021, 12, 20, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	entries := []*todo.Entry{
+		{ID: "1", Tags: []string{"ops"}, AssigneeID: "alice", DueDate: &past},
+		{ID: "2", Tags: []string{"ops", "backend"}, AssigneeID: "bob", DueDate: &future},
+		{ID: "3", AssigneeID: "alice"},
+	}
+
+	tt := map[string]struct {
+		filter   todo.Filter
+		expected []string
+	}{
+		"Empty": {
+			filter:   todo.Filter{},
+			expected: []string{"1", "2", "3"},
+		},
+		"Tag": {
+			filter:   todo.Filter{Tag: "#OPS"},
+			expected: []string{"1", "2"},
+		},
+		"Assignee": {
+			filter:   todo.Filter{AssigneeID: "alice"},
+			expected: []string{"1", "3"},
+		},
+		"Overdue": {
+			filter:   todo.Filter{Overdue: true},
+			expected: []string{"1"},
+		},
+		"Combined": {
+			filter:   todo.Filter{Tag: "ops", AssigneeID: "bob"},
+			expected: []string{"2"},
+		},
+	}
+
+	for name, test := range tt {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			filtered := test.filter.Apply(entries, now)
+
+			IDs := make([]string, 0, len(filtered))
+			for _, entry := range filtered {
+				IDs = append(IDs, entry.ID)
+			}
+
+			require.Equal(t, test.expected, IDs)
+		})
+	}
+}
+
+func TestGroupByTag(t *testing.T) {
+	entries := []*todo.Entry{
+		{ID: "1", Tags: []string{"ops"}},
+		{ID: "2"},
+		{ID: "3", Tags: []string{"ops", "backend"}},
+	}
+
+	groups := todo.GroupByTag(entries)
+
+	require.Len(t, groups, 3)
+
+	require.Equal(t, "backend", groups[0].Tag)
+	require.Equal(t, []*todo.Entry{entries[2]}, groups[0].Entries)
+
+	require.Equal(t, "ops", groups[1].Tag)
+	require.Equal(t, []*todo.Entry{entries[0], entries[2]}, groups[1].Entries)
+
+	require.Equal(t, "", groups[2].Tag)
+	require.Equal(t, []*todo.Entry{entries[1]}, groups[2].Entries)
+}